@@ -0,0 +1,269 @@
+package bibformat
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+import (
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/bibhash"
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/model"
+)
+
+// Supported bibliographic exchange formats. CSL-JSON is supported for export only.
+const (
+	FormatBibTeX  = "bibtex"
+	FormatRIS     = "ris"
+	FormatCSLJSON = "csljson"
+)
+
+// Media types and file extensions of the supported exchange formats.
+var (
+	mediaTypes = map[string]string{
+		FormatBibTeX:  "application/x-bibtex",
+		FormatRIS:     "application/x-research-info-systems",
+		FormatCSLJSON: "application/vnd.citationstyles.csl+json",
+	}
+	fileExtensions = map[string]string{
+		FormatBibTeX:  ".bib",
+		FormatRIS:     ".ris",
+		FormatCSLJSON: ".json",
+	}
+)
+
+// Further media types, that are commonly used for the supported exchange formats (e.g. by DOI content negotiation).
+var mediaTypeAliases = map[string]string{
+	"text/x-bibtex":                FormatBibTeX,
+	"text/x-bibliography":          FormatBibTeX,
+	"application/citeproc+json":    FormatCSLJSON,
+	"application/json":             FormatCSLJSON,
+	"application/x-ris":            FormatRIS,
+	"text/x-research-info-systems": FormatRIS,
+}
+
+var (
+	yearPattern = regexp.MustCompile(`\b(1[5-9]|20)[0-9]{2}\b`)
+	doiPattern  = regexp.MustCompile(`10\.[0-9]{4,9}/\S+`)
+)
+
+// FormatByFilename guesses the exchange format of a file from its extension (".bib" or ".bibtex" for BibTeX, ".ris"
+// for RIS). Returns an error for unknown extensions.
+func FormatByFilename(filename string) (format string, err error) {
+
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".bib", ".bibtex":
+		return FormatBibTeX, nil
+	case ".ris":
+		return FormatRIS, nil
+	}
+
+	return "", fmt.Errorf("Unknown bibliographic format of file '%s'. Expected extension '.bib', '.bibtex' or '.ris'.", filename)
+}
+
+// FormatByMediaType negotiates an export format from the value of an HTTP Accept header (e.g.
+// "application/x-research-info-systems, application/x-bibtex;q=0.5"). Media types are considered in the order of their
+// quality values. Wildcards and an empty header select BibTeX. Returns an empty string if none of the accepted media
+// types is supported.
+func FormatByMediaType(accept string) string {
+
+	if strings.TrimSpace(accept) == "" {
+		return FormatBibTeX
+	}
+
+	type acceptedType struct {
+		mediaType string
+		quality   float64
+	}
+
+	var accepted []acceptedType
+
+	for _, part := range strings.Split(accept, ",") {
+
+		params := strings.Split(part, ";")
+		t := acceptedType{mediaType: strings.ToLower(strings.TrimSpace(params[0])), quality: 1}
+
+		for _, param := range params[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if q, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					t.quality = q
+				}
+			}
+		}
+
+		if t.mediaType != "" && t.quality > 0 {
+			accepted = append(accepted, t)
+		}
+	}
+
+	sort.SliceStable(accepted, func(i, j int) bool { return accepted[i].quality > accepted[j].quality })
+
+	for _, t := range accepted {
+
+		if t.mediaType == "*/*" || t.mediaType == "application/*" || t.mediaType == "text/*" {
+			return FormatBibTeX
+		}
+
+		for format, mediaType := range mediaTypes {
+			if mediaType == t.mediaType {
+				return format
+			}
+		}
+
+		if format, ok := mediaTypeAliases[t.mediaType]; ok {
+			return format
+		}
+	}
+
+	return ""
+}
+
+// MediaType returns the media type of an export format (e.g. "application/x-bibtex" for BibTeX), or an empty string
+// for unknown formats.
+func MediaType(format string) string {
+	return mediaTypes[format]
+}
+
+// FileExtension returns the common file extension of an export format (e.g. ".bib" for BibTeX), or an empty string
+// for unknown formats.
+func FileExtension(format string) string {
+	return fileExtensions[format]
+}
+
+// Write exports records in the specified format (FormatBibTeX, FormatRIS or FormatCSLJSON).
+func Write(w io.Writer, records []model.Record, format string) error {
+
+	switch format {
+	case FormatBibTeX:
+		return WriteBibTeX(w, records)
+	case FormatRIS:
+		return WriteRIS(w, records)
+	case FormatCSLJSON:
+		return WriteCSLJSON(w, records)
+	}
+
+	return fmt.Errorf("Unsupported bibliographic format '%s'.", format)
+}
+
+// NormalizeDoi extracts a DOI (e.g. "10.1000/xyz") from an identifier like "doi:10.1000/XYZ" or
+// "https://doi.org/10.1000/XYZ" and converts it to lower case, since DOIs are case-insensitive.
+// Returns an empty string if the identifier does not contain a DOI.
+func NormalizeDoi(identifier string) string {
+	return strings.ToLower(strings.TrimRight(doiPattern.FindString(identifier), ".,;"))
+}
+
+// parseYear returns the first plausible year of publication within a date (e.g. "2019/05/01" or "May 2019").
+func parseYear(date string) int64 {
+
+	year, _ := strconv.ParseInt(yearPattern.FindString(date), 10, 64)
+
+	return year
+}
+
+// splitKeywords splits a list of keywords that is separated by commas or semicolons.
+func splitKeywords(keywords string) (subjects []string) {
+
+	for _, k := range strings.FieldsFunc(keywords, func(r rune) bool { return r == ',' || r == ';' }) {
+		if k = strings.TrimSpace(k); k != "" {
+			subjects = append(subjects, k)
+		}
+	}
+
+	return
+}
+
+// validate completes a parsed record and checks that it has the non-optional attributes title and year.
+// Duplicate subjects are removed.
+func validate(rec *model.Record, entry string) error {
+
+	if rec.Title == "" {
+		return fmt.Errorf("Entry '%s' has no title.", entry)
+	}
+
+	if rec.Year == 0 {
+		return fmt.Errorf("Entry '%s' has no year of publication.", entry)
+	}
+
+	known := make(map[string]bool)
+	subjects := rec.Subjects
+	rec.Subjects = nil
+
+	for _, s := range subjects {
+		if !known[s] {
+			known[s] = true
+			rec.Subjects = append(rec.Subjects, s)
+		}
+	}
+
+	return nil
+}
+
+// addLink sets a URL either as link to the PDF document or as link to the repository frontpage of a record,
+// unless the respective link is already set.
+func addLink(rec *model.Record, url string) {
+
+	url = strings.TrimSpace(url)
+	lowerURL := strings.ToLower(url)
+
+	switch {
+	case !strings.HasPrefix(lowerURL, "http://") && !strings.HasPrefix(lowerURL, "https://"):
+		return
+	case strings.HasSuffix(lowerURL, ".pdf"):
+		if rec.PDFLink == "" {
+			rec.PDFLink = url
+		}
+	case rec.RepositoryLink == "":
+		rec.RepositoryLink = url
+	}
+}
+
+// citationKeys builds a unique citation key for each record from the last name of its first creator and its year of
+// publication (e.g. "doe2019"). Keys that would be ambiguous get a letter suffix (e.g. "doe2019a" and "doe2019b").
+func citationKeys(records []model.Record) (keys []string) {
+
+	count := make(map[string]int)
+
+	for _, rec := range records {
+
+		name := "anonymous"
+		if len(rec.Creators) > 0 {
+			if n := strings.Replace(bibhash.Normalize(rec.Creators[0].LastName), " ", "", -1); n != "" {
+				name = n
+			}
+		}
+
+		key := name + strconv.FormatInt(rec.Year, 10)
+		keys = append(keys, key)
+		count[key]++
+	}
+
+	used := make(map[string]int)
+
+	for i, key := range keys {
+
+		if count[key] < 2 {
+			continue
+		}
+
+		n := used[key]
+		used[key]++
+
+		if n < 26 {
+			keys[i] = key + string('a'+rune(n))
+		} else {
+			keys[i] = key + "_" + strconv.Itoa(n+1)
+		}
+	}
+
+	return keys
+}
+
+// singleLine collapses all whitespace of a value, including line breaks, to single spaces.
+func singleLine(value string) string {
+	return strings.Join(strings.Fields(value), " ")
+}
@@ -0,0 +1,259 @@
+package bibformat
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+import (
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/model"
+)
+
+const testBibTeX = `
+This text is a comment, as is the following entry.
+@comment{ignored, title = {Ignored}}
+
+@string{ jfe = "Journal of Financial " # {Economics} }
+
+@Article{lambertini2017,
+  author    = {Lambertini, Luisa and Uysal, Pinar and Nuguer Victoria},
+  title     = {Mortgage Default in an Estimated Model of the {U.S.} Housing Market},
+  journal   = jfe,
+  year      = 2017,
+  month     = jun,
+  doi       = {https://doi.org/10.1016/J.JEDC.2017.05.001},
+  url       = {http://hdl.handle.net/10419/174459},
+  keywords  = {Banks; Financial Crisis, Banks},
+  abstract  = "This paper models the housing sector -- mortgages and {"}default{"}."
+}
+
+@techreport(bialek2015,
+  author = "Bia{\l}ek, Natalia and M\"{u}ller, J{\'e}r{\^o}me and others",
+  title  = "Causes of the outbreak of the Eurozone crisis",
+  date   = {2015-03-01},
+  url    = {https://www.econstor.eu/bitstream/10419/1/paper.pdf}
+)
+
+@phdthesis{notitle, author = {Doe, John}, year = {2019}}
+`
+
+const testRIS = "\uFEFFTY  - JOUR\r\n" + `TI  - Mortgage default in an estimated model of the U.S. housing market
+AU  - Lambertini, Luisa
+AU  - Uysal, Pinar
+PY  - 2017/06/01/
+DO  - 10.1016/j.jedc.2017.05.001
+KW  - Banks
+KW  - Financial Crisis
+AB  - This paper models the housing sector,
+  mortgages and default.
+UR  - http://hdl.handle.net/10419/174459
+L1  - https://www.econstor.eu/bitstream/10419/174459/1/2017-06.pdf
+ER  -
+
+TY  - THES
+T1  - A thesis
+A1  - Doe, J.
+Y1  - 2019
+ER  -
+
+TY  - GEN
+AU  - Doe, John
+PY  - 2019
+ER  -
+`
+
+func TestReadBibTeX(t *testing.T) {
+
+	records, err := ReadBibTeX(strings.NewReader(testBibTeX))
+	if err != nil {
+		t.Errorf("Unexpected error. %s", err)
+		return
+	}
+
+	if len(records) != 2 {
+		t.Errorf("Expected %d records but got %d.", 2, len(records))
+		return
+	}
+
+	recA, recB := records[0], records[1]
+
+	if recA.Title != "Mortgage Default in an Estimated Model of the U.S. Housing Market" || recA.Year != 2017 ||
+		recA.Type != model.RecordTypeArticle || recA.Doi != "10.1016/j.jedc.2017.05.001" {
+		t.Errorf("Unexpected record field values %+v.", recA)
+		return
+	}
+
+	if recA.Abstract != `This paper models the housing sector – mortgages and "default".` {
+		t.Errorf("Unexpected abstract '%s'.", recA.Abstract)
+		return
+	}
+
+	if len(recA.Creators) != 3 || recA.Creators[0] != (model.Creator{FirstName: "Luisa", LastName: "Lambertini"}) ||
+		recA.Creators[2] != (model.Creator{FirstName: "Nuguer", LastName: "Victoria"}) {
+		t.Errorf("Unexpected creators %+v.", recA.Creators)
+		return
+	}
+
+	if len(recA.Subjects) != 2 || recA.Subjects[1] != "Financial Crisis" || recA.RepositoryLink != "http://hdl.handle.net/10419/174459" {
+		t.Errorf("Unexpected subjects %v or link '%s'.", recA.Subjects, recA.RepositoryLink)
+		return
+	}
+
+	if recB.Type != model.RecordTypeReport || recB.Year != 2015 || recB.PDFLink == "" || len(recB.Creators) != 2 {
+		t.Errorf("Unexpected record field values %+v.", recB)
+		return
+	}
+
+	if recB.Creators[0].LastName != "Białek" || recB.Creators[1] != (model.Creator{FirstName: "Jérôme", LastName: "Müller"}) {
+		t.Errorf("Unexpected decoding of creators %+v.", recB.Creators)
+		return
+	}
+
+	// Syntax errors are reported
+
+	for _, broken := range []string{
+		`@article{broken, title = {Unbalanced}`,
+		`@string(0={}`,
+		`@string{a = "b"`,
+		`@article{broken, title = {Escaped\`,
+	} {
+		if _, err = ReadBibTeX(strings.NewReader(broken)); err == nil {
+			t.Errorf("Expected error for unclosed entry '%s'.", broken)
+			return
+		}
+	}
+}
+
+func TestReadRIS(t *testing.T) {
+
+	records, err := ReadRIS(strings.NewReader(testRIS))
+	if err != nil {
+		t.Errorf("Unexpected error. %s", err)
+		return
+	}
+
+	if len(records) != 2 {
+		t.Errorf("Expected %d records but got %d.", 2, len(records))
+		return
+	}
+
+	recA, recB := records[0], records[1]
+
+	if recA.Title != "Mortgage default in an estimated model of the U.S. housing market" || recA.Year != 2017 ||
+		recA.Type != model.RecordTypeArticle || recA.Doi != "10.1016/j.jedc.2017.05.001" || len(recA.Creators) != 2 {
+		t.Errorf("Unexpected record field values %+v.", recA)
+		return
+	}
+
+	if recA.Abstract != "This paper models the housing sector, mortgages and default." || len(recA.Subjects) != 2 ||
+		recA.RepositoryLink == "" || recA.PDFLink == "" {
+		t.Errorf("Unexpected record field values %+v.", recA)
+		return
+	}
+
+	if recB.Type != model.RecordTypeThesis || recB.Creators[0] != (model.Creator{FirstName: "J.", LastName: "Doe"}) {
+		t.Errorf("Unexpected record field values %+v.", recB)
+		return
+	}
+
+	// References must be closed
+
+	if _, err = ReadRIS(strings.NewReader("TY  - JOUR\nTI  - Unclosed\n")); err == nil {
+		t.Errorf("Expected error for unclosed reference.")
+		return
+	}
+}
+
+func TestWrite(t *testing.T) {
+
+	records := []model.Record{
+		{
+			Title:          "Prices & quantities: 100% of {braces}",
+			Type:           model.RecordTypeReport,
+			Year:           2019,
+			Abstract:       "An abstract\nwith line breaks.",
+			Doi:            "10.1000/abc_1",
+			RepositoryLink: "http://hdl.handle.net/10419/1",
+			Creators:       []model.Creator{{FirstName: "Jérôme", LastName: "Müller"}, {FirstName: "Jane", LastName: "Roe"}},
+			Subjects:       []string{"Banks", "Financial Crisis"},
+		},
+		{
+			Title:    "A second paper",
+			Type:     model.RecordTypePaper,
+			Year:     2019,
+			PDFLink:  "https://www.econstor.eu/bitstream/10419/2/paper.pdf",
+			Creators: []model.Creator{{FirstName: "J.", LastName: "Muller"}},
+		},
+	}
+
+	// Perform test #1: records survive a round trip through BibTeX and RIS
+
+	for _, format := range []string{FormatBibTeX, FormatRIS} {
+
+		var buf bytes.Buffer
+
+		if err := Write(&buf, records, format); err != nil {
+			t.Errorf("Unexpected error. %s", err)
+			return
+		}
+
+		var imported []model.Record
+		var err error
+
+		if format == FormatBibTeX {
+			imported, err = ReadBibTeX(&buf)
+		} else {
+			imported, err = ReadRIS(&buf)
+		}
+
+		if err != nil {
+			t.Errorf("Unexpected error. %s", err)
+			return
+		}
+
+		records[0].Abstract = "An abstract with line breaks."
+
+		if !reflect.DeepEqual(imported, records) {
+			t.Errorf("Expected records to survive a round trip through %s, but got %+v.", format, imported)
+			return
+		}
+	}
+
+	// Perform test #2: CSL-JSON items have unique IDs
+
+	var buf bytes.Buffer
+	var items []cslItem
+
+	if err := WriteCSLJSON(&buf, records); err != nil {
+		t.Errorf("Unexpected error. %s", err)
+		return
+	}
+
+	if err := json.Unmarshal(buf.Bytes(), &items); err != nil || len(items) != 2 {
+		t.Errorf("Expected %d CSL-JSON items but got '%s'. %v", 2, buf.String(), err)
+		return
+	}
+
+	if items[0].Id != "muller2019a" || items[1].Id != "muller2019b" || items[0].Type != "report" || items[1].URL == "" {
+		t.Errorf("Unexpected CSL-JSON items %+v.", items)
+		return
+	}
+
+	// Perform test #3: negotiate formats via media types
+
+	for accept, format := range map[string]string{
+		"":          FormatBibTeX,
+		"*/*":       FormatBibTeX,
+		"text/html": "",
+		"application/x-bibtex;q=0.5, application/vnd.citationstyles.csl+json": FormatCSLJSON,
+		"text/html, application/x-research-info-systems;q=0.1":                FormatRIS,
+	} {
+		if f := FormatByMediaType(accept); f != format {
+			t.Errorf("Expected format '%s' for media types '%s' but got '%s'.", format, accept, f)
+			return
+		}
+	}
+}
@@ -0,0 +1,619 @@
+package bibformat
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+import (
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/model"
+	"golang.org/x/text/unicode/norm"
+)
+
+// bibtexEntry defines a parsed BibTeX entry with its lower case entry type (e.g. "article"), its citation key and its
+// fields. Field names are in lower case, field values are still LaTeX encoded.
+type bibtexEntry struct {
+	entryType string
+	key       string
+	fields    map[string]string
+}
+
+// bibtexParser implements a parser for BibTeX databases, that supports string macros, concatenation of values and
+// nested braces.
+type bibtexParser struct {
+	text   string
+	pos    int
+	macros map[string]string
+}
+
+// Combining characters of LaTeX accent commands (e.g. \"a or \'{e}).
+var latexAccents = map[string]rune{
+	"\"": '\u0308', "'": '\u0301', "`": '\u0300', "^": '\u0302', "~": '\u0303', "=": '\u0304', ".": '\u0307',
+	"u": '\u0306', "v": '\u030C', "H": '\u030B', "c": '\u0327', "k": '\u0328', "r": '\u030A', "d": '\u0323',
+}
+
+// Characters of LaTeX commands for special letters and escaped symbols.
+var latexSymbols = map[string]string{
+	"ss": "ß", "o": "ø", "O": "Ø", "ae": "æ", "AE": "Æ", "oe": "œ", "OE": "Œ", "aa": "å", "AA": "Å", "l": "ł", "L": "Ł",
+	"i": "ı", "j": "ȷ", "&": "&", "%": "%", "$": "$", "_": "_", "#": "#", "{": "{", "}": "}", " ": " ",
+}
+
+// ReadBibTeX parses BibTeX entries and maps them to GoZer's record model. Entries without title or year of
+// publication are skipped. Comments, preambles and string macros are not considered as entries.
+func ReadBibTeX(r io.Reader) (records []model.Record, err error) {
+
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("Could not read BibTeX data. %s", err)
+	}
+
+	p := bibtexParser{text: string(data), macros: defaultBibTeXMacros()}
+
+	for {
+		entry, ok, err := p.next()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			break
+		}
+
+		rec, mapErr := entry.toRecord()
+		if mapErr != nil {
+			log.Printf("Skipping imported record. %s", mapErr)
+			continue
+		}
+
+		records = append(records, rec)
+	}
+
+	return records, nil
+}
+
+// defaultBibTeXMacros returns the predefined string macros for the names of months.
+func defaultBibTeXMacros() map[string]string {
+
+	macros := make(map[string]string)
+
+	for i, m := range []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"} {
+		macros[m] = fmt.Sprintf("%d", i+1)
+	}
+
+	return macros
+}
+
+// toRecord maps the fields of a BibTeX entry to GoZer's record model.
+func (e *bibtexEntry) toRecord() (rec model.Record, err error) {
+
+	rec.Title = decodeLaTeX(e.fields["title"])
+	rec.Abstract = decodeLaTeX(e.fields["abstract"])
+	rec.Type = bibtexRecordType(e.entryType)
+	rec.Doi = NormalizeDoi(e.fields["doi"])
+
+	if rec.Year = parseYear(e.fields["year"]); rec.Year == 0 {
+		rec.Year = parseYear(e.fields["date"])
+	}
+
+	for _, name := range splitBibTeXNames(e.fields["author"]) {
+		if c, ok := parseBibTeXName(name); ok {
+			rec.Creators = append(rec.Creators, c)
+		}
+	}
+
+	rec.Subjects = splitKeywords(decodeLaTeX(e.fields["keywords"]))
+
+	addLink(&rec, decodeLaTeX(e.fields["url"]))
+	addLink(&rec, decodeLaTeX(e.fields["pdf"]))
+
+	return rec, validate(&rec, e.key)
+}
+
+// bibtexRecordType maps BibTeX entry types to the numerical keys of the supported record types.
+func bibtexRecordType(entryType string) int64 {
+
+	switch entryType {
+	case "article":
+		return model.RecordTypeArticle
+	case "book", "inbook", "incollection", "booklet":
+		return model.RecordTypeBook
+	case "inproceedings", "conference", "proceedings", "unpublished":
+		return model.RecordTypePaper
+	case "techreport", "report":
+		return model.RecordTypeReport
+	case "phdthesis", "mastersthesis", "thesis":
+		return model.RecordTypeThesis
+	}
+
+	return model.RecordTypeOther
+}
+
+// next parses the next entry of the BibTeX database. Returns false if there are no more entries.
+func (p *bibtexParser) next() (entry bibtexEntry, ok bool, err error) {
+
+	for {
+		// Everything outside of entries is considered as comment.
+		at := strings.IndexByte(p.text[p.pos:], '@')
+		if at < 0 {
+			return entry, false, nil
+		}
+		p.pos += at + 1
+
+		entryType := strings.ToLower(p.readIdentifier())
+
+		p.skipSpace()
+		if p.pos >= len(p.text) || (p.text[p.pos] != '{' && p.text[p.pos] != '(') {
+			continue // '@' within a comment
+		}
+
+		closing := byte('}')
+		if p.text[p.pos] == '(' {
+			closing = ')'
+		}
+		p.pos++
+
+		switch entryType {
+		case "comment", "preamble":
+			if _, err = p.readBalanced(closing); err != nil {
+				return entry, false, err
+			}
+			continue
+		case "string":
+			name, value, err := p.readField()
+			if err != nil {
+				return entry, false, err
+			}
+			p.skipSpace()
+			if p.pos >= len(p.text) || p.text[p.pos] != closing {
+				return entry, false, fmt.Errorf("BibTeX string macro '%s' is not closed.", name)
+			}
+			p.pos++
+			p.macros[name] = value
+			continue
+		}
+
+		entry = bibtexEntry{entryType: entryType, fields: make(map[string]string)}
+
+		p.skipSpace()
+		comma := strings.IndexByte(p.text[p.pos:], ',')
+		if comma < 0 {
+			return entry, false, fmt.Errorf("BibTeX entry of type '%s' at position %d has no citation key.", entryType, p.pos)
+		}
+		entry.key = strings.TrimSpace(p.text[p.pos : p.pos+comma])
+		p.pos += comma + 1
+
+		for {
+			p.skipSpace()
+
+			if p.pos >= len(p.text) {
+				return entry, false, fmt.Errorf("BibTeX entry '%s' is not closed.", entry.key)
+			}
+
+			if p.text[p.pos] == closing {
+				p.pos++
+				return entry, true, nil
+			}
+
+			if p.text[p.pos] == ',' {
+				p.pos++
+				continue
+			}
+
+			name, value, err := p.readField()
+			if err != nil {
+				return entry, false, fmt.Errorf("Could not parse BibTeX entry '%s'. %s", entry.key, err)
+			}
+
+			entry.fields[name] = value
+		}
+	}
+}
+
+// readField parses a field assignment (e.g. 'title = {A title} # " and more"').
+func (p *bibtexParser) readField() (name string, value string, err error) {
+
+	p.skipSpace()
+	name = strings.ToLower(p.readIdentifier())
+
+	p.skipSpace()
+	if name == "" || p.pos >= len(p.text) || p.text[p.pos] != '=' {
+		return "", "", fmt.Errorf("Expected field assignment at position %d.", p.pos)
+	}
+	p.pos++
+
+	for {
+		p.skipSpace()
+
+		if p.pos >= len(p.text) {
+			return "", "", fmt.Errorf("Expected value of field '%s'.", name)
+		}
+
+		switch c := p.text[p.pos]; {
+		case c == '{':
+			p.pos++
+			part, err := p.readBalanced('}')
+			if err != nil {
+				return "", "", err
+			}
+			value += part
+		case c == '"':
+			p.pos++
+			part, err := p.readQuoted()
+			if err != nil {
+				return "", "", err
+			}
+			value += part
+		default:
+			ident := p.readIdentifier()
+			if ident == "" {
+				return "", "", fmt.Errorf("Unexpected character '%c' in value of field '%s'.", c, name)
+			}
+			if macro, ok := p.macros[strings.ToLower(ident)]; ok {
+				value += macro
+			} else {
+				value += ident
+			}
+		}
+
+		p.skipSpace()
+		if p.pos < len(p.text) && p.text[p.pos] == '#' {
+			p.pos++
+			continue
+		}
+
+		return name, value, nil
+	}
+}
+
+// readBalanced returns the text up to the closing character, that is not enclosed by braces.
+// The position is moved behind the closing character.
+func (p *bibtexParser) readBalanced(closing byte) (text string, err error) {
+
+	depth := 0
+	start := p.pos
+
+	for ; p.pos < len(p.text); p.pos++ {
+		switch c := p.text[p.pos]; {
+		case c == '\\' && p.pos+1 < len(p.text):
+			p.pos++
+		case c == closing && depth == 0:
+			text = p.text[start:p.pos]
+			p.pos++
+			return text, nil
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+		}
+	}
+
+	return "", fmt.Errorf("Missing '%c' for text starting at position %d.", closing, start)
+}
+
+// readQuoted returns the text up to the closing quotation mark, that is not enclosed by braces.
+func (p *bibtexParser) readQuoted() (text string, err error) {
+	return p.readBalanced('"')
+}
+
+// readIdentifier returns the identifier at the current position. Identifiers may contain all printable characters
+// except for whitespace and the special characters of BibTeX.
+func (p *bibtexParser) readIdentifier() string {
+
+	start := p.pos
+
+	for p.pos < len(p.text) && !strings.ContainsRune(" \t\r\n{}()\",=#%@", rune(p.text[p.pos])) {
+		p.pos++
+	}
+
+	return p.text[start:p.pos]
+}
+
+// skipSpace moves the position behind any whitespace.
+func (p *bibtexParser) skipSpace() {
+
+	for p.pos < len(p.text) && strings.ContainsRune(" \t\r\n", rune(p.text[p.pos])) {
+		p.pos++
+	}
+}
+
+// splitBibTeXNames splits a list of names, that is separated by the word "and" outside of braces.
+func splitBibTeXNames(names string) (list []string) {
+
+	depth, start := 0, 0
+
+	for i := 0; i < len(names); i++ {
+		switch names[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case ' ', '\t', '\r', '\n':
+			if depth == 0 && i+5 <= len(names) && strings.EqualFold(names[i+1:i+4], "and") && strings.ContainsRune(" \t\r\n", rune(names[i+4])) {
+				list = append(list, names[start:i])
+				start = i + 5
+				i += 4
+			}
+		}
+	}
+
+	return append(list, names[start:])
+}
+
+// parseBibTeXName splits a name, given either as "Doe, John", "Doe, Jr., John" or "John Doe", into first and last
+// name. Names enclosed by braces (e.g. "{World Bank}") are taken as last name. The name "others" is skipped.
+func parseBibTeXName(name string) (c model.Creator, ok bool) {
+
+	name = strings.TrimSpace(name)
+
+	if name == "" || name == "others" {
+		return c, false
+	}
+
+	// Split by commas outside of braces.
+
+	var parts []string
+	depth, start := 0, 0
+
+	for i := 0; i < len(name); i++ {
+		switch name[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, name[start:i])
+				start = i + 1
+			}
+		}
+	}
+	parts = append(parts, name[start:])
+
+	switch len(parts) {
+	case 1:
+		words := splitOutsideBraces(parts[0])
+		c.LastName = words[len(words)-1]
+		c.FirstName = strings.Join(words[:len(words)-1], " ")
+	default:
+		c.LastName = parts[0]
+		c.FirstName = parts[len(parts)-1]
+	}
+
+	c.FirstName = decodeLaTeX(c.FirstName)
+	c.LastName = decodeLaTeX(c.LastName)
+
+	return c, c.LastName != ""
+}
+
+// splitOutsideBraces splits a text into words that are separated by whitespace outside of braces.
+func splitOutsideBraces(text string) (words []string) {
+
+	depth, start := 0, -1
+
+	for i, r := range text {
+		switch {
+		case r == '{':
+			depth++
+		case r == '}':
+			depth--
+		case unicode.IsSpace(r) && depth == 0:
+			if start >= 0 {
+				words = append(words, text[start:i])
+				start = -1
+			}
+			continue
+		}
+		if start < 0 {
+			start = i
+		}
+	}
+
+	if start >= 0 {
+		words = append(words, text[start:])
+	}
+
+	if len(words) == 0 {
+		words = []string{""}
+	}
+
+	return
+}
+
+// decodeLaTeX converts LaTeX encoded text to plain Unicode text. Accents and special letters are converted to their
+// Unicode characters, other commands (e.g. \emph) and braces are removed, and whitespace is collapsed.
+func decodeLaTeX(text string) string {
+
+	var b strings.Builder
+
+	runes := []rune(text)
+
+	for i := 0; i < len(runes); i++ {
+
+		r := runes[i]
+
+		switch r {
+		case '{', '}':
+			continue
+		case '~':
+			b.WriteRune(' ')
+			continue
+		case '\\':
+		default:
+			b.WriteRune(r)
+			continue
+		}
+
+		// Read command name, which is either a single non-letter or a sequence of letters.
+
+		i++
+		if i >= len(runes) {
+			break
+		}
+
+		start := i
+		if unicode.IsLetter(runes[i]) {
+			for i+1 < len(runes) && unicode.IsLetter(runes[i+1]) {
+				i++
+			}
+		}
+		command := string(runes[start : i+1])
+
+		if accent, ok := latexAccents[command]; ok {
+
+			// Read the accented letter, either enclosed by braces or not.
+			j := i + 1
+			for j < len(runes) && runes[j] == ' ' && unicode.IsLetter(runes[start]) {
+				j++
+			}
+
+			arg := ""
+			switch {
+			case j < len(runes) && runes[j] == '{':
+				end := j + 1
+				for end < len(runes) && runes[end] != '}' {
+					end++
+				}
+				arg = decodeLaTeX(string(runes[j+1 : end]))
+				i = end
+			case j < len(runes):
+				arg = string(runes[j])
+				i = j
+			}
+
+			if arg == "ı" {
+				arg = "i" // e.g. \'{\i}
+			}
+
+			if arg != "" {
+				argRunes := []rune(arg)
+				b.WriteRune(argRunes[0])
+				b.WriteRune(accent)
+				b.WriteString(string(argRunes[1:]))
+			}
+			continue
+		}
+
+		if symbol, ok := latexSymbols[command]; ok {
+			b.WriteString(symbol)
+			// Skip the space that terminates a command like "\ss ".
+			if unicode.IsLetter(runes[start]) && i+1 < len(runes) && runes[i+1] == ' ' {
+				i++
+			}
+			continue
+		}
+
+		// Unknown commands are removed, their arguments are kept.
+	}
+
+	text = strings.Replace(b.String(), "---", "—", -1)
+	text = strings.Replace(text, "--", "–", -1)
+
+	return strings.Join(strings.Fields(norm.NFC.String(text)), " ")
+}
+
+// WriteBibTeX writes records as BibTeX entries. Citation keys are derived from the first creator and the year of
+// publication. LaTeX special characters are escaped, all other characters are written as UTF-8.
+func WriteBibTeX(w io.Writer, records []model.Record) error {
+
+	var b strings.Builder
+
+	keys := citationKeys(records)
+
+	for i, rec := range records {
+
+		fmt.Fprintf(&b, "@%s{%s,\n", bibtexEntryType(rec.Type), keys[i])
+
+		var names []string
+		for _, c := range rec.Creators {
+			if c.FirstName != "" {
+				names = append(names, c.LastName+", "+c.FirstName)
+			} else {
+				names = append(names, c.LastName)
+			}
+		}
+
+		url := rec.RepositoryLink
+		if url == "" {
+			url = rec.PDFLink
+		}
+
+		writeBibTeXField(&b, "author", strings.Join(names, " and "))
+		writeBibTeXField(&b, "title", rec.Title)
+		writeBibTeXField(&b, "year", strconv.FormatInt(rec.Year, 10))
+		writeBibTeXField(&b, "doi", rec.Doi)
+		writeBibTeXField(&b, "url", url)
+		writeBibTeXField(&b, "keywords", strings.Join(rec.Subjects, ", "))
+		writeBibTeXField(&b, "abstract", rec.Abstract)
+
+		b.WriteString("}\n\n")
+	}
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("Could not write BibTeX data. %s", err)
+	}
+
+	return nil
+}
+
+// writeBibTeXField writes a braced BibTeX field, unless its value is empty. URLs and DOIs are written verbatim, since
+// they are usually typeset by the url package.
+func writeBibTeXField(b *strings.Builder, name string, value string) {
+
+	value = singleLine(value)
+	if value == "" {
+		return
+	}
+
+	if name != "url" && name != "doi" {
+		value = encodeLaTeX(value)
+	}
+
+	fmt.Fprintf(b, "  %s = {%s},\n", name, value)
+}
+
+// bibtexEntryType maps the numerical keys of the supported record types to BibTeX entry types.
+func bibtexEntryType(recordType int64) string {
+
+	switch recordType {
+	case model.RecordTypeArticle:
+		return "article"
+	case model.RecordTypeBook:
+		return "book"
+	case model.RecordTypePaper:
+		return "inproceedings"
+	case model.RecordTypeReport:
+		return "techreport"
+	case model.RecordTypeThesis:
+		return "phdthesis"
+	}
+
+	return "misc"
+}
+
+// encodeLaTeX escapes the special characters of LaTeX within plain Unicode text.
+func encodeLaTeX(text string) string {
+
+	var b strings.Builder
+
+	for _, r := range text {
+		switch r {
+		case '\\':
+			b.WriteString(`\textbackslash{}`)
+		case '~':
+			b.WriteString(`\textasciitilde{}`)
+		case '^':
+			b.WriteString(`\textasciicircum{}`)
+		case '{', '}', '&', '%', '$', '#', '_':
+			b.WriteRune('\\')
+			b.WriteRune(r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,100 @@
+package bibformat
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+import (
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/model"
+)
+
+// cslItem defines the subset of CSL-JSON item variables (see https://citationstyles.org), that are exported.
+type cslItem struct {
+	Id       string    `json:"id"`
+	Type     string    `json:"type"`
+	Title    string    `json:"title"`
+	Author   []cslName `json:"author,omitempty"`
+	Issued   *cslDate  `json:"issued,omitempty"`
+	Abstract string    `json:"abstract,omitempty"`
+	Doi      string    `json:"DOI,omitempty"`
+	URL      string    `json:"URL,omitempty"`
+	Keyword  string    `json:"keyword,omitempty"`
+}
+
+// cslName defines the name of a creator in CSL-JSON.
+type cslName struct {
+	Family string `json:"family"`
+	Given  string `json:"given,omitempty"`
+}
+
+// cslDate defines a date in CSL-JSON, which is given as list of date parts (year, month, day).
+type cslDate struct {
+	DateParts [][]int64 `json:"date-parts"`
+}
+
+// WriteCSLJSON writes records as a CSL-JSON array, that can be processed by citeproc implementations and reference
+// managers like Zotero. Item IDs are the same citation keys that are used for BibTeX.
+func WriteCSLJSON(w io.Writer, records []model.Record) error {
+
+	items := []cslItem{}
+	keys := citationKeys(records)
+
+	for i, rec := range records {
+
+		item := cslItem{
+			Id:       keys[i],
+			Type:     cslType(rec.Type),
+			Title:    rec.Title,
+			Abstract: rec.Abstract,
+			Doi:      rec.Doi,
+			URL:      rec.RepositoryLink,
+			Keyword:  strings.Join(rec.Subjects, ", "),
+		}
+
+		if item.URL == "" {
+			item.URL = rec.PDFLink
+		}
+
+		if rec.Year != 0 {
+			item.Issued = &cslDate{DateParts: [][]int64{{rec.Year}}}
+		}
+
+		for _, c := range rec.Creators {
+			item.Author = append(item.Author, cslName{Family: c.LastName, Given: c.FirstName})
+		}
+
+		items = append(items, item)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+
+	if err := enc.Encode(items); err != nil {
+		return fmt.Errorf("Could not write CSL-JSON data. %s", err)
+	}
+
+	return nil
+}
+
+// cslType maps the numerical keys of the supported record types to CSL item types.
+func cslType(recordType int64) string {
+
+	switch recordType {
+	case model.RecordTypeArticle:
+		return "article-journal"
+	case model.RecordTypeBook:
+		return "book"
+	case model.RecordTypePaper:
+		return "paper-conference"
+	case model.RecordTypeReport:
+		return "report"
+	case model.RecordTypeThesis:
+		return "thesis"
+	}
+
+	return "article"
+}
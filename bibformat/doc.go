@@ -0,0 +1,6 @@
+/*
+Package bibformat implements bibliographic exchange formats, that are used to import publication records from and
+export them to reference managers. Records can be imported from BibTeX and RIS, and exported to BibTeX, RIS and
+CSL-JSON.
+*/
+package bibformat
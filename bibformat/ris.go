@@ -0,0 +1,216 @@
+package bibformat
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+import (
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/model"
+)
+
+// risEntry defines a parsed RIS reference with its tags (e.g. "TI") and their values, in the order of appearance.
+type risEntry map[string][]string
+
+// Matches a tagged line of a RIS file (e.g. "TI  - A title").
+var risLinePattern = regexp.MustCompile(`^([A-Z][A-Z0-9])  -( (.*))?$`)
+
+// ReadRIS parses RIS references and maps them to GoZer's record model. References without title or year of
+// publication are skipped.
+func ReadRIS(r io.Reader) (records []model.Record, err error) {
+
+	var entry risEntry
+	var lastTag string
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+
+		line := strings.TrimRight(strings.TrimPrefix(scanner.Text(), "\uFEFF"), " \t\r")
+
+		m := risLinePattern.FindStringSubmatch(line)
+
+		switch {
+		case m == nil && entry != nil && lastTag != "" && strings.TrimSpace(line) != "":
+			// Continuation of a multi-line value (e.g. an abstract)
+			values := entry[lastTag]
+			values[len(values)-1] += " " + strings.TrimSpace(line)
+		case m == nil:
+			continue
+		case m[1] == "TY":
+			entry = risEntry{"TY": {strings.TrimSpace(m[3])}}
+			lastTag = "TY"
+		case entry == nil:
+			return nil, fmt.Errorf("RIS tag '%s' in line %d is outside of a reference.", m[1], lineNumber)
+		case m[1] == "ER":
+			rec, mapErr := entry.toRecord()
+			if mapErr != nil {
+				log.Printf("Skipping imported record. %s", mapErr)
+			} else {
+				records = append(records, rec)
+			}
+			entry = nil
+			lastTag = ""
+		default:
+			entry[m[1]] = append(entry[m[1]], strings.TrimSpace(m[3]))
+			lastTag = m[1]
+		}
+	}
+
+	if err = scanner.Err(); err != nil {
+		return nil, fmt.Errorf("Could not read RIS data. %s", err)
+	}
+
+	if entry != nil {
+		return nil, fmt.Errorf("RIS reference '%s' is not closed by an 'ER' tag.", entry.first("TI", "T1"))
+	}
+
+	return records, nil
+}
+
+// first returns the first non-empty value of the first of the specified tags that is set.
+func (e risEntry) first(tags ...string) string {
+
+	for _, tag := range tags {
+		for _, v := range e[tag] {
+			if v != "" {
+				return v
+			}
+		}
+	}
+
+	return ""
+}
+
+// toRecord maps the tags of a RIS reference to GoZer's record model.
+func (e risEntry) toRecord() (rec model.Record, err error) {
+
+	rec.Title = e.first("TI", "T1", "CT", "BT")
+	rec.Abstract = e.first("AB", "N2")
+	rec.Type = risRecordType(e.first("TY"))
+	rec.Doi = NormalizeDoi(e.first("DO"))
+	rec.Year = parseYear(e.first("PY", "Y1", "DA"))
+
+	for _, tag := range []string{"AU", "A1"} {
+		for _, name := range e[tag] {
+			if c, ok := parseRISName(name); ok {
+				rec.Creators = append(rec.Creators, c)
+			}
+		}
+	}
+
+	for _, kw := range e["KW"] {
+		rec.Subjects = append(rec.Subjects, splitKeywords(kw)...)
+	}
+
+	for _, tag := range []string{"UR", "L2", "L1"} {
+		for _, url := range e[tag] {
+			addLink(&rec, url)
+		}
+	}
+
+	return rec, validate(&rec, rec.Title)
+}
+
+// risRecordType maps RIS reference types (e.g. "JOUR") to the numerical keys of the supported record types.
+func risRecordType(referenceType string) int64 {
+
+	switch strings.ToUpper(referenceType) {
+	case "JOUR", "JFULL", "EJOUR", "MGZN", "NEWS":
+		return model.RecordTypeArticle
+	case "BOOK", "EBOOK", "EDBOOK", "CHAP", "ECHAP":
+		return model.RecordTypeBook
+	case "CONF", "CPAPER", "UNPB":
+		return model.RecordTypePaper
+	case "RPRT", "GOVDOC":
+		return model.RecordTypeReport
+	case "THES":
+		return model.RecordTypeThesis
+	}
+
+	return model.RecordTypeOther
+}
+
+// parseRISName splits a name, given as "Doe, John", "Doe, John, Jr." or "Doe,J.", into first and last name.
+func parseRISName(name string) (c model.Creator, ok bool) {
+
+	parts := strings.Split(name, ",")
+
+	c.LastName = strings.TrimSpace(parts[0])
+	if len(parts) > 1 {
+		c.FirstName = strings.TrimSpace(parts[1])
+	}
+
+	return c, c.LastName != ""
+}
+
+// WriteRIS writes records as RIS references. Lines are terminated by CR LF, as required by the RIS specification.
+func WriteRIS(w io.Writer, records []model.Record) error {
+
+	var b strings.Builder
+
+	for _, rec := range records {
+
+		writeRISLine(&b, "TY", risReferenceType(rec.Type))
+		writeRISLine(&b, "TI", rec.Title)
+
+		for _, c := range rec.Creators {
+			if c.FirstName != "" {
+				writeRISLine(&b, "AU", c.LastName+", "+c.FirstName)
+			} else {
+				writeRISLine(&b, "AU", c.LastName)
+			}
+		}
+
+		writeRISLine(&b, "PY", strconv.FormatInt(rec.Year, 10))
+		writeRISLine(&b, "AB", rec.Abstract)
+
+		for _, s := range rec.Subjects {
+			writeRISLine(&b, "KW", s)
+		}
+
+		writeRISLine(&b, "DO", rec.Doi)
+		writeRISLine(&b, "UR", rec.RepositoryLink)
+		writeRISLine(&b, "L1", rec.PDFLink)
+		b.WriteString("ER  - \r\n\r\n")
+	}
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("Could not write RIS data. %s", err)
+	}
+
+	return nil
+}
+
+// writeRISLine writes a tagged line of a RIS reference, unless its value is empty.
+func writeRISLine(b *strings.Builder, tag string, value string) {
+
+	if value = singleLine(value); value != "" {
+		fmt.Fprintf(b, "%s  - %s\r\n", tag, value)
+	}
+}
+
+// risReferenceType maps the numerical keys of the supported record types to RIS reference types.
+func risReferenceType(recordType int64) string {
+
+	switch recordType {
+	case model.RecordTypeArticle:
+		return "JOUR"
+	case model.RecordTypeBook:
+		return "BOOK"
+	case model.RecordTypePaper:
+		return "CPAPER"
+	case model.RecordTypeReport:
+		return "RPRT"
+	case model.RecordTypeThesis:
+		return "THES"
+	}
+
+	return "GEN"
+}
@@ -0,0 +1,98 @@
+package bibhash
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+import (
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/model"
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	// Number of hex characters of a level 1 hash.
+	Level1Length = 2 * md5.Size
+	// Separates the title, the author names and the year within the hashed text.
+	fieldSeparator = "|"
+	// Separates the authors' last names within the hashed text.
+	nameSeparator = ";"
+)
+
+// Level1 computes the level 1 bibliographic hash of a publication from its title, the last names of its creators in
+// the given order, and its year of publication.
+func Level1(title string, creators []model.Creator, year int64) string {
+
+	digest := md5.Sum([]byte(Level1Text(title, creators, year)))
+
+	return hex.EncodeToString(digest[:])
+}
+
+// Level1Text returns the normalized text that the level 1 bibliographic hash is computed from
+// (e.g. "mortgage default|lambertini;uysal|2017").
+func Level1Text(title string, creators []model.Creator, year int64) string {
+
+	var names []string
+
+	for _, c := range creators {
+		if name := Normalize(c.LastName); name != "" {
+			names = append(names, name)
+		}
+	}
+
+	return Normalize(title) + fieldSeparator + strings.Join(names, nameSeparator) + fieldSeparator + strconv.FormatInt(year, 10)
+}
+
+// Letters that are not decomposed by Unicode normalization, but are commonly transliterated in bibliographic metadata.
+var foldings = map[rune]string{
+	'ß': "ss", 'æ': "ae", 'Æ': "ae", 'œ': "oe", 'Œ': "oe", 'ø': "o", 'Ø': "o",
+	'ł': "l", 'Ł': "l", 'đ': "d", 'Đ': "d", 'ð': "d", 'Ð': "d", 'þ': "th", 'Þ': "th", 'ı': "i",
+}
+
+// IsValid returns true if the provided string has the form of a level 1 bibliographic hash, i.e. if it consists of
+// 32 hex characters.
+func IsValid(hash string) bool {
+
+	if len(hash) != Level1Length {
+		return false
+	}
+
+	_, err := hex.DecodeString(hash)
+
+	return err == nil
+}
+
+// Normalize converts a text to its canonical form. Characters are decomposed (NFKD) and diacritical marks are removed.
+// Letters are converted to lower case. Sequences of other characters than letters and digits are replaced by a single
+// space, except for apostrophes, which are removed (e.g. "O'Neill" becomes "oneill"). Letters without decomposition are
+// transliterated (e.g. "Białek" becomes "bialek" and "Straße" becomes "strasse").
+func Normalize(text string) string {
+
+	var b strings.Builder
+
+	space := false
+
+	for _, r := range norm.NFKD.String(text) {
+		switch {
+		case unicode.Is(unicode.Mn, r), r == '\'', r == '’':
+			continue
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if space && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			if folded, ok := foldings[r]; ok {
+				b.WriteString(folded)
+			} else {
+				b.WriteRune(unicode.ToLower(r))
+			}
+			space = false
+		default:
+			space = true
+		}
+	}
+
+	return b.String()
+}
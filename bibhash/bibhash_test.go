@@ -0,0 +1,94 @@
+package bibhash
+
+import (
+	"testing"
+)
+
+import (
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/model"
+)
+
+// creators returns creators with the specified last names.
+func creators(lastNames ...string) (cs []model.Creator) {
+
+	for _, name := range lastNames {
+		cs = append(cs, model.Creator{FirstName: "Jane", LastName: name})
+	}
+
+	return
+}
+
+func TestLevel1(t *testing.T) {
+
+	// Golden test vectors. The hashes must never change, since they identify publications on the ledger. Note that the
+	// first publication is part of the example database with a hash of the former scheme, which is kept as-is.
+	vectors := []struct {
+		title    string
+		creators []model.Creator
+		year     int64
+		text     string
+		hash     string
+	}{
+		{
+			"Mortgage default in an estimated model of the U.S. housing market", creators("Lambertini", "Uysal", "Victoria"), 2017,
+			"mortgage default in an estimated model of the u s housing market|lambertini;uysal;victoria|2017",
+			"c015df23030a767fb80638ab30055997",
+		},
+		{
+			"Causes of the outbreak of the Eurozone crisis: The role of the USA and the European Central Bank monetary policy", creators("Białek"), 2015,
+			"causes of the outbreak of the eurozone crisis the role of the usa and the european central bank monetary policy|bialek|2015",
+			"62f822e71191fe214fb8c40dfb60ece8",
+		},
+		{
+			"Ökonomische Effekte der Straßenmaut", creators("Müller", "O'Neill"), 2019,
+			"okonomische effekte der strassenmaut|muller;oneill|2019",
+			"d700795fd9dd84a042e172515a93342a",
+		},
+		{
+			"Zipf zipped", nil, 2004,
+			"zipf zipped||2004",
+			"6215625f17c293230971dad51301783d",
+		},
+	}
+
+	for _, v := range vectors {
+
+		if text := Level1Text(v.title, v.creators, v.year); text != v.text {
+			t.Errorf("Expected normalized text '%s' but got '%s'.", v.text, text)
+			return
+		}
+
+		if hash := Level1(v.title, v.creators, v.year); hash != v.hash {
+			t.Errorf("Expected hash '%s' for '%s' but got '%s'.", v.hash, v.text, hash)
+			return
+		}
+	}
+
+	// Different spellings of the same metadata result in the same hash.
+
+	hashA := Level1("Ökonomische Effekte der Straßenmaut", creators("Müller", "O'Neill"), 2019)
+	hashB := Level1(" OKONOMISCHE effekte -- der  Strassenmaut. ", creators("Müller", "O’Neill", ""), 2019)
+
+	if hashA != hashB {
+		t.Errorf("Expected equal hashes but got '%s' and '%s'.", hashA, hashB)
+		return
+	}
+}
+
+func TestIsValid(t *testing.T) {
+
+	valid := []string{"c015df23030a767fb80638ab30055997", "C015DF23030A767FB80638AB30055997", "00000000000000000000000000000000"}
+	invalid := []string{"", "c015df23030a767fb80638ab3005599", "c015df23030a767fb80638ab300559970", "g015df23030a767fb80638ab30055997"}
+
+	for _, hash := range valid {
+		if !IsValid(hash) {
+			t.Errorf("Expected '%s' to be a valid hash.", hash)
+		}
+	}
+
+	for _, hash := range invalid {
+		if IsValid(hash) {
+			t.Errorf("Expected '%s' to be an invalid hash.", hash)
+		}
+	}
+}
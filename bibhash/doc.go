@@ -0,0 +1,16 @@
+/*
+Package bibhash implements bibliographic hashes, that globally identify publications independently of their data source.
+
+The level 1 hash of a publication is the MD5 digest of its normalized title, the normalized last names of its authors
+and its year of publication. Normalization decomposes Unicode characters, removes diacritical marks, converts all
+letters to lower case and replaces punctuation by single spaces, so that different spellings of the same metadata
+(e.g. "Müller" and "Muller" or "U.S.-Housing" and "U.S. housing") result in the same hash. The 16 byte digest is
+represented by 32 lower case hex characters, as it is expected by the open feedback ledger.
+
+Level 1 is a new hashing scheme. The hashes, that were assigned to records before (e.g. by the import of the example
+database), were computed differently and can not be reproduced from the records' metadata. Since they identify feedback
+that is already stored on the ledger, existing valid hashes are kept as they are and level 1 hashes are only computed
+for records without a (valid) hash. Consequently, the same publication may have different hashes in databases, that
+were populated before and after the introduction of level 1 hashes.
+*/
+package bibhash
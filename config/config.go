@@ -18,10 +18,52 @@ const (
 // The interface defines the network device GoZer is using for communication, e.g. "192.168.222.1" (specific interface)
 // or "0.0.0.0" (all interfaces). The port specifies where GoZer is listening for HTTP requests. In addition a local path
 // to ploc APK file can be specified, which allows to download the APK from GoZer directly.
+// Clients can exchange their credentials for a signed access token and a refresh token (see "/session/create"). The
+// token secret is the key for signing access tokens. If it is empty, a random key is used, so that all sessions end
+// when GoZer is restarted. The access token expiry is given in minutes, the refresh token expiry in hours.
 type WebAPIConfiguration struct {
-	Interface string `toml:"interface"`
-	Port      int    `toml:"port"`
-	PlocAPK   string `toml:"ploc_apk"`
+	Interface          string                 `toml:"interface"`
+	Port               int                    `toml:"port"`
+	PlocAPK            string                 `toml:"ploc_apk"`
+	TokenSecret        string                 `toml:"token_secret"`
+	AccessTokenExpiry  int                    `toml:"access_token_expiry"`
+	RefreshTokenExpiry int                    `toml:"refresh_token_expiry"`
+	OrcId              OrcIdConfiguration     `toml:"orcid"`
+	RateLimit          RateLimitConfiguration `toml:"rate_limit"`
+}
+
+// Defines the global configuration parameters for verifying the ORCiD of experts via OAuth2 (authorization code flow
+// with PKCE). The client ID and secret are issued by the ORCiD-compatible provider, whose authorization and token
+// endpoints are specified by URL. The redirect URL is the address of GoZer's callback ("/orcid/callback"), as it is
+// registered at the provider. It is required if verification is enabled, since the address of the callback must not
+// be derived from the requests of clients. Verification is disabled, if no client ID is specified.
+type OrcIdConfiguration struct {
+	ClientId     string `toml:"client_id"`
+	ClientSecret string `toml:"client_secret"`
+	AuthorizeURL string `toml:"authorize_url"`
+	TokenURL     string `toml:"token_url"`
+	RedirectURL  string `toml:"redirect_url"`
+	Scope        string `toml:"scope"`
+}
+
+// Defines the global configuration parameters for limiting the rate of requests to the Web API. Each client IP address
+// and each authenticated GUID has a token bucket, that holds up to the burst of requests and is refilled by the rate
+// (requests per minute). New user profiles are limited separately per IP address (profiles per hour). After the
+// maximum number of failed authorizations within the lockout duration (minutes), the IP address, or the IP address'
+// attempts for a GUID, are locked for the lockout duration. GUIDs are never locked on their own, so that nobody can lock
+// out a user by guessing the user's secret. Limited requests are answered with HTTP status 429 (Too Many Requests). If GoZer runs
+// behind a reverse proxy, the client IP address is taken from the header "X-Forwarded-For" of trusted proxies.
+type RateLimitConfiguration struct {
+	Enable          bool    `toml:"enable"`
+	IPRate          float64 `toml:"ip_rate"`
+	IPBurst         int     `toml:"ip_burst"`
+	GUIDRate        float64 `toml:"guid_rate"`
+	GUIDBurst       int     `toml:"guid_burst"`
+	ProfileRate     float64 `toml:"profile_rate"`
+	ProfileBurst    int     `toml:"profile_burst"`
+	MaxFailures     int     `toml:"max_failures"`
+	LockoutDuration int     `toml:"lockout_duration"`
+	TrustProxy      bool    `toml:"trust_proxy"`
 }
 
 // Defines the global configuration parameters for GoZer's SQLite database, which is the local path to the SQLite database file.
@@ -41,16 +83,62 @@ type LedgerConfiguration struct {
 	PrivateKey      string `toml:"private_key"`
 }
 
+// Defines the global configuration parameters for GoZer's OAI-PMH harvester.
+// The use of the harvester is optional and can be disabled.
+// The endpoint specifies the base URL of an OAI-PMH repository (e.g. EconStor), the metadata prefix the format in which
+// records are requested (only "oai_dc" is supported) and the optional set restricts harvesting to a part of the repository.
+// The interval defines the number of hours between two incremental harvesting runs.
+type HarvesterConfiguration struct {
+	Enable         bool   `toml:"enable"`
+	Endpoint       string `toml:"endpoint"`
+	MetadataPrefix string `toml:"metadata_prefix"`
+	Set            string `toml:"set"`
+	Interval       int    `toml:"interval"`
+}
+
+// Defines the global configuration parameters for scoring the records of a user's record feed.
+// The score of a record is the weighted sum of the number of subjects that match the user's interests, the summed
+// weights of these subjects, and the recency of the record, reduced by a penalty for each subject that the record
+// shares with records the user disliked. The recency decays by half each half-life (in years).
+// The preference weight defines how strongly the subject and author affinities, that are learned from a user's
+// bookmarks, visits, feedback and dislikes, change the order of the record and the expert feed.
+// The expert strategy selects how the experts of a user's expert feed are ranked: by the number of publications that
+// match the user's interests ("publications"), by that number decaying with the years since the expert's last
+// publication ("recency", using the same half-life) or by the feedback from verified experts ("feedback").
+type RankingConfiguration struct {
+	MatchWeight      float64 `toml:"match_weight"`
+	SubjectWeight    float64 `toml:"subject_weight"`
+	RecencyWeight    float64 `toml:"recency_weight"`
+	RecencyHalfLife  float64 `toml:"recency_half_life"`
+	DislikePenalty   float64 `toml:"dislike_penalty"`
+	PreferenceWeight float64 `toml:"preference_weight"`
+	ExpertStrategy   string  `toml:"expert_strategy"`
+}
+
+// Defines the global configuration parameters for GoZer's recommender, which periodically derives similarities
+// between records from the bookmarks, visits and positive feedback of all users (collaborative filtering).
+// The interval defines the number of hours between two runs. The minimum support defines how many different users
+// must have interacted with both records of a pair, before the pair is used for recommendations. It ensures that
+// no single user's behaviour can be inferred from the recommendations and must be at least 2.
+type RecommenderConfiguration struct {
+	Interval   int   `toml:"interval"`
+	MinSupport int64 `toml:"min_support"`
+}
+
 // Defines the global configuration of the GoZer service.
-// The configuration consists of parameters for the Web API, the database and the Ethereum ledger.
+// The configuration consists of parameters for the Web API, the database, the Ethereum ledger, the harvester, the
+// ranking of the record feed and the recommender.
 type Configuration struct {
-	WebAPI  WebAPIConfiguration  `toml:"webapi"`
-	Storage StorageConfiguration `toml:"storage"`
-	Ledger  LedgerConfiguration  `toml:"ledger"`
+	WebAPI      WebAPIConfiguration      `toml:"webapi"`
+	Storage     StorageConfiguration     `toml:"storage"`
+	Ledger      LedgerConfiguration      `toml:"ledger"`
+	Harvester   HarvesterConfiguration   `toml:"harvester"`
+	Ranking     RankingConfiguration     `toml:"ranking"`
+	Recommender RecommenderConfiguration `toml:"recommender"`
 }
 
 // DefaultConfiguration returns a default configuration, that can be used e.g. for testing.
-// The ledger is disabled per default.
+// The ledger and the harvester are disabled per default.
 func DefaultConfiguration() *Configuration {
 
 	var conf Configuration
@@ -58,6 +146,22 @@ func DefaultConfiguration() *Configuration {
 	conf.WebAPI.Interface = "0.0.0.0"
 	conf.WebAPI.Port = 8080
 	conf.WebAPI.PlocAPK = "ploc.apk"
+	conf.WebAPI.TokenSecret = ""
+	conf.WebAPI.AccessTokenExpiry = 15
+	conf.WebAPI.RefreshTokenExpiry = 720
+	conf.WebAPI.OrcId.AuthorizeURL = "https://orcid.org/oauth/authorize"
+	conf.WebAPI.OrcId.TokenURL = "https://orcid.org/oauth/token"
+	conf.WebAPI.OrcId.Scope = "/authenticate"
+	conf.WebAPI.RateLimit.Enable = true
+	conf.WebAPI.RateLimit.IPRate = 120
+	conf.WebAPI.RateLimit.IPBurst = 60
+	conf.WebAPI.RateLimit.GUIDRate = 60
+	conf.WebAPI.RateLimit.GUIDBurst = 30
+	conf.WebAPI.RateLimit.ProfileRate = 10
+	conf.WebAPI.RateLimit.ProfileBurst = 3
+	conf.WebAPI.RateLimit.MaxFailures = 5
+	conf.WebAPI.RateLimit.LockoutDuration = 15
+	conf.WebAPI.RateLimit.TrustProxy = false
 
 	conf.Storage.DBFilename = "storage.db"
 
@@ -66,6 +170,23 @@ func DefaultConfiguration() *Configuration {
 	conf.Ledger.ContractAddress = "0xc8B381DCCAE278F809DB5e0b7B2EfA8c716270d7"                  // dummy, not a real one
 	conf.Ledger.PrivateKey = "0fc142ddbe063614c3cab903fbc1516a5ab663d1fa8bcfb46f867df4bd5c03fe" // dummy, not a real one
 
+	conf.Harvester.Enable = false
+	conf.Harvester.Endpoint = "https://www.econstor.eu/oai/request"
+	conf.Harvester.MetadataPrefix = "oai_dc"
+	conf.Harvester.Set = ""
+	conf.Harvester.Interval = 24
+
+	conf.Ranking.MatchWeight = 1.0
+	conf.Ranking.SubjectWeight = 1.0
+	conf.Ranking.RecencyWeight = 2.0
+	conf.Ranking.RecencyHalfLife = 5.0
+	conf.Ranking.DislikePenalty = 0.5
+	conf.Ranking.PreferenceWeight = 1.0
+	conf.Ranking.ExpertStrategy = "publications"
+
+	conf.Recommender.Interval = 6
+	conf.Recommender.MinSupport = 3
+
 	return &conf
 }
 
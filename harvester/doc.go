@@ -0,0 +1,4 @@
+/*
+Package harvester implements an OAI-PMH client that periodically imports publication records from an external repository.
+*/
+package harvester
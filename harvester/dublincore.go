@@ -0,0 +1,169 @@
+package harvester
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+import (
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/model"
+)
+
+// dublinCore defines the simple Dublin Core metadata of a record (oai_dc). All elements are optional and repeatable.
+type dublinCore struct {
+	Titles       []string `xml:"title"`
+	Creators     []string `xml:"creator"`
+	Subjects     []string `xml:"subject"`
+	Descriptions []string `xml:"description"`
+	Dates        []string `xml:"date"`
+	Types        []string `xml:"type"`
+	Identifiers  []string `xml:"identifier"`
+	Rights       []string `xml:"rights"`
+}
+
+var (
+	yearPattern = regexp.MustCompile(`\b(1[5-9]|20)[0-9]{2}\b`)
+	doiPattern  = regexp.MustCompile(`10\.[0-9]{4,9}/\S+`)
+)
+
+// toRecord maps the Dublin Core metadata of a harvested record to GoZer's record model.
+// The OAI identifier of the record is used as source ID. Records without title or year of publication are rejected.
+func (dc *dublinCore) toRecord(identifier string) (rec model.Record, err error) {
+
+	rec.SourceId = identifier
+	rec.Title = first(dc.Titles)
+	rec.Abstract = first(dc.Descriptions)
+	rec.Type = recordType(dc.Types)
+	rec.OpenAccess = openAccess(dc.Rights)
+
+	if rec.Title == "" {
+		return rec, fmt.Errorf("Record '%s' has no title.", identifier)
+	}
+
+	for _, d := range dc.Dates {
+		if y := yearPattern.FindString(d); y != "" {
+			rec.Year, _ = strconv.ParseInt(y, 10, 64)
+			break
+		}
+	}
+
+	if rec.Year == 0 {
+		return rec, fmt.Errorf("Record '%s' has no year of publication.", identifier)
+	}
+
+	for _, c := range dc.Creators {
+		if creator, ok := parseCreator(c); ok {
+			rec.Creators = append(rec.Creators, creator)
+		}
+	}
+
+	known := make(map[string]bool)
+
+	for _, s := range dc.Subjects {
+		s = strings.TrimSpace(s)
+		if s != "" && !known[s] {
+			known[s] = true
+			rec.Subjects = append(rec.Subjects, s)
+		}
+	}
+
+	for _, id := range dc.Identifiers {
+
+		id = strings.TrimSpace(id)
+		lowerId := strings.ToLower(id)
+
+		switch {
+		case rec.Doi == "" && (strings.HasPrefix(lowerId, "doi:") || strings.Contains(lowerId, "doi.org/")):
+			rec.Doi = doiPattern.FindString(id)
+		case !strings.HasPrefix(lowerId, "http://") && !strings.HasPrefix(lowerId, "https://"):
+			continue
+		case strings.HasSuffix(lowerId, ".pdf"):
+			if rec.PDFLink == "" {
+				rec.PDFLink = id
+			}
+		case rec.RepositoryLink == "":
+			rec.RepositoryLink = id
+		}
+	}
+
+	return rec, nil
+}
+
+// first returns the first non-empty element of a list of values.
+func first(values []string) string {
+
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+
+	return ""
+}
+
+// openAccess maps Dublin Core access rights (e.g. "info:eu-repo/semantics/openAccess") to GoZer's open access state.
+func openAccess(rights []string) int64 {
+
+	for _, r := range rights {
+
+		r = strings.ToLower(r)
+
+		switch {
+		case strings.Contains(r, "openaccess"):
+			return model.OpenAccessTrue
+		case strings.Contains(r, "closedaccess"), strings.Contains(r, "restrictedaccess"), strings.Contains(r, "embargoedaccess"):
+			return model.OpenAccessFalse
+		}
+	}
+
+	return model.OpenAccessUnknown
+}
+
+// parseCreator splits a creator's name, given either as "Doe, John" or "John Doe", into first and last name.
+func parseCreator(name string) (c model.Creator, ok bool) {
+
+	name = strings.TrimSpace(name)
+
+	if name == "" {
+		return c, false
+	}
+
+	if i := strings.Index(name, ","); i >= 0 {
+		c.LastName = strings.TrimSpace(name[:i])
+		c.FirstName = strings.TrimSpace(name[i+1:])
+	} else if i := strings.LastIndex(name, " "); i >= 0 {
+		c.FirstName = strings.TrimSpace(name[:i])
+		c.LastName = strings.TrimSpace(name[i+1:])
+	} else {
+		c.LastName = name
+	}
+
+	return c, c.LastName != ""
+}
+
+// recordType maps Dublin Core types (e.g. "doc-type:workingPaper" or "info:eu-repo/semantics/article") to the
+// numerical keys of the supported record types.
+func recordType(types []string) int64 {
+
+	for _, t := range types {
+
+		t = strings.ToLower(t)
+
+		switch {
+		case strings.Contains(t, "thesis"), strings.Contains(t, "dissertation"):
+			return model.RecordTypeThesis
+		case strings.Contains(t, "report"):
+			return model.RecordTypeReport
+		case strings.Contains(t, "paper"), strings.Contains(t, "conference"):
+			return model.RecordTypePaper
+		case strings.Contains(t, "article"):
+			return model.RecordTypeArticle
+		case strings.Contains(t, "book"):
+			return model.RecordTypeBook
+		}
+	}
+
+	return model.RecordTypeOther
+}
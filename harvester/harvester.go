@@ -0,0 +1,156 @@
+package harvester
+
+import (
+	"log"
+	"strings"
+	"time"
+)
+
+import (
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/config"
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/storage"
+)
+
+// Harvester periodically imports publication records from an OAI-PMH repository into the storage.
+// Each run is incremental and only requests the records that were added, changed or deleted since the last
+// successful run.
+type Harvester struct {
+	conf   *config.HarvesterConfiguration
+	st     *storage.Storage
+	client *oaiClient
+	stop   chan bool // signal to stop periodic harvesting
+	down   chan bool // signal for successful shutdown
+}
+
+// Open initializes the harvester accordingly to the provided global configuration.
+// Returns nil if harvesting is disabled.
+func Open(conf *config.HarvesterConfiguration, st *storage.Storage) *Harvester {
+
+	if !conf.Enable {
+		return nil
+	}
+
+	return &Harvester{
+		conf:   conf,
+		st:     st,
+		client: newOAIClient(conf.Endpoint),
+		stop:   make(chan bool, 1),
+		down:   make(chan bool, 1),
+	}
+}
+
+// Run harvests the repository immediately and then repeatedly after the configured interval, until Shutdown is called.
+func (h *Harvester) Run() {
+
+	interval := time.Duration(h.conf.Interval) * time.Hour
+
+	log.Printf("Harvesting '%s' every %v.", h.conf.Endpoint, interval)
+
+	for {
+		if err := h.Harvest(); err != nil {
+			log.Printf("Harvesting '%s' has failed. %s", h.conf.Endpoint, err)
+		}
+
+		select {
+		case <-h.stop:
+			h.down <- true
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Shutdown stops periodic harvesting and waits until a running harvest has finished.
+func (h *Harvester) Shutdown() {
+
+	h.stop <- true
+
+	<-h.down
+
+	log.Print("Stopping harvester was successful.")
+}
+
+// Harvest requests all records that were added, changed or deleted since the last successful run and
+// upserts them into the storage. Afterwards all data derived from the records (e.g. experts, search indices and feeds)
+// is rebuilt.
+func (h *Harvester) Harvest() (err error) {
+
+	identify, err := h.client.identify()
+	if err != nil {
+		return
+	}
+
+	from, err := h.st.ReadLastHarvestUntil(h.conf.Endpoint)
+	if err != nil {
+		return
+	}
+
+	until := formatDatestamp(time.Now(), identify.Granularity)
+
+	runId, err := h.st.CreateHarvestRun(h.conf.Endpoint, from, until)
+	if err != nil {
+		return
+	}
+
+	log.Printf("Harvesting '%s' (%s) from '%s' until '%s'.", identify.RepositoryName, h.conf.Endpoint, from, until)
+
+	recordCount, deletedCount, err := h.harvestRecords(from, until)
+
+	if err == nil {
+		err = h.st.RebuildAfterIngest()
+	}
+
+	errMsg := ""
+	if err != nil {
+		errMsg = err.Error()
+	}
+
+	if updateErr := h.st.UpdateHarvestRun(runId, recordCount, deletedCount, errMsg); updateErr != nil && err == nil {
+		err = updateErr
+	}
+
+	log.Printf("Harvesting '%s' has finished. %d records upserted, %d records deleted.", h.conf.Endpoint, recordCount, deletedCount)
+
+	return
+}
+
+// harvestRecords requests all segments of records within the specified time window, following the resumption tokens
+// of the repository, and writes them to the storage. Records with insufficient metadata are skipped.
+func (h *Harvester) harvestRecords(from string, until string) (recordCount int64, deletedCount int64, err error) {
+
+	list, err := h.client.listRecords(h.conf.MetadataPrefix, h.conf.Set, from, until)
+
+	for err == nil {
+
+		for _, r := range list.Records {
+
+			if r.isDeleted() {
+				if err = h.st.DeleteRecordBySourceId(r.Header.Identifier); err != nil {
+					return
+				}
+				deletedCount++
+				continue
+			}
+
+			rec, mapErr := r.Metadata.DC.toRecord(r.Header.Identifier)
+			if mapErr != nil {
+				log.Printf("Skipping harvested record. %s", mapErr)
+				continue
+			}
+
+			if _, err = h.st.UpsertRecord(&rec); err != nil {
+				return
+			}
+			recordCount++
+		}
+
+		token := strings.TrimSpace(list.ResumptionToken)
+		if token == "" {
+			break
+		}
+
+		list, err = h.client.resumeListRecords(token)
+	}
+
+	return
+}
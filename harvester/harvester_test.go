@@ -0,0 +1,273 @@
+package harvester
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+import (
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/config"
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/model"
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/model/ploc"
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/storage"
+)
+
+const testIdentify = `<?xml version="1.0" encoding="UTF-8"?>
+<OAI-PMH xmlns="http://www.openarchives.org/OAI/2.0/">
+  <responseDate>2019-11-26T10:00:00Z</responseDate>
+  <request verb="Identify">%s</request>
+  <Identify>
+    <repositoryName>Test Repository</repositoryName>
+    <baseURL>%s</baseURL>
+    <protocolVersion>2.0</protocolVersion>
+    <earliestDatestamp>2000-01-01</earliestDatestamp>
+    <deletedRecord>persistent</deletedRecord>
+    <granularity>YYYY-MM-DD</granularity>
+  </Identify>
+</OAI-PMH>`
+
+const testListRecords = `<?xml version="1.0" encoding="UTF-8"?>
+<OAI-PMH xmlns="http://www.openarchives.org/OAI/2.0/">
+  <responseDate>2019-11-26T10:00:00Z</responseDate>
+  <request verb="ListRecords">%s</request>
+  <ListRecords>%s
+    <resumptionToken>%s</resumptionToken>
+  </ListRecords>
+</OAI-PMH>`
+
+const testNoRecordsMatch = `<?xml version="1.0" encoding="UTF-8"?>
+<OAI-PMH xmlns="http://www.openarchives.org/OAI/2.0/">
+  <responseDate>2019-11-26T10:00:00Z</responseDate>
+  <request verb="ListRecords">%s</request>
+  <error code="noRecordsMatch">No records match.</error>
+</OAI-PMH>`
+
+const testRecord = `
+    <record>
+      <header>
+        <identifier>%s</identifier>
+        <datestamp>2019-11-25</datestamp>
+      </header>
+      <metadata>
+        <oai_dc:dc xmlns:oai_dc="http://www.openarchives.org/OAI/2.0/oai_dc/" xmlns:dc="http://purl.org/dc/elements/1.1/">
+          <dc:title>%s</dc:title>
+          <dc:creator>Doe, John</dc:creator>
+          <dc:creator>Jane Roe</dc:creator>
+          <dc:subject>Banks</dc:subject>
+          <dc:subject>Financial Crisis</dc:subject>
+          <dc:description>An abstract about banks.</dc:description>
+          <dc:date>2018-05-01</dc:date>
+          <dc:type>doc-type:workingPaper</dc:type>
+          <dc:identifier>http://hdl.handle.net/10419/%s</dc:identifier>
+          <dc:identifier>https://doi.org/10.1000/%s</dc:identifier>
+          <dc:rights>info:eu-repo/semantics/openAccess</dc:rights>
+        </oai_dc:dc>
+      </metadata>
+    </record>`
+
+const testDeletedRecord = `
+    <record>
+      <header status="deleted">
+        <identifier>%s</identifier>
+        <datestamp>2019-11-25</datestamp>
+      </header>
+    </record>`
+
+// testRepository is a stand-in for an OAI-PMH repository, that serves its records in two segments.
+// The parameters of the last ListRecords request are kept for inspection.
+type testRepository struct {
+	server  *httptest.Server
+	records [][]string
+	from    string
+	until   string
+}
+
+func newTestRepository() *testRepository {
+
+	repo := &testRepository{}
+	repo.server = httptest.NewServer(http.HandlerFunc(repo.handle))
+
+	return repo
+}
+
+func (repo *testRepository) handle(w http.ResponseWriter, r *http.Request) {
+
+	q := r.URL.Query()
+
+	w.Header().Set("Content-Type", "text/xml")
+
+	switch q.Get("verb") {
+	case "Identify":
+		fmt.Fprintf(w, testIdentify, repo.server.URL, repo.server.URL)
+	case "ListRecords":
+		if q.Get("resumptionToken") == "" {
+			repo.from = q.Get("from")
+			repo.until = q.Get("until")
+		}
+
+		segment := 0
+		if q.Get("resumptionToken") == "segment-2" {
+			segment = 1
+		}
+
+		if segment >= len(repo.records) || len(repo.records[segment]) == 0 {
+			fmt.Fprintf(w, testNoRecordsMatch, repo.server.URL)
+			return
+		}
+
+		token := ""
+		if segment+1 < len(repo.records) {
+			token = "segment-2"
+		}
+
+		body := ""
+		for _, rec := range repo.records[segment] {
+			body += rec
+		}
+
+		fmt.Fprintf(w, testListRecords, repo.server.URL, body, token)
+	default:
+		http.Error(w, "Bad Verb", http.StatusBadRequest)
+	}
+}
+
+func newTestRecord(id string, title string) string {
+	return fmt.Sprintf(testRecord, "oai:test:"+id, title, id, id)
+}
+
+func newTestDeletedRecord(id string) string {
+	return fmt.Sprintf(testDeletedRecord, "oai:test:"+id)
+}
+
+func readRecordFeed(t *testing.T, st *storage.Storage, uid int64) (records ploc.RecordPreviews) {
+
+	rawRecords, err := st.ReadRecordFeed(uid, nil, 0, 100)
+	if err != nil {
+		t.Errorf("Could not read record feed. %s", err)
+		return
+	}
+
+	for _, raw := range rawRecords {
+
+		var r ploc.RecordPreview
+
+		if err := json.Unmarshal(raw, &r); err != nil {
+			t.Errorf("Could not unmarshal record preview '%s'. %s", string(raw), err)
+			return
+		}
+
+		records = append(records, r)
+	}
+
+	return
+}
+
+func TestHarvest(t *testing.T) {
+
+	// Setup repository, database and harvester
+
+	repo := newTestRepository()
+	defer repo.server.Close()
+
+	st := storage.Open(&config.StorageConfiguration{DBFilename: ":memory:"})
+	defer st.Close()
+
+	conf := config.DefaultConfiguration().Harvester
+	conf.Enable = true
+	conf.Endpoint = repo.server.URL
+
+	h := Open(&conf, st)
+
+	repo.records = [][]string{
+		{newTestRecord("1", "Banks and the crisis"), newTestRecord("2", "Banking regulation")},
+		{newTestRecord("3", "Bank runs"), newTestDeletedRecord("4")},
+	}
+
+	// Perform test #1: initial harvest follows the resumption token
+
+	if err := h.Harvest(); err != nil {
+		t.Errorf("Unexpected error. %s", err)
+		return
+	}
+
+	if repo.from != "" {
+		t.Errorf("Expected initial harvest without 'from' but got '%s'.", repo.from)
+		return
+	}
+
+	user, _ := model.NewUserWithSecret("dsj738hFs3d:Kl67jdk")
+	st.CreateUser(&user)
+
+	subs, _ := st.ReadAllSubjects()
+	st.CreateInterest(user.Id, subs.SelectByKeyword("Banks").Id)
+
+	records := readRecordFeed(t, st, user.Id)
+
+	if len(records) != 3 {
+		t.Errorf("Expected %d records in the feed but got %d.", 3, len(records))
+		return
+	}
+
+	rawExperts, err := st.ReadExpertFeed(user.Id, 0, 100)
+	if err != nil || len(rawExperts) != 2 {
+		t.Errorf("Expected %d experts in the feed but got %d. %v", 2, len(rawExperts), err)
+		return
+	}
+
+	recordA := records.SelectByTitle("Banks and the crisis")
+
+	if recordA.Creators != "Doe and Roe" || recordA.Year != 2018 || recordA.Type != model.RecordTypePaper {
+		t.Errorf("Unexpected record preview field values %+v.", recordA)
+		return
+	}
+
+	// Perform test #2: incremental harvest updates, deletes and adds records and rebuilds the feeds
+
+	repo.records = [][]string{
+		{newTestRecord("1", "Banks and the financial crisis"), newTestDeletedRecord("2")},
+		{newTestRecord("5", "Shadow banks")},
+	}
+
+	if err := h.Harvest(); err != nil {
+		t.Errorf("Unexpected error. %s", err)
+		return
+	}
+
+	if repo.from != repo.until {
+		t.Errorf("Expected incremental harvest from '%s' but got '%s'.", repo.until, repo.from)
+		return
+	}
+
+	records = readRecordFeed(t, st, user.Id)
+
+	if len(records) != 3 {
+		t.Errorf("Expected %d records in the feed but got %d.", 3, len(records))
+		return
+	}
+
+	if records.SelectByTitle("Banks and the financial crisis").Id != recordA.Id {
+		t.Errorf("Expected updated record to keep its ID %d.", recordA.Id)
+		return
+	}
+
+	if records.SelectByTitle("Shadow banks").Id == 0 {
+		t.Errorf("Expected newly harvested record in the feed.")
+		return
+	}
+
+	// Perform test #3: harvested records are part of the search index
+
+	rawRecords, _, err := st.SearchRecordFeed(user.Id, "shadow", nil, 0, 10)
+	if err != nil {
+		t.Errorf("Unexpected error. %s", err)
+		return
+	}
+
+	if len(rawRecords) != 1 {
+		t.Errorf("Expected %d records to match search term, but got %d.", 1, len(rawRecords))
+		return
+	}
+}
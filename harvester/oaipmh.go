@@ -0,0 +1,186 @@
+package harvester
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// Fine granularity of datestamps as defined by the OAI-PMH specification. The default is "YYYY-MM-DD".
+	secondGranularity  = "YYYY-MM-DDThh:mm:ssZ"
+	dayDateFormat      = "2006-01-02"
+	secondDateFormat   = "2006-01-02T15:04:05Z"
+	noRecordsMatchCode = "noRecordsMatch"
+)
+
+// oaiResponse defines the envelope of all responses of an OAI-PMH repository. Depending on the requested verb
+// either the Identify or the ListRecords element is set.
+type oaiResponse struct {
+	XMLName      xml.Name       `xml:"OAI-PMH"`
+	ResponseDate string         `xml:"responseDate"`
+	Errors       []oaiError     `xml:"error"`
+	Identify     oaiIdentify    `xml:"Identify"`
+	ListRecords  oaiListRecords `xml:"ListRecords"`
+}
+
+// oaiError defines an error reported by an OAI-PMH repository (e.g. "badArgument" or "noRecordsMatch").
+type oaiError struct {
+	Code    string `xml:"code,attr"`
+	Message string `xml:",chardata"`
+}
+
+// oaiIdentify defines the description of an OAI-PMH repository.
+type oaiIdentify struct {
+	RepositoryName    string `xml:"repositoryName"`
+	BaseURL           string `xml:"baseURL"`
+	ProtocolVersion   string `xml:"protocolVersion"`
+	EarliestDatestamp string `xml:"earliestDatestamp"`
+	DeletedRecord     string `xml:"deletedRecord"`
+	Granularity       string `xml:"granularity"`
+}
+
+// oaiListRecords defines a segment of harvested records. A non-empty resumption token signifies that
+// further segments need to be requested.
+type oaiListRecords struct {
+	Records         []oaiRecord `xml:"record"`
+	ResumptionToken string      `xml:"resumptionToken"`
+}
+
+// oaiRecord defines a single harvested record, consisting of a header and its Dublin Core metadata.
+type oaiRecord struct {
+	Header   oaiHeader   `xml:"header"`
+	Metadata oaiMetadata `xml:"metadata"`
+}
+
+// oaiHeader defines the unique identifier of a record within the repository and its state.
+// Records withdrawn from the repository are marked by the status "deleted".
+type oaiHeader struct {
+	Status     string `xml:"status,attr"`
+	Identifier string `xml:"identifier"`
+	Datestamp  string `xml:"datestamp"`
+}
+
+// oaiMetadata wraps the metadata of a record in the simple Dublin Core format (oai_dc).
+type oaiMetadata struct {
+	DC dublinCore `xml:"dc"`
+}
+
+// isDeleted returns true if the record was withdrawn from the repository.
+func (r *oaiRecord) isDeleted() bool {
+	return r.Header.Status == "deleted"
+}
+
+// oaiClient implements the requests of the OAI-PMH protocol against a single repository.
+type oaiClient struct {
+	baseURL string
+	http    *http.Client
+}
+
+// newOAIClient returns a client for the OAI-PMH repository with the specified base URL.
+func newOAIClient(baseURL string) *oaiClient {
+	return &oaiClient{
+		baseURL: baseURL,
+		http:    &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+// identify requests the description of the repository.
+func (c *oaiClient) identify() (identify oaiIdentify, err error) {
+
+	params := url.Values{}
+	params.Set("verb", "Identify")
+
+	resp, err := c.request(params)
+	if err != nil {
+		return
+	}
+
+	return resp.Identify, nil
+}
+
+// listRecords requests the first segment of records in the specified metadata format that were added, changed or
+// deleted within the time window defined by from and until. The set, from and until parameters are optional.
+func (c *oaiClient) listRecords(metadataPrefix string, set string, from string, until string) (records oaiListRecords, err error) {
+
+	params := url.Values{}
+	params.Set("verb", "ListRecords")
+	params.Set("metadataPrefix", metadataPrefix)
+
+	if set != "" {
+		params.Set("set", set)
+	}
+	if from != "" {
+		params.Set("from", from)
+	}
+	if until != "" {
+		params.Set("until", until)
+	}
+
+	resp, err := c.request(params)
+	if err != nil {
+		return
+	}
+
+	return resp.ListRecords, nil
+}
+
+// resumeListRecords requests the next segment of records for a resumption token that was returned by the
+// previous segment.
+func (c *oaiClient) resumeListRecords(resumptionToken string) (records oaiListRecords, err error) {
+
+	params := url.Values{}
+	params.Set("verb", "ListRecords")
+	params.Set("resumptionToken", resumptionToken)
+
+	resp, err := c.request(params)
+	if err != nil {
+		return
+	}
+
+	return resp.ListRecords, nil
+}
+
+// request sends an OAI-PMH request with the specified parameters and decodes the XML response.
+// Errors reported by the repository are returned as error, except "noRecordsMatch" which simply results in an empty
+// list of records.
+func (c *oaiClient) request(params url.Values) (resp oaiResponse, err error) {
+
+	reqURL := c.baseURL + "?" + params.Encode()
+
+	httpResp, err := c.http.Get(reqURL)
+	if err != nil {
+		return resp, fmt.Errorf("OAI-PMH request '%s' has failed. %s", reqURL, err)
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		return resp, fmt.Errorf("OAI-PMH request '%s' has failed with HTTP status %d.", reqURL, httpResp.StatusCode)
+	}
+
+	err = xml.NewDecoder(httpResp.Body).Decode(&resp)
+	if err != nil {
+		return resp, fmt.Errorf("Could not decode OAI-PMH response for '%s'. %s", reqURL, err)
+	}
+
+	for _, e := range resp.Errors {
+		if e.Code != noRecordsMatchCode {
+			return resp, fmt.Errorf("OAI-PMH repository reported error '%s' for '%s'. %s", e.Code, reqURL, strings.TrimSpace(e.Message))
+		}
+	}
+
+	return resp, nil
+}
+
+// formatDatestamp formats a point in time as datestamp with the granularity that is supported by the repository.
+func formatDatestamp(t time.Time, granularity string) string {
+
+	if granularity == secondGranularity {
+		return t.UTC().Format(secondDateFormat)
+	}
+
+	return t.UTC().Format(dayDateFormat)
+}
@@ -0,0 +1,5 @@
+/*
+Package importer implements the import of publication records from files in bibliographic exchange formats (e.g.
+reading lists that were curated in a reference manager).
+*/
+package importer
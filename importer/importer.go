@@ -0,0 +1,123 @@
+package importer
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+)
+
+import (
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/bibformat"
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/bibhash"
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/model"
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/storage"
+)
+
+// Prefix of the source IDs of imported records. The source ID of an imported record is based on its bibliographic hash,
+// since imported files do not provide stable identifiers.
+const sourceIdPrefix = "import:"
+
+// Summary reports the number of imported records and the number of records that were skipped, since a record with
+// the same DOI or bibliographic hash already exists.
+type Summary struct {
+	Imported   int64
+	Duplicates int64
+}
+
+// ImportFiles imports the publication records of BibTeX or RIS files into the storage. The format of each file is
+// determined by its extension. Afterwards all data derived from the records (e.g. experts, search indices and feeds)
+// is rebuilt. Importing stops at the first file that fails, but the records imported so far are rebuilt anyway.
+func ImportFiles(st *storage.Storage, filenames []string) (summary Summary, err error) {
+
+	for _, filename := range filenames {
+
+		fileSummary, fileErr := importFile(st, filename)
+
+		summary.Imported += fileSummary.Imported
+		summary.Duplicates += fileSummary.Duplicates
+
+		if fileErr != nil {
+			err = fileErr
+			break
+		}
+
+		log.Printf("Importing '%s' was successful. %d records imported, %d duplicates skipped.", filename, fileSummary.Imported, fileSummary.Duplicates)
+	}
+
+	if summary.Imported > 0 {
+		if rebuildErr := st.RebuildAfterIngest(); err == nil {
+			err = rebuildErr
+		}
+	}
+
+	return
+}
+
+// importFile imports the publication records of a BibTeX or RIS file, whose format is determined by its extension.
+func importFile(st *storage.Storage, filename string) (summary Summary, err error) {
+
+	format, err := bibformat.FormatByFilename(filename)
+	if err != nil {
+		return
+	}
+
+	f, err := os.Open(filename)
+	if err != nil {
+		return summary, fmt.Errorf("Could not open file '%s'. %s", filename, err)
+	}
+	defer f.Close()
+
+	if summary, err = Import(st, f, format); err != nil {
+		return summary, fmt.Errorf("Could not import file '%s'. %s", filename, err)
+	}
+
+	return
+}
+
+// Import reads publication records in the specified format (bibformat.FormatBibTeX or bibformat.FormatRIS) and
+// inserts them into the storage. Records are skipped, if a record with the same DOI or bibliographic hash already
+// exists. Derived data (e.g. experts and search indices) is not rebuilt.
+func Import(st *storage.Storage, r io.Reader, format string) (summary Summary, err error) {
+
+	var records []model.Record
+
+	switch format {
+	case bibformat.FormatBibTeX:
+		records, err = bibformat.ReadBibTeX(r)
+	case bibformat.FormatRIS:
+		records, err = bibformat.ReadRIS(r)
+	default:
+		err = fmt.Errorf("Unsupported bibliographic format '%s'.", format)
+	}
+
+	if err != nil {
+		return
+	}
+
+	for i := range records {
+
+		rec := &records[i]
+
+		rec.BibHash = bibhash.Level1(rec.Title, rec.Creators, rec.Year)
+		rec.SourceId = sourceIdPrefix + rec.BibHash
+
+		recordId, err := st.ReadRecordIdByDoiOrBibHash(rec.Doi, rec.BibHash)
+		if err != nil {
+			return summary, err
+		}
+
+		if recordId != 0 {
+			summary.Duplicates++
+			continue
+		}
+
+		if _, err = st.UpsertRecord(rec); err != nil {
+			return summary, err
+		}
+
+		summary.Imported++
+	}
+
+	return summary, nil
+}
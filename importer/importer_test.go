@@ -0,0 +1,132 @@
+package importer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+import (
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/bibformat"
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/config"
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/model"
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/storage"
+)
+
+const testBibTeX = `
+@article{a, author = {Doe, John and Roe, Jane}, title = {Banks and the crisis}, year = 2018, doi = {10.1000/ABC}, keywords = {Banks}}
+@article{b, author = {Doe, John}, title = {Shadow banks}, year = 2019, keywords = {Banks}}
+`
+
+const testRIS = `TY  - JOUR
+TI  - Banks and the financial crisis
+AU  - Doe, John
+PY  - 2018
+DO  - https://doi.org/10.1000/abc
+ER  -
+
+TY  - RPRT
+TI  - Shadow Banks!
+AU  - Doe, J.
+PY  - 2019
+ER  -
+
+TY  - BOOK
+TI  - Bank runs
+AU  - Roe, Jane
+PY  - 2020
+KW  - Banks
+ER  -
+`
+
+func TestImportFiles(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "gozer-import")
+	if err != nil {
+		t.Errorf("Could not create temporary directory. %s", err)
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	bibFile := filepath.Join(dir, "list.bib")
+	risFile := filepath.Join(dir, "list.ris")
+
+	ioutil.WriteFile(bibFile, []byte(testBibTeX), 0644)
+	ioutil.WriteFile(risFile, []byte(testRIS), 0644)
+
+	st := storage.Open(&config.StorageConfiguration{DBFilename: ":memory:"})
+	defer st.Close()
+
+	// Perform test #1: records are imported and duplicates by DOI or bibliographic hash are skipped
+
+	summary, err := ImportFiles(st, []string{bibFile, risFile})
+	if err != nil {
+		t.Errorf("Unexpected error. %s", err)
+		return
+	}
+
+	if summary.Imported != 3 || summary.Duplicates != 2 {
+		t.Errorf("Expected %d imported records and %d duplicates but got %+v.", 3, 2, summary)
+		return
+	}
+
+	// Perform test #2: imported records are part of the feeds and search index
+
+	user, _ := model.NewUserWithSecret("dsj738hFs3d:Kl67jdk")
+	st.CreateUser(&user)
+
+	subs, _ := st.ReadAllSubjects()
+	st.CreateInterest(user.Id, subs.SelectByKeyword("Banks").Id)
+
+	rawRecords, _, err := st.SearchRecordFeed(user.Id, "shadow", nil, 0, 10)
+	if err != nil || len(rawRecords) != 1 {
+		t.Errorf("Expected %d records to match search term, but got %d. %v", 1, len(rawRecords), err)
+		return
+	}
+
+	rawExperts, err := st.ReadExpertFeed(user.Id, 0, 10)
+	if err != nil || len(rawExperts) != 2 {
+		t.Errorf("Expected %d experts in the feed but got %d. %v", 2, len(rawExperts), err)
+		return
+	}
+
+	// Perform test #3: importing the same records again results in duplicates only
+
+	summary, err = Import(st, strings.NewReader(testBibTeX), bibformat.FormatBibTeX)
+	if err != nil || summary.Imported != 0 || summary.Duplicates != 2 {
+		t.Errorf("Expected %d duplicates but got %+v. %v", 2, summary, err)
+		return
+	}
+
+	// Perform test #4: unknown formats are rejected
+
+	if _, err = ImportFiles(st, []string{filepath.Join(dir, "list.txt")}); err == nil {
+		t.Errorf("Expected error for unknown file format.")
+		return
+	}
+
+	// Perform test #5: records of files before a failing file are rebuilt anyway
+
+	brokenFile := filepath.Join(dir, "broken.bib")
+	ioutil.WriteFile(brokenFile, []byte(`@article{broken, title = {Unbalanced}`), 0644)
+
+	st2 := storage.Open(&config.StorageConfiguration{DBFilename: ":memory:"})
+	defer st2.Close()
+
+	if summary, err = ImportFiles(st2, []string{bibFile, brokenFile}); err == nil || summary.Imported != 2 {
+		t.Errorf("Expected error after %d imported records but got %+v. %v", 2, summary, err)
+		return
+	}
+
+	st2.CreateUser(&user)
+	subs, _ = st2.ReadAllSubjects()
+	st2.CreateInterest(user.Id, subs.SelectByKeyword("Banks").Id)
+
+	rawRecords, _, err = st2.SearchRecordFeed(user.Id, "shadow", nil, 0, 10)
+	if err != nil || len(rawRecords) != 1 {
+		t.Errorf("Expected %d records of the first file to match search term, but got %d. %v", 1, len(rawRecords), err)
+		return
+	}
+}
@@ -4,13 +4,20 @@ GoZer is a prototypical database backend that was used to evaluate new ways of i
 package main
 
 import (
+	"flag"
+	"log"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 )
 
 import (
 	"github.com/fzi-forschungszentrum-informatik/dream-gozer/config"
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/harvester"
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/importer"
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/model"
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/recommender"
 	"github.com/fzi-forschungszentrum-informatik/dream-gozer/storage"
 	"github.com/fzi-forschungszentrum-informatik/dream-gozer/storage/ledger"
 	"github.com/fzi-forschungszentrum-informatik/dream-gozer/webapi"
@@ -27,20 +34,185 @@ func waitForTerminateSignal() {
 	<-sigs
 }
 
+// importFiles imports the publication records of BibTeX or RIS files into the database, instead of running the service.
+func importFiles(st *storage.Storage, filenames []string) {
+
+	defer st.Close()
+
+	summary, err := importer.ImportFiles(st, filenames)
+	if err != nil {
+		log.Printf("Importing records has failed. %s", err)
+	}
+
+	log.Printf("%d records imported, %d duplicates skipped.", summary.Imported, summary.Duplicates)
+}
+
+// evaluateRanking replays the interactions stored in the database to measure the precision of the record feed ranking,
+// instead of running the service.
+func evaluateRanking(st *storage.Storage, k int64) {
+
+	defer st.Close()
+
+	eval, err := st.EvaluateRanking(k, rankingHoldout)
+	if err != nil {
+		log.Printf("Evaluating ranking has failed. %s", err)
+		return
+	}
+
+	log.Printf("%d users evaluated. Precision@%d is %.4f with preferences and %.4f without.", eval.Users, eval.K, eval.Precision, eval.BaselinePrecision)
+}
+
+// reviewExpertClaims lists the claims of experts, that await approval, or approves or rejects a single claim, instead
+// of running the service.
+func reviewExpertClaims(st *storage.Storage, approveId int64, rejectId int64) {
+
+	defer st.Close()
+
+	switch {
+	case approveId != 0:
+		if err := st.ApproveExpertClaim(approveId); err != nil {
+			log.Printf("Approving claim %d has failed. %s", approveId, err)
+			return
+		}
+		st.CreateAuditEntry(storage.AuditActorConsole, "expert-claim/approve", strconv.FormatInt(approveId, 10))
+		log.Printf("Claim %d was approved.", approveId)
+	case rejectId != 0:
+		if err := st.RejectExpertClaim(rejectId); err != nil {
+			log.Printf("Rejecting claim %d has failed. %s", rejectId, err)
+			return
+		}
+		st.CreateAuditEntry(storage.AuditActorConsole, "expert-claim/reject", strconv.FormatInt(rejectId, 10))
+		log.Printf("Claim %d was rejected.", rejectId)
+	default:
+		claims, err := st.ReadPendingExpertClaims()
+		if err != nil {
+			log.Printf("Reading claims has failed. %s", err)
+			return
+		}
+		for _, c := range claims {
+			log.Printf("Claim %d: expert %d '%s' is claimed by ORCiD %s of '%s'.", c.Id, c.ExpertId, c.ExpertName, c.OrcId, c.OrcIdName)
+		}
+		log.Printf("%d claims await approval.", len(claims))
+	}
+}
+
+// updateUserRole grants or revokes the role of an administrator, instead of running the service. This is the only
+// way to appoint the first administrator, who may then use the admin API.
+func updateUserRole(st *storage.Storage, guid string, role string) {
+
+	defer st.Close()
+
+	if err := st.UpdateUserRole(guid, role); err != nil {
+		log.Printf("Updating role of user with GUID '%s' has failed. %s", guid, err)
+		return
+	}
+
+	st.CreateAuditEntry(storage.AuditActorConsole, "user-role/update", guid+"="+role)
+	log.Printf("User with GUID '%s' has the role '%s'.", guid, role)
+}
+
+// Fraction of each user's relevant records, that is hidden when evaluating the ranking.
+const rankingHoldout = 0.2
+
 // main runs the GoZer service until an interrupt or terminate signal is raised.
+// If the '-import' option is specified, the files given as arguments are imported instead (e.g. 'gozer -import list.bib').
+// If the '-evaluate' option is specified, the ranking is evaluated against the database given as argument or the
+// configured one (e.g. 'gozer -evaluate -k 10 gozer.db').
+// If the '-claims' option is specified, pending claims of experts are listed, or the claim given by '-approve' or
+// '-reject' is approved or rejected (e.g. 'gozer -claims -approve 42').
+// If the '-grant-admin' or '-revoke-admin' option is specified, the user with the given GUID becomes an administrator
+// or a regular user.
 func main() {
 
 	var webapi webapi.Service
 
+	importMode := flag.Bool("import", false, "Imports the BibTeX (.bib) or RIS (.ris) files given as arguments and exits.")
+	evaluateMode := flag.Bool("evaluate", false, "Evaluates the precision@k of the record feed ranking and exits.")
+	k := flag.Int64("k", 10, "Number of top ranked records that are evaluated with the '-evaluate' option.")
+	claimsMode := flag.Bool("claims", false, "Lists the claims of experts, that await approval, or approves/rejects a claim (see '-approve', '-reject') and exits.")
+	approveId := flag.Int64("approve", 0, "ID of the claim that is approved with the '-claims' option.")
+	rejectId := flag.Int64("reject", 0, "ID of the claim that is rejected with the '-claims' option.")
+	grantAdmin := flag.String("grant-admin", "", "Grants the role of an administrator to the user with the given GUID and exits.")
+	revokeAdmin := flag.String("revoke-admin", "", "Revokes the role of an administrator from the user with the given GUID and exits.")
+
 	conf := config.LoadFromFile()
+
+	if *evaluateMode && flag.NArg() > 0 {
+		conf.Storage.DBFilename = flag.Arg(0)
+	}
+
+	if conf.WebAPI.OrcId.ClientId != "" && conf.WebAPI.OrcId.RedirectURL == "" {
+		log.Fatalf("Reading ORCiD configuration has failed. Redirect URL is required for verifying ORCiDs.")
+	}
+
+	if conf.Recommender.Interval > 0 && conf.Recommender.MinSupport < storage.MinRecordSimilaritySupport {
+		log.Fatalf("Reading recommender configuration has failed. Minimum support must be at least %d.", storage.MinRecordSimilaritySupport)
+	}
+
 	storage := storage.Open(&conf.Storage)
+
+	if err := storage.SetRankingConfiguration(&conf.Ranking); err != nil {
+		log.Fatalf("Reading ranking configuration has failed. %s", err)
+	}
+
+	if *importMode {
+		importFiles(storage, flag.Args())
+		return
+	}
+
+	if *evaluateMode {
+		evaluateRanking(storage, *k)
+		return
+	}
+
+	if *claimsMode {
+		reviewExpertClaims(storage, *approveId, *rejectId)
+		return
+	}
+
+	if *grantAdmin != "" {
+		updateUserRole(storage, *grantAdmin, model.RoleAdmin)
+		return
+	}
+
+	if *revokeAdmin != "" {
+		updateUserRole(storage, *revokeAdmin, model.RoleUser)
+		return
+	}
+
 	ledger := ledger.Open(&conf.Ledger)
+	harvester := harvester.Open(&conf.Harvester, storage)
+	recommender := recommender.Open(&conf.Recommender, storage)
+
+	// Records without bibliographic hash can not receive feedback on the ledger.
+	storage.UpdateBibHashes()
+
+	// Suggestions and the co-authorship graph must reflect the current subjects, experts and records of the database.
+	storage.BuildSuggestionIndex()
+	storage.BuildCoauthorshipGraph()
+
+	// Record feeds are scored with the configured weights, which may have changed since the last start.
+	storage.RebuildAllFeeds()
 
 	go webapi.Run(&conf.WebAPI, storage, ledger)
 
+	if harvester != nil {
+		go harvester.Run()
+	}
+
+	if recommender != nil {
+		go recommender.Run()
+	}
+
 	waitForTerminateSignal()
 
 	webapi.Shutdown()
+	if harvester != nil {
+		harvester.Shutdown()
+	}
+	if recommender != nil {
+		recommender.Shutdown()
+	}
 	if ledger != nil {
 		ledger.Close()
 	}
@@ -0,0 +1,75 @@
+package admin
+
+// User is used to send the account of a user in JSON format to an administrator. The secret of the user is never
+// included. The feedback count is the number of publications the user has given feedback for.
+type User struct {
+	GUID          string `json:"guid"`
+	Role          string `json:"role"`
+	OrcId         string `json:"orcid"`
+	OrcIdVerified bool   `json:"orcid_verified"`
+	FeedbackCount int64  `json:"feedback_count"`
+}
+
+// Users is used to send a segment of all users, e.g. to list them for moderation.
+type Users []User
+
+// Feedback is used to send the feedback of a user for a publication in JSON format to an administrator, for moderation.
+// The ratings are binary flags (0=no,1=yes), like in the feedback of the ploc client app.
+type Feedback struct {
+	GUID         string `json:"guid"`
+	RecordId     int64  `json:"record_id"`
+	RecordTitle  string `json:"record_title"`
+	OrcId        string `json:"orcid"`
+	Relevance    int64  `json:"relevance"`
+	Presentation int64  `json:"presentation"`
+	Methodology  int64  `json:"methodology"`
+}
+
+// Feedbacks is used to send a segment of all feedback, most recent first.
+type Feedbacks []Feedback
+
+// HarvestRun is used to send a run of the harvester against an OAI-PMH repository in JSON format. Times are given
+// in RFC 3339 format. The finish time is empty while the run is in progress, the error is empty if the run succeeded.
+type HarvestRun struct {
+	Id           int64  `json:"id"`
+	Endpoint     string `json:"endpoint"`
+	Started      string `json:"started"`
+	Finished     string `json:"finished"`
+	FromDate     string `json:"from_date"`
+	UntilDate    string `json:"until_date"`
+	RecordCount  int64  `json:"record_count"`
+	DeletedCount int64  `json:"deleted_count"`
+	Error        string `json:"error"`
+}
+
+// HarvestRuns is used to send a list of harvester runs, most recent first.
+type HarvestRuns []HarvestRun
+
+// Statistics is used to send aggregated numbers about the users and the content of the database in JSON format.
+// Verified experts are users with verified ORCiD, sessions only include sessions that have not expired.
+type Statistics struct {
+	Users           int64 `json:"users"`
+	Administrators  int64 `json:"administrators"`
+	VerifiedExperts int64 `json:"verified_experts"`
+	Sessions        int64 `json:"sessions"`
+	Records         int64 `json:"records"`
+	Experts         int64 `json:"experts"`
+	ClaimedExperts  int64 `json:"claimed_experts"`
+	PendingClaims   int64 `json:"pending_claims"`
+	Feedback        int64 `json:"feedback"`
+	RecordBookmarks int64 `json:"record_bookmarks"`
+	ExpertBookmarks int64 `json:"expert_bookmarks"`
+}
+
+// AuditEntry is used to send an action of an administrator in JSON format. The actor is the GUID of the
+// administrator, or "console" for actions on the command line. The time of the action is given in RFC 3339 format.
+type AuditEntry struct {
+	Id      int64  `json:"id"`
+	Actor   string `json:"actor"`
+	Action  string `json:"action"`
+	Target  string `json:"target"`
+	Created string `json:"created"`
+}
+
+// AuditEntries is used to send a segment of the audit log, most recent first.
+type AuditEntries []AuditEntry
@@ -0,0 +1,5 @@
+/*
+Package admin defines the message types of the admin API, which administrators use to moderate users and feedback and
+to maintain the database.
+*/
+package admin
@@ -0,0 +1,103 @@
+package admin
+
+import (
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/model/ploc"
+)
+
+// *** USERS **********************************************
+
+// ReadUsersRequest defines a request of an administrator for a segment of all users, ordered by their registration.
+// The limit defines the maximum number of users that should be returned, while the offset defines the start index.
+type ReadUsersRequest struct {
+	Offset int64 `json:"offset"`
+	Limit  int64 `json:"limit"`
+}
+
+// ReadUsersResponse defines a response returning a segment of all users.
+// Offset and limit duplicate the requested position and number of users from the request.
+type ReadUsersResponse struct {
+	Offset int64 `json:"offset"`
+	Limit  int64 `json:"limit"`
+	Users  Users `json:"users"`
+}
+
+// DeleteUserRequest defines a request of an administrator to delete a user and all user-related information.
+type DeleteUserRequest struct {
+	GUID string `json:"guid"`
+}
+
+// UpdateUserRoleRequest defines a request of an administrator to grant or revoke the role of an administrator.
+// The role is either "user" or "admin".
+type UpdateUserRoleRequest struct {
+	GUID string `json:"guid"`
+	Role string `json:"role"`
+}
+
+// *** FEEDBACK *******************************************
+
+// ReadFeedbackRequest defines a request of an administrator for a segment of the feedback of all users, most recent
+// first. The limit defines the maximum number of feedbacks that should be returned, while the offset defines the start
+// index.
+type ReadFeedbackRequest struct {
+	Offset int64 `json:"offset"`
+	Limit  int64 `json:"limit"`
+}
+
+// ReadFeedbackResponse defines a response returning a segment of the feedback of all users.
+// Offset and limit duplicate the requested position and number of feedbacks from the request.
+type ReadFeedbackResponse struct {
+	Offset    int64     `json:"offset"`
+	Limit     int64     `json:"limit"`
+	Feedbacks Feedbacks `json:"feedbacks"`
+}
+
+// DeleteFeedbackRequest defines a request of an administrator to remove the feedback of a user for a publication.
+// The feedback is removed from the local database, but remains in the public ledger.
+type DeleteFeedbackRequest struct {
+	GUID     string `json:"guid"`
+	RecordId int64  `json:"record_id"`
+}
+
+// *** EXPERT CLAIMS **************************************
+
+// ReadExpertClaimsResponse defines a response returning all claims of experts, that await approval, oldest first.
+type ReadExpertClaimsResponse struct {
+	Claims ploc.ExpertClaims `json:"claims"`
+}
+
+// UpdateExpertClaimRequest defines a request of an administrator to approve or reject a pending claim of an expert.
+type UpdateExpertClaimRequest struct {
+	ClaimId int64 `json:"claim_id"`
+}
+
+// *** MAINTENANCE ****************************************
+
+// ReadIngestStatusResponse defines a response returning the number of publication records and experts in the
+// database and the most recent runs of the harvester.
+type ReadIngestStatusResponse struct {
+	Records     int64       `json:"records"`
+	Experts     int64       `json:"experts"`
+	HarvestRuns HarvestRuns `json:"harvest_runs"`
+}
+
+// ReadStatisticsResponse defines a response returning aggregated numbers about the users and the database content.
+type ReadStatisticsResponse struct {
+	Statistics
+}
+
+// *** AUDIT LOG ******************************************
+
+// ReadAuditLogRequest defines a request of an administrator for a segment of the audit log, most recent first.
+// The limit defines the maximum number of entries that should be returned, while the offset defines the start index.
+type ReadAuditLogRequest struct {
+	Offset int64 `json:"offset"`
+	Limit  int64 `json:"limit"`
+}
+
+// ReadAuditLogResponse defines a response returning a segment of the audit log.
+// Offset and limit duplicate the requested position and number of entries from the request.
+type ReadAuditLogResponse struct {
+	Offset  int64        `json:"offset"`
+	Limit   int64        `json:"limit"`
+	Entries AuditEntries `json:"entries"`
+}
@@ -0,0 +1,85 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Prefixes of ORCiD identifiers in URL form, that are removed while parsing (e.g. "https://orcid.org/").
+var orcIdURLPrefixes = []string{
+	"https://orcid.org/",
+	"http://orcid.org/",
+	"https://sandbox.orcid.org/",
+	"http://sandbox.orcid.org/",
+	"orcid.org/",
+}
+
+// OrcID is the canonical form of an ORCiD identifier, that consists of four groups of four digits separated by dashes
+// (e.g. "0000-0002-1825-0097"). The last character is a check digit, which may also be "X".
+type OrcID string
+
+// ParseOrcID parses an ORCiD identifier, either in its plain form (with or without dashes) or as URL (e.g.
+// "https://orcid.org/0000-0002-1825-0097"), and returns its canonical form. Returns an error if the identifier is
+// malformed or its check digit is wrong.
+func ParseOrcID(s string) (OrcID, error) {
+
+	trimmed := strings.TrimSpace(s)
+
+	for _, prefix := range orcIdURLPrefixes {
+		if len(trimmed) > len(prefix) && strings.EqualFold(trimmed[:len(prefix)], prefix) {
+			trimmed = trimmed[len(prefix):]
+			break
+		}
+	}
+
+	digits := strings.ToUpper(strings.Replace(trimmed, "-", "", -1))
+
+	if len(digits) != 16 || (len(trimmed) != 16 && len(trimmed) != 19) {
+		return "", fmt.Errorf("ORCiD '%s' is malformed. Expected 16 digits like '0000-0002-1825-0097'.", s)
+	}
+
+	if len(trimmed) == 19 && (trimmed[4] != '-' || trimmed[9] != '-' || trimmed[14] != '-') {
+		return "", fmt.Errorf("ORCiD '%s' is malformed. Dashes are expected after every fourth digit.", s)
+	}
+
+	for i, r := range digits {
+		if (r < '0' || r > '9') && !(r == 'X' && i == 15) {
+			return "", fmt.Errorf("ORCiD '%s' is malformed. Character '%c' is not a digit.", s, r)
+		}
+	}
+
+	if check := orcIdCheckDigit(digits[:15]); check != digits[15] {
+		return "", fmt.Errorf("ORCiD '%s' has a wrong check digit. Expected '%c'.", s, check)
+	}
+
+	return OrcID(digits[0:4] + "-" + digits[4:8] + "-" + digits[8:12] + "-" + digits[12:16]), nil
+}
+
+// String returns the canonical form of the ORCiD identifier.
+func (id OrcID) String() string {
+	return string(id)
+}
+
+// Digits returns the 16 digits of the ORCiD identifier without dashes (e.g. "0000000218250097").
+func (id OrcID) Digits() string {
+	return strings.Replace(string(id), "-", "", -1)
+}
+
+// orcIdCheckDigit computes the check digit of the first 15 digits of an ORCiD identifier according to ISO 7064
+// MOD 11-2. A remainder of 10 is represented by "X".
+func orcIdCheckDigit(base string) byte {
+
+	total := 0
+
+	for _, r := range base {
+		total = (total + int(r-'0')) * 2
+	}
+
+	result := (12 - total%11) % 11
+
+	if result == 10 {
+		return 'X'
+	}
+
+	return byte('0' + result)
+}
@@ -0,0 +1,44 @@
+package model
+
+import (
+	"testing"
+)
+
+func TestParseOrcID(t *testing.T) {
+
+	tests := []struct {
+		input string
+		orcId OrcID
+		valid bool
+	}{
+		{"0000-0002-1825-0097", "0000-0002-1825-0097", true},
+		{"https://orcid.org/0000-0002-1825-0097", "0000-0002-1825-0097", true},
+		{" HTTP://ORCID.ORG/0000-0002-1825-0097 ", "0000-0002-1825-0097", true},
+		{"0000000218250097", "0000-0002-1825-0097", true},
+		{"0000-0002-1694-233x", "0000-0002-1694-233X", true},
+		{"0000-0002-1825-0098", "", false}, // wrong check digit
+		{"abcd-efgh-ijkl-mnop", "", false},
+		{"0000-0002-1825-009", "", false},
+		{"00000-002-1825-0097", "", false},
+		{"0000-000X-1825-0097", "", false},
+		{"https://example.org/0000-0002-1825-0097", "", false},
+		{"", "", false},
+	}
+
+	for _, test := range tests {
+
+		orcId, err := ParseOrcID(test.input)
+
+		if test.valid && (err != nil || orcId != test.orcId) {
+			t.Errorf("Expected ORCiD '%s' for '%s' but got '%s'. %s", test.orcId, test.input, orcId, err)
+		}
+
+		if !test.valid && err == nil {
+			t.Errorf("Expected error for malformed ORCiD '%s' but got '%s'.", test.input, orcId)
+		}
+	}
+
+	if digits := OrcID("0000-0002-1694-233X").Digits(); digits != "000000021694233X" {
+		t.Errorf("Expected digits '%s' but got '%s'.", "000000021694233X", digits)
+	}
+}
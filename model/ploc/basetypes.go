@@ -1,5 +1,50 @@
 package ploc
 
+// CatalogueExpertPreview is used to send a preview of an expert, that was found by a search in the whole catalogue,
+// in JSON format to the ploc client app. Beside the preview, it signifies if the user has bookmarked the expert and if
+// the expert is part of the user's expert feed.
+type CatalogueExpertPreview struct {
+	ExpertPreview
+	Bookmarked bool `json:"bookmarked"`
+	InFeed     bool `json:"in_feed"`
+}
+
+// CatalogueExpertPreviews is used to send a list of experts, that were found by a search in the whole catalogue.
+type CatalogueExpertPreviews []CatalogueExpertPreview
+
+// ScoredExpertPreview is used to send a preview of an expert within a user's expert feed in JSON format to the ploc
+// client app. Beside the preview, it contains the score that determines the expert's rank within the feed.
+type ScoredExpertPreview struct {
+	ExpertPreview
+	Score float64 `json:"score"`
+}
+
+// ScoredExpertPreviews is used to send a segment of a user's expert feed.
+type ScoredExpertPreviews []ScoredExpertPreview
+
+// Coauthor is used to send a preview of an expert, that has co-authored publications with another expert, in JSON
+// format to the ploc client app. Beside the preview, it contains the number of publications both have co-authored.
+type Coauthor struct {
+	ExpertPreview
+	RecordCount int64 `json:"record_count"`
+}
+
+// Coauthors is used to send the co-authors of an expert, e.g. to present the expert's network.
+type Coauthors []Coauthor
+
+// CatalogueRecordPreview is used to send a preview of a publication record, that was found by a search in the whole
+// catalogue, in JSON format to the ploc client app. Beside the preview, it signifies if the user has bookmarked the
+// record and if the record is part of the user's record feed.
+type CatalogueRecordPreview struct {
+	RecordPreview
+	Bookmarked bool `json:"bookmarked"`
+	InFeed     bool `json:"in_feed"`
+}
+
+// CatalogueRecordPreviews is used to send a list of publication records, that were found by a search in the whole
+// catalogue.
+type CatalogueRecordPreviews []CatalogueRecordPreview
+
 // Collection is used to send the name of a bookmark collection in JSON format to the ploc client app.
 type Collection struct {
 	Id    int64  `json:"id"`
@@ -17,11 +62,27 @@ type ExpertBookmark ExpertPreview
 // Such a list is used for example to present a user's expert bookmark list.
 type ExpertBookmarks ExpertPreviews
 
+// ExpertClaim is used to send the claim of a user, that an expert of the catalogue is the user, in JSON format.
+// The status is either "pending" (awaiting approval), "approved" or "rejected". The name of the ORCiD's owner is
+// compared against the expert's name for approval.
+type ExpertClaim struct {
+	Id         int64  `json:"id"`
+	ExpertId   int64  `json:"expert_id"`
+	ExpertName string `json:"expert_name"`
+	OrcId      string `json:"orcid"`
+	OrcIdName  string `json:"orcid_name"`
+	Status     string `json:"status"`
+}
+
+// ExpertClaims is used to communicate a list of claims in JSON format (e.g. all claims of a user).
+type ExpertClaims []ExpertClaim
+
 // ExpertPreview is used to send a preview of an expert in JSON format to the ploc client app.
 // ExpertPreview is used to preview an expert in the expert feed.
 type ExpertPreview struct {
 	Id                    int64    `json:"id"`
 	Name                  string   `json:"name"`
+	Affiliation           string   `json:"affiliation"`
 	LastPublicationYear   int64    `json:"last_publication_year"`
 	TotalPublicationCount int64    `json:"total_publication_count"`
 	Subjects              []string `json:"subjects"`
@@ -56,16 +117,19 @@ type Names []string
 
 // RecordBookmark is used to send a preview of a bookmarked record in JSON format to the ploc client app.
 // The preview contains the bookmarks database ID, the title of the record, the publication year, the
-// names of the creators, the related subjects, the kind of publication (e.g. thesis), a flag that signifies if
-// the details of that record were watched before, and the collections of which the bookmarked record is part of.
+// names of the creators, the related subjects, the abstract, the kind of publication (e.g. thesis), a flag that
+// signifies if the details of that record were watched before, and the collections of which the bookmarked record
+// is part of. The visited flag is serialized like the one of record previews, since precomputed bookmarks end with it
+// and the flag is replaced on the fly when bookmarks are read.
 type RecordBookmark struct {
 	Id            int64    `json:"id"`
 	Title         string   `json:"title"`
 	Year          int64    `json:"year"`
 	Creators      string   `json:"creators"`
 	Subjects      []string `json:"subjects"`
+	Abstract      string   `json:"abstract"`
 	Type          int64    `json:"type"`
-	visited       bool     `json:"visited"`
+	Visited       bool     `json:"visited"`
 	CollectionIds []int64  `json:"collection_ids"`
 }
 
@@ -99,6 +163,74 @@ type RecordType struct {
 // RecordTypes is used to send a list of all supported kind of publications in JSON format to the ploc client app.
 type RecordTypes []RecordType
 
+// RecordFeedFilter is used to receive optional filters for the record feed from the ploc client app.
+// Zero values and empty lists disable the respective filter. Records must match all enabled filters, i.e. they must be
+// published within the year range, be of one of the types, be published under open access (if requested), be linked
+// to all included subjects and to none of the excluded subjects.
+type RecordFeedFilter struct {
+	YearFrom          int64   `json:"year_from"`
+	YearTo            int64   `json:"year_to"`
+	Types             []int64 `json:"types"`
+	OpenAccessOnly    bool    `json:"open_access_only"`
+	IncludeSubjectIds []int64 `json:"include_subject_ids"`
+	ExcludeSubjectIds []int64 `json:"exclude_subject_ids"`
+}
+
+// FacetCount is used to send the number of records that share a value of a facet (e.g. the year 2017 or the type
+// report) in JSON format to the ploc client app. The label is a human-readable representation of the value.
+type FacetCount struct {
+	Value int64  `json:"value"`
+	Label string `json:"label"`
+	Count int64  `json:"count"`
+}
+
+// FacetCounts is used to send the counts for all values of a facet.
+type FacetCounts []FacetCount
+
+// RecordFeedFacets is used to send the facet counts of a filtered record feed in JSON format to the ploc client app,
+// so that the app can offer further filters together with the number of matching records (e.g. "2017 (12)").
+// The counts refer to the records that match the current filters. Open access values are the keys of the record's
+// open access state (-1 = false, 0 = unknown, 1 = true). Only the most frequent subjects are counted.
+type RecordFeedFacets struct {
+	RecordCount int64       `json:"record_count"`
+	Years       FacetCounts `json:"years"`
+	Types       FacetCounts `json:"types"`
+	OpenAccess  FacetCounts `json:"open_access"`
+	Subjects    FacetCounts `json:"subjects"`
+}
+
+// SearchHighlight is used to send the text fragments of a search result, that match a user's search term, in JSON
+// format to the ploc client app. The heading is the record's title or the expert's name with all matching terms
+// highlighted. The snippet is a short fragment of the best matching field. Matching terms are enclosed in <b> tags.
+type SearchHighlight struct {
+	Id      int64  `json:"id"`
+	Heading string `json:"heading"`
+	Snippet string `json:"snippet"`
+}
+
+// SearchHighlights is used to send the highlights of a list of search results in the same order as the results.
+type SearchHighlights []SearchHighlight
+
+// Suggestion is used to send a completion of a user's input in JSON format to the ploc client app.
+// The ID refers to the suggested subject, expert or record.
+type Suggestion struct {
+	Id   int64  `json:"id"`
+	Text string `json:"text"`
+}
+
+// Suggestions is used to send a ranked list of completions of a user's input.
+type Suggestions []Suggestion
+
+// SessionTokens is used to send the tokens of a login session to the ploc client app. The access token authenticates
+// requests via HTTP Bearer authentication until it expires (in seconds). The refresh token is used once to obtain
+// fresh tokens for the session.
+type SessionTokens struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+}
+
 // Subject is used to send a single topic or keyword in JSON format to the ploc client app.
 // A subject is used for example to classify a publication, creator or expert.
 type Subject struct {
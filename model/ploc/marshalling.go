@@ -39,13 +39,25 @@ func (r ReadExpertDetailsResponse) MarshalJSON() ([]byte, error) {
 // MarshalJSON converts a ReadExpertFeedResponse into JSON format, paying respect to fields that already
 // contain data in JSON format for performance reasons.
 func (r ReadExpertFeedResponse) MarshalJSON() ([]byte, error) {
-	return marshalRawExpertFeed(r.RawExperts, r.Offset, r.Limit)
+	return marshalRawExpertFeed(r.RawExperts, r.Offset, r.Limit, nil)
+}
+
+// MarshalJSON converts a ReadExpertNetworkResponse into JSON format, paying respect to fields that already
+// contain data in JSON format for performance reasons.
+func (r ReadExpertNetworkResponse) MarshalJSON() ([]byte, error) {
+	return marshalRawList(fmt.Sprintf(`{"expert_id":%d,"coauthors":`, r.ExpertId), r.RawCoauthors), nil
+}
+
+// MarshalJSON converts a ReadExpertPathResponse into JSON format, paying respect to fields that already
+// contain data in JSON format for performance reasons.
+func (r ReadExpertPathResponse) MarshalJSON() ([]byte, error) {
+	return marshalRawList(fmt.Sprintf(`{"from_id":%d,"to_id":%d,"experts":`, r.FromId, r.ToId), r.RawExperts), nil
 }
 
 // MarshalJSON converts a ReadFeedbackFeedResponse into JSON format, paying respect to fields that already
 // contain data in JSON format for performance reasons.
 func (r ReadFeedbackFeedResponse) MarshalJSON() ([]byte, error) {
-	return marshalRawRecordFeed(r.RawRecords, r.Offset, r.Limit)
+	return marshalRawRecordFeed(r.RawRecords, r.Offset, r.Limit, nil)
 }
 
 // MarshalJSON converts a ReadRecordBookmarksResponse into JSON format, paying respect to fields that already
@@ -81,29 +93,68 @@ func (r ReadRecordDetailsResponse) MarshalJSON() ([]byte, error) {
 // MarshalJSON converts a ReadRecordFeedResponse into JSON format, paying respect to fields that already
 // contain data in JSON format for performance reasons.
 func (r ReadRecordFeedResponse) MarshalJSON() ([]byte, error) {
-	return marshalRawRecordFeed(r.RawRecords, r.Offset, r.Limit)
+	return marshalRawRecordFeed(r.RawRecords, r.Offset, r.Limit, struct {
+		Facets RecordFeedFacets `json:"facets"`
+	}{r.Facets})
+}
+
+// MarshalJSON converts a ReadRecordRecommendationsResponse into JSON format, paying respect to fields that already
+// contain data in JSON format for performance reasons.
+func (r ReadRecordRecommendationsResponse) MarshalJSON() ([]byte, error) {
+	return marshalRawRecordFeed(r.RawRecords, r.Offset, r.Limit, nil)
+}
+
+// MarshalJSON converts a ReadRelatedRecordsResponse into JSON format, paying respect to fields that already
+// contain data in JSON format for performance reasons.
+func (r ReadRelatedRecordsResponse) MarshalJSON() ([]byte, error) {
+	return marshalRawList(fmt.Sprintf(`{"record_id":%d,"records":`, r.RecordId), r.RawRecords), nil
+}
+
+// MarshalJSON converts a SearchExpertCatalogueResponse into JSON format, paying respect to fields that already
+// contain data in JSON format for performance reasons.
+func (r SearchExpertCatalogueResponse) MarshalJSON() ([]byte, error) {
+	return marshalRawExpertFeed(r.RawExperts, r.Offset, r.Limit, struct {
+		Highlights SearchHighlights `json:"highlights"`
+	}{r.Highlights})
 }
 
 // MarshalJSON converts a SearchExpertFeedResponse into JSON format, paying respect to fields that already
 // contain data in JSON format for performance reasons.
 func (r SearchExpertFeedResponse) MarshalJSON() ([]byte, error) {
-	return marshalRawExpertFeed(r.RawExperts, r.Offset, r.Limit)
+	return marshalRawExpertFeed(r.RawExperts, r.Offset, r.Limit, struct {
+		Highlights SearchHighlights `json:"highlights"`
+	}{r.Highlights})
+}
+
+// MarshalJSON converts a SearchRecordCatalogueResponse into JSON format, paying respect to fields that already
+// contain data in JSON format for performance reasons.
+func (r SearchRecordCatalogueResponse) MarshalJSON() ([]byte, error) {
+	return marshalRawRecordFeed(r.RawRecords, r.Offset, r.Limit, struct {
+		Highlights SearchHighlights `json:"highlights"`
+	}{r.Highlights})
 }
 
 // MarshalJSON converts a SearchRecordFeedResponse into JSON format, paying respect to fields that already
 // contain data in JSON format for performance reasons.
 func (r SearchRecordFeedResponse) MarshalJSON() ([]byte, error) {
-	return marshalRawRecordFeed(r.RawRecords, r.Offset, r.Limit)
+	return marshalRawRecordFeed(r.RawRecords, r.Offset, r.Limit, struct {
+		Facets     RecordFeedFacets `json:"facets"`
+		Highlights SearchHighlights `json:"highlights"`
+	}{r.Facets, r.Highlights})
 }
 
 // marshalRawExpertFeed assembles a list of expert in JSON format, using a list of raw experts, offset and limit
-// parameters as input.
-func marshalRawExpertFeed(rawExperts []json.RawMessage, offset int64, limit int64) ([]byte, error) {
+// parameters as input. The fields of the optional extra struct (may be nil) are appended.
+func marshalRawExpertFeed(rawExperts []json.RawMessage, offset int64, limit int64, extra interface{}) ([]byte, error) {
 
 	var buffer bytes.Buffer
 
 	prefix := fmt.Sprintf(`{"offset":%d,"limit":%d,"experts":[`, offset, limit)
-	postfix := `]}`
+
+	postfix, err := marshalPostfix(extra)
+	if err != nil {
+		return nil, err
+	}
 
 	buffer.WriteString(prefix)
 
@@ -121,13 +172,17 @@ func marshalRawExpertFeed(rawExperts []json.RawMessage, offset int64, limit int6
 }
 
 // marshalRawRecordFeed assembles a list of records in JSON format, using a list of raw records, offset and limit
-// parameters as input.
-func marshalRawRecordFeed(rawRecords []json.RawMessage, offset int64, limit int64) ([]byte, error) {
+// parameters as input. The fields of the optional extra struct (may be nil) are appended.
+func marshalRawRecordFeed(rawRecords []json.RawMessage, offset int64, limit int64, extra interface{}) ([]byte, error) {
 
 	var buffer bytes.Buffer
 
 	prefix := fmt.Sprintf(`{"offset":%d,"limit":%d,"records":[`, offset, limit)
-	postfix := `]}`
+
+	postfix, err := marshalPostfix(extra)
+	if err != nil {
+		return nil, err
+	}
 
 	buffer.WriteString(prefix)
 
@@ -143,3 +198,44 @@ func marshalRawRecordFeed(rawRecords []json.RawMessage, offset int64, limit int6
 
 	return []byte(buffer.String()), nil
 }
+
+// marshalRawList returns a JSON object, that starts with the specified prefix and ends with a list of raw JSON elements.
+func marshalRawList(prefix string, rawElements []json.RawMessage) []byte {
+
+	var buffer bytes.Buffer
+
+	buffer.WriteString(prefix)
+	buffer.WriteString(`[`)
+
+	delimiter := ``
+
+	for _, e := range rawElements {
+		buffer.WriteString(delimiter)
+		buffer.Write(e)
+		delimiter = `,`
+	}
+
+	buffer.WriteString(`]}`)
+
+	return buffer.Bytes()
+}
+
+// marshalPostfix returns the JSON text that closes a list of raw JSON elements and its enclosing object. The fields of
+// the optional extra struct (may be nil) are added to the enclosing object.
+func marshalPostfix(extra interface{}) (string, error) {
+
+	if extra == nil {
+		return `]}`, nil
+	}
+
+	rawExtra, err := json.Marshal(extra)
+	if err != nil {
+		return "", err
+	}
+
+	if len(rawExtra) <= 2 {
+		return `]}`, nil
+	}
+
+	return `],` + string(rawExtra[1:]), nil
+}
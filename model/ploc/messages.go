@@ -14,8 +14,60 @@ type CreateUserProfileRequest struct {
 
 // CreateUserProfileResponse defines a response to a user after creating a new user profile.
 // The provided GUID is used to relate to a user's profile in future requests (as part of HTTP Basic Auth).
+// The recovery codes allow to reset the secret once each, e.g. if the user's device was lost.
 type CreateUserProfileResponse struct {
-	GUID string `json:"guid"`
+	GUID          string   `json:"guid"`
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// UpdateUserSecretRequest defines a request of a user to replace the secret of the user's profile. The current secret
+// must be provided as well, even if the user is authorized by an access token.
+type UpdateUserSecretRequest struct {
+	CurrentSecret string `json:"current_secret"`
+	Secret        string `json:"secret"`
+}
+
+// RecoverUserProfileRequest defines a request of a user, who has lost the secret, to set a new secret by using up
+// one of the user's recovery codes.
+type RecoverUserProfileRequest struct {
+	GUID         string `json:"guid"`
+	RecoveryCode string `json:"recovery_code"`
+	Secret       string `json:"secret"`
+}
+
+// CreateRecoveryCodesResponse defines a response returning fresh recovery codes, which replace the former ones.
+type CreateRecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// *** SESSIONS *******************************************
+
+// CreateSessionRequest defines a request of a user to log in with the GUID and the secret of the user's profile.
+type CreateSessionRequest struct {
+	GUID   string `json:"guid"`
+	Secret string `json:"secret"`
+}
+
+// CreateSessionResponse defines a response to a user after logging in, returning the tokens of the new session.
+type CreateSessionResponse struct {
+	SessionTokens
+}
+
+// RefreshSessionRequest defines a request of a user to exchange a refresh token for fresh session tokens.
+type RefreshSessionRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// RefreshSessionResponse defines a response returning fresh tokens of a session. The old refresh token is invalid.
+type RefreshSessionResponse struct {
+	SessionTokens
+}
+
+// DeleteSessionRequest defines a request of a user to log out, which revokes the session of the refresh token.
+// If "all" is set, all sessions of the user are revoked.
+type DeleteSessionRequest struct {
+	RefreshToken string `json:"refresh_token"`
+	All          bool   `json:"all"`
 }
 
 // *** EXPERT PROFILE *************************************
@@ -26,9 +78,33 @@ type CreateExpertProfileRequest struct {
 	OrcId string `json:"orcid"`
 }
 
-// ReadExpertProfileResponse defines a response to a user returning its ORCiD.
+// ReadExpertProfileResponse defines a response to a user returning its ORCiD and whether the user has verified it.
 type ReadExpertProfileResponse struct {
-	OrcId string `json:"orcid,omitempty"`
+	OrcId    string `json:"orcid,omitempty"`
+	Verified bool   `json:"verified"`
+}
+
+// CreateOrcIdAuthorizationResponse defines a response returning the URL of the ORCiD provider, where the user signs
+// in to verify the user's ORCiD.
+type CreateOrcIdAuthorizationResponse struct {
+	AuthorizationURL string `json:"authorization_url"`
+}
+
+// CreateExpertClaimRequest defines a request of a user with verified ORCiD to claim that an expert of the catalogue
+// is the user.
+type CreateExpertClaimRequest struct {
+	ExpertId int64 `json:"expert_id"`
+}
+
+// CreateExpertClaimResponse defines a response returning the new claim. The claim is approved immediately if the name
+// of the user's ORCiD matches the expert's name, otherwise it remains pending until approved by an administrator.
+type CreateExpertClaimResponse struct {
+	ExpertClaim
+}
+
+// ReadExpertClaimsResponse defines a response returning all claims of a user, most recent first.
+type ReadExpertClaimsResponse struct {
+	Claims ExpertClaims `json:"claims"`
 }
 
 // *** SUBJECTS *******************************************
@@ -83,10 +159,11 @@ type ReadRecordTypesResponse struct {
 
 // ReadRecordFeedRequest defines a request of a user for a segment a publication feed that respects his interests.
 // The limit defines the maximum number of records that should be returned, while the offset defines the start index within
-// the feed.
+// the feed. The optional filter restricts the feed to records with specific years, types, open access state or subjects.
 type ReadRecordFeedRequest struct {
-	Offset int64 `json:"offset"`
-	Limit  int64 `json:"limit"`
+	Offset int64            `json:"offset"`
+	Limit  int64            `json:"limit"`
+	Filter RecordFeedFilter `json:"filter"`
 }
 
 // ReadRecordFeedResponse defines a response that returns the user's personal publication feed.
@@ -94,20 +171,25 @@ type ReadRecordFeedRequest struct {
 // The list of records contains the specified segment with a preview for each record.
 // The fields Records and RawRecords are used for either marshalling (RawRecords) or unmarshalling (Records).
 // RawRecords directly map to precomputed JSON-data from the database for performance reasons.
+// The facets count the records of the whole filtered feed by year, type, open access state and subject.
 type ReadRecordFeedResponse struct {
 	Offset     int64             `json:"offset"`
 	Limit      int64             `json:"limit"`
 	Records    RecordPreviews    `json:"records"`
 	RawRecords []json.RawMessage `json:"-"`
+	Facets     RecordFeedFacets  `json:"facets"`
 }
 
 // SearchRecordFeedRequest defines a request of a user to show only the publications from his personalized feed that contain the
 // specified search term. The limit defines the maximum number of records that should be returned, while the offset defines
-// the start index within the feed.
+// the start index within the feed. The search term supports the operators AND, OR and NOT, prefixes (e.g. "bank*"), quoted
+// phrases, parentheses and the column filters "title:", "abstract:", "subjects:" and "authors:".
+// The optional filter is applied in the same way as for reading the record feed.
 type SearchRecordFeedRequest struct {
-	SearchTerm string `json:"search_term"`
-	Offset     int64  `json:"offset"`
-	Limit      int64  `json:"limit"`
+	SearchTerm string           `json:"search_term"`
+	Offset     int64            `json:"offset"`
+	Limit      int64            `json:"limit"`
+	Filter     RecordFeedFilter `json:"filter"`
 }
 
 // SearchRecordFeedResponse defines a response returning a user's search results in his personal publication feed.
@@ -115,11 +197,15 @@ type SearchRecordFeedRequest struct {
 // The list of records contains the specified segment within the search results with a preview for each record.
 // The fields Records and RawRecords are used for either marshalling (RawRecords) or unmarshalling (Records).
 // RawRecords directly map to precomputed JSON-data from the database for performance reasons.
+// The facets count all search results by year, type, open access state and subject.
+// The records are ordered by relevance. The highlights contain the matching text fragments of each record.
 type SearchRecordFeedResponse struct {
 	Offset     int64             `json:"offset"`
 	Limit      int64             `json:"limit"`
 	Records    RecordPreviews    `json:"records"`
 	RawRecords []json.RawMessage `json:"-"`
+	Facets     RecordFeedFacets  `json:"facets"`
+	Highlights SearchHighlights  `json:"highlights"`
 }
 
 // ReadRecordDetailsRequest defines a request for detailed information about a record.
@@ -146,6 +232,23 @@ type ReadRecordDetailsResponse struct {
 	RawDetails     json.RawMessage `json:"-"`
 }
 
+// ReadRelatedRecordsRequest defines a request for the publications, that are most similar to a specific record.
+// The limit defines the maximum number of records that should be returned.
+type ReadRelatedRecordsRequest struct {
+	RecordId int64 `json:"record_id"`
+	Limit    int64 `json:"limit"`
+}
+
+// ReadRelatedRecordsResponse defines a response returning the publications, that are most similar to a specific record,
+// in descending order of their similarity. The similarity is based on shared subjects, experts and terms.
+// The fields Records and RawRecords are used for either marshalling (RawRecords) or unmarshalling (Records).
+// RawRecords directly map to precomputed JSON-data from the database for performance reasons.
+type ReadRelatedRecordsResponse struct {
+	RecordId   int64             `json:"record_id"`
+	Records    RecordPreviews    `json:"records"`
+	RawRecords []json.RawMessage `json:"-"`
+}
+
 // *** EXPERT-FEED ****************************************
 
 // ReadExpertFeedRequest defines a request of a user its expert feed. The limit defines the maximum
@@ -157,19 +260,20 @@ type ReadExpertFeedRequest struct {
 
 // ReadExpertFeedResponse defines a response returning a user's personal expert feed.
 // Offset and limit duplicate the requested position and number of records from the request.
-// The list of experts contains the specified segment with preview information for each expert.
+// The list of experts contains the specified segment with preview information and the score for each expert.
 // The fields Experts and RawExperts are used for either marshalling (RawExperts) or unmarshalling (Experts).
 // RawExperts directly map to precomputed JSON-data from the database for performance reasons.
 type ReadExpertFeedResponse struct {
-	Offset     int64             `json:"offset"`
-	Limit      int64             `json:"limit"`
-	Experts    ExpertPreviews    `json:"experts"`
-	RawExperts []json.RawMessage `json:"-"`
+	Offset     int64                `json:"offset"`
+	Limit      int64                `json:"limit"`
+	Experts    ScoredExpertPreviews `json:"experts"`
+	RawExperts []json.RawMessage    `json:"-"`
 }
 
 // SearchExpertFeedRequest defines a request of a user to show only the experts his expert feed that contain the
 // specified search term. The limit defines the maximum number of experts that should be returned, while the offset defines
-// the start index within the feed.
+// the start index within the feed. The search term supports the same syntax as for records, but with the column filters
+// "name:", "titles:" and "subjects:".
 type SearchExpertFeedRequest struct {
 	SearchTerm string `json:"search_term"`
 	Offset     int64  `json:"offset"`
@@ -181,11 +285,13 @@ type SearchExpertFeedRequest struct {
 // The list of experts contains the specified segment within the search results with preview information for each expert.
 // The fields Experts and RawExperts are used for either marshalling (RawExperts) or unmarshalling (Experts).
 // RawExperts directly map to precomputed JSON-data from the database for performance reasons.
+// The experts are ordered by relevance. The highlights contain the matching text fragments of each expert.
 type SearchExpertFeedResponse struct {
 	Offset     int64             `json:"offset"`
 	Limit      int64             `json:"limit"`
 	Experts    ExpertPreviews    `json:"experts"`
 	RawExperts []json.RawMessage `json:"-"`
+	Highlights SearchHighlights  `json:"highlights"`
 }
 
 // ReadExpertDetailsRequest defines a request for detailed information about an expert.
@@ -194,18 +300,128 @@ type ReadExpertDetailsRequest struct {
 }
 
 // ReadExpertDetailsResponse defines a response returning detailed information about an expert.
-// The details include the expert's name, subjects of expertise, ORCiD identifier, last known year of publication,
-// and a list of all its publications.
-// These fields and RawDetails are used for either marshalling (RawDetails) or unmarshalling (ExpertId,Name,...,Records).
+// The details include the expert's name, affiliation, last known year of publication, number of publications,
+// ORCiD identifier, whether a user has claimed the expert's profile, subjects of expertise, and a list of all its
+// publications.
+// These fields and RawDetails are used for either marshalling (RawDetails) or unmarshalling (Id,Name,...,Records).
 // RawDetails directly map to precomputed JSON-data from the database for performance reasons.
 type ReadExpertDetailsResponse struct {
-	ExpertId            int64           `json:"expert_id"`
-	Name                string          `json:"name"`
-	Subjects            Keywords        `json:"subjects"`
-	OrcId               string          `json:"orcid"`
-	LastPublicationYear int64           `json:"last_publication_year"`
-	Records             TinyRecords     `json:"records"`
-	RawDetails          json.RawMessage `json:"-"`
+	Id                    int64           `json:"id"`
+	Name                  string          `json:"name"`
+	Affiliation           string          `json:"affiliation"`
+	LastPublicationYear   int64           `json:"last_publication_year"`
+	TotalPublicationCount int64           `json:"total_publication_count"`
+	OrcId                 string          `json:"orcid"`
+	Claimed               bool            `json:"claimed"`
+	Subjects              Keywords        `json:"subjects"`
+	Records               TinyRecords     `json:"records"`
+	RawDetails            json.RawMessage `json:"-"`
+}
+
+// UpdateExpertDetailsRequest defines a request of the owner of an expert profile (see CreateExpertClaimRequest) to
+// change the expert's affiliation. An empty affiliation removes it.
+type UpdateExpertDetailsRequest struct {
+	ExpertId    int64  `json:"expert_id"`
+	Affiliation string `json:"affiliation"`
+}
+
+// ReadExpertNetworkRequest defines a request for the co-authors of an expert. The limit defines the maximum number of
+// co-authors that should be returned.
+type ReadExpertNetworkRequest struct {
+	ExpertId int64 `json:"expert_id"`
+	Limit    int64 `json:"limit"`
+}
+
+// ReadExpertNetworkResponse defines a response returning the co-authors of an expert, in descending order of the
+// number of publications they have co-authored with the expert.
+// The fields Coauthors and RawCoauthors are used for either marshalling (RawCoauthors) or unmarshalling (Coauthors).
+// RawCoauthors directly map to precomputed JSON-data from the database for performance reasons.
+type ReadExpertNetworkResponse struct {
+	ExpertId     int64             `json:"expert_id"`
+	Coauthors    Coauthors         `json:"coauthors"`
+	RawCoauthors []json.RawMessage `json:"-"`
+}
+
+// ReadExpertPathRequest defines a request for the shortest chain of co-authors, that connects two experts.
+type ReadExpertPathRequest struct {
+	FromId int64 `json:"from_id"`
+	ToId   int64 `json:"to_id"`
+}
+
+// ReadExpertPathResponse defines a response returning the shortest chain of co-authors between two experts,
+// starting with the first and ending with the second expert. The list is empty if both experts are not connected.
+// The fields Experts and RawExperts are used for either marshalling (RawExperts) or unmarshalling (Experts).
+// RawExperts directly map to precomputed JSON-data from the database for performance reasons.
+type ReadExpertPathResponse struct {
+	FromId     int64             `json:"from_id"`
+	ToId       int64             `json:"to_id"`
+	Experts    ExpertPreviews    `json:"experts"`
+	RawExperts []json.RawMessage `json:"-"`
+}
+
+// *** CATALOGUE ******************************************
+
+// ReadSuggestionsRequest defines a request for completions of a user's input, while the user types a search term or
+// looks for interests. The limit defines the maximum number of suggestions of each kind.
+type ReadSuggestionsRequest struct {
+	Input string `json:"input"`
+	Limit int64  `json:"limit"`
+}
+
+// ReadSuggestionsResponse defines a response returning ranked completions of a user's input by subject keywords,
+// expert names and record titles.
+type ReadSuggestionsResponse struct {
+	Subjects Suggestions `json:"subjects"`
+	Experts  Suggestions `json:"experts"`
+	Records  Suggestions `json:"records"`
+}
+
+// SearchRecordCatalogueRequest defines a request of a user to search the whole catalogue of publications, including
+// those that are not part of his personal feed. The limit defines the maximum number of records that should be returned,
+// while the offset defines the start index within the search results. The search term supports the same syntax as
+// for searching the record feed.
+type SearchRecordCatalogueRequest struct {
+	SearchTerm string `json:"search_term"`
+	Offset     int64  `json:"offset"`
+	Limit      int64  `json:"limit"`
+}
+
+// SearchRecordCatalogueResponse defines a response returning a user's search results in the whole catalogue of publications.
+// Offset and limit duplicate the requested position and number of records from the request.
+// The list of records contains the specified segment within the search results, ordered by relevance, with a preview for
+// each record. The previews signify if a record was visited or bookmarked by the user and if it is part of his feed.
+// The fields Records and RawRecords are used for either marshalling (RawRecords) or unmarshalling (Records).
+// The highlights contain the matching text fragments of each record.
+type SearchRecordCatalogueResponse struct {
+	Offset     int64                   `json:"offset"`
+	Limit      int64                   `json:"limit"`
+	Records    CatalogueRecordPreviews `json:"records"`
+	RawRecords []json.RawMessage       `json:"-"`
+	Highlights SearchHighlights        `json:"highlights"`
+}
+
+// SearchExpertCatalogueRequest defines a request of a user to search all experts, including those that are not part
+// of his personal expert feed. The limit defines the maximum number of experts that should be returned, while the offset
+// defines the start index within the search results. The search term supports the same syntax as for searching the
+// expert feed.
+type SearchExpertCatalogueRequest struct {
+	SearchTerm string `json:"search_term"`
+	Offset     int64  `json:"offset"`
+	Limit      int64  `json:"limit"`
+}
+
+// SearchExpertCatalogueResponse defines a response returning a user's search results among all experts.
+// Offset and limit duplicate the requested position and number of experts from the request.
+// The list of experts contains the specified segment within the search results, ordered by relevance, with a preview for
+// each expert. The previews signify if an expert was bookmarked by the user and if it is part of his expert feed.
+// The fields Experts and RawExperts are used for either marshalling (RawExperts) or unmarshalling (Experts).
+// The highlights contain the matching text fragments of each expert.
+type SearchExpertCatalogueResponse struct {
+	Offset     int64                   `json:"offset"`
+	Limit      int64                   `json:"limit"`
+	Experts    CatalogueExpertPreviews `json:"experts"`
+	RawExperts []json.RawMessage       `json:"-"`
+	Highlights SearchHighlights        `json:"highlights"`
 }
 
 // *** FEEDBACK-FEED **************************************
@@ -280,6 +496,15 @@ type ReadRecordBookmarksResponse struct {
 	RawBookmarks []json.RawMessage `json:"-"`
 }
 
+// ExportRecordBookmarksRequest defines a request of a user to export the bookmarked records to a reference manager.
+// If a collection ID is given, only the records of that collection are exported. The format ("bibtex", "ris" or
+// "csljson") is optional. If it is missing, the format is negotiated via the HTTP Accept header.
+// The response is the exported file itself.
+type ExportRecordBookmarksRequest struct {
+	CollectionId int64  `json:"collection_id"`
+	Format       string `json:"format"`
+}
+
 // *** COLLECTIONS *********************************
 
 // CreateRecordBookmarkRequest defines a request of a user to create a new, named collection for publication bookmarks.
@@ -309,6 +534,16 @@ type ReadCollectionsResponse struct {
 	Collections Collections `json:"collections"`
 }
 
+// *** SYNDICATION FEEDS **********************************
+
+// CreateFeedTokenResponse defines a response that returns a fresh token for reading a user's record feed with a feed
+// reader, together with the URLs of the feed in the Atom and RSS formats. Previously created tokens are revoked.
+type CreateFeedTokenResponse struct {
+	Token   string `json:"token"`
+	AtomURL string `json:"atom_url"`
+	RSSURL  string `json:"rss_url"`
+}
+
 // *** FEEDBACK *******************************************
 
 // CreateFeedbackRequest defines a request of a user in the role of a domain expert to add feedback for a specific publication.
@@ -333,6 +568,25 @@ type ReadFeedbackResponse struct {
 
 // *** PERSONALIZATION ************************************
 
+// ReadRecordRecommendationsRequest defines a request of a user for a segment of the publications, that users with
+// similar bookmarks, visits and feedback have found useful. The limit defines the maximum number of records that
+// should be returned, while the offset defines the start index within the recommendations.
+type ReadRecordRecommendationsRequest struct {
+	Offset int64 `json:"offset"`
+	Limit  int64 `json:"limit"`
+}
+
+// ReadRecordRecommendationsResponse defines a response that returns the recommendations for a user.
+// Offset and limit duplicate the requested position and number of records from the request.
+// The fields Records and RawRecords are used for either marshalling (RawRecords) or unmarshalling (Records).
+// RawRecords directly map to precomputed JSON-data from the database for performance reasons.
+type ReadRecordRecommendationsResponse struct {
+	Offset     int64             `json:"offset"`
+	Limit      int64             `json:"limit"`
+	Records    RecordPreviews    `json:"records"`
+	RawRecords []json.RawMessage `json:"-"`
+}
+
 // CreateRecordDislikeRequest defines a request of a user to mark a specific publication as uninteresting.
 // This information is used to improve the user's publication and expert feed composition, by removing similar content.
 type CreateRecordDislikeRequest struct {
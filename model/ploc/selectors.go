@@ -22,6 +22,28 @@ func (exps *ExpertPreviews) SelectByName(name string) ExpertPreview {
 	return ExpertPreview{}
 }
 
+// SelectByName traverses a list of scored expert previews and returns the first that matches the specified expert name.
+func (exps *ScoredExpertPreviews) SelectByName(name string) ScoredExpertPreview {
+	for _, e := range *exps {
+		if e.Name == name {
+			return e
+		}
+	}
+
+	return ScoredExpertPreview{}
+}
+
+// SelectById traverses a list of collections and returns the one with the specified ID.
+func (collections *Collections) SelectById(id int64) Collection {
+	for _, c := range *collections {
+		if c.Id == id {
+			return c
+		}
+	}
+
+	return Collection{}
+}
+
 // SelectByTitle traverses a list of collection names and returns the first that matches the specified title.
 func (collections *Collections) SelectByTitle(title string) Collection {
 	for _, c := range *collections {
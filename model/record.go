@@ -0,0 +1,45 @@
+package model
+
+// Numerical keys for the supported kinds of publication records. The keys are stored in the record's type column
+// and are sent to the ploc client app as they are.
+const (
+	RecordTypeArticle int64 = 0
+	RecordTypeBook    int64 = 1
+	RecordTypeOther   int64 = 2
+	RecordTypePaper   int64 = 3
+	RecordTypeReport  int64 = 4
+	RecordTypeThesis  int64 = 5
+)
+
+// Open access states of a publication record, as they are stored in the record's oa column.
+const (
+	OpenAccessFalse   int64 = -1
+	OpenAccessUnknown int64 = 0
+	OpenAccessTrue    int64 = 1
+)
+
+// Creator encapsulates the name of an author that is related to a publication record.
+type Creator struct {
+	FirstName string
+	LastName  string
+}
+
+// Record encapsulates the bibliographic metadata of a publication, as it is provided by an external data source
+// (e.g. an OAI-PMH repository). The source ID uniquely identifies the record within its data source and is used to
+// update already existing records.
+type Record struct {
+	// Non-optional attributes
+	SourceId string
+	Title    string
+	Type     int64
+	Year     int64
+	// Optional attributes
+	Abstract       string
+	Doi            string
+	OpenAccess     int64
+	RepositoryLink string
+	PDFLink        string
+	BibHash        string
+	Creators       []Creator
+	Subjects       []string
+}
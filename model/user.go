@@ -3,6 +3,7 @@ package model
 import (
 	"encoding/hex"
 	"fmt"
+	"unicode/utf8"
 )
 
 import (
@@ -10,15 +11,23 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+// Roles of users. Administrators may additionally use the admin API.
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
 // User encapsulates all identity-related information about a user. That includes the public GUID, the internal
-// database ID, the ORCiD identifier and the hashed secret.
+// database ID, the ORCiD identifier with its verification status, the hashed secret and the user's role.
 type User struct {
 	// Non-optional attributes
 	Id           int64
 	GUID         string
 	HashedSecret string
+	Role         string // RoleUser or RoleAdmin
 	// Optional attributes
-	OrcId string
+	OrcId         string
+	OrcIdVerified bool // true if the user has proven to own the ORCiD via OAuth2
 }
 
 // Authorize checks wether the provided secret matches the hashed secret of a user.
@@ -43,7 +52,52 @@ func (a *User) Equals(b *User) bool {
 	return a.Id == b.Id &&
 		a.GUID == b.GUID &&
 		a.HashedSecret == b.HashedSecret &&
-		a.OrcId == b.OrcId
+		a.OrcId == b.OrcId &&
+		a.OrcIdVerified == b.OrcIdVerified &&
+		a.Role == b.Role
+}
+
+// IsAdmin returns true if the user has the role of an administrator.
+func (u *User) IsAdmin() bool {
+	return u.Role == RoleAdmin
+}
+
+// ValidateRole checks whether a role is known.
+func ValidateRole(role string) error {
+
+	if role != RoleUser && role != RoleAdmin {
+		return fmt.Errorf("Unknown role '%s'. Must be '%s' or '%s'.", role, RoleUser, RoleAdmin)
+	}
+
+	return nil
+}
+
+// Minimum number of characters of a user's secret.
+const MinSecretLength = 16
+
+// ValidateSecret checks whether a user-defined secret is strong enough to protect a user's profile.
+// TODO: Better check entropy instead of password length.
+func ValidateSecret(secret string) error {
+
+	if utf8.RuneCountInString(secret) < MinSecretLength {
+		return fmt.Errorf("Secret phrase is too short. Must contain at least %d characters.", MinSecretLength)
+	}
+
+	return nil
+}
+
+// SetSecret replaces the hashed secret of a user by the hashed version of the specified secret.
+func (u *User) SetSecret(secret string) error {
+
+	// Compute hash for salted secret.
+	hash, err := bcrypt.GenerateFromPassword([]byte(secret), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("Could not compute salted password hash. %s", err)
+	}
+
+	u.HashedSecret = hex.EncodeToString(hash)
+
+	return nil
 }
 
 // NewUserWithSecret takes a user-defined secret and creates a new user object with a fresh GUID and the hashed
@@ -51,9 +105,7 @@ func (a *User) Equals(b *User) bool {
 func NewUserWithSecret(secret string) (u User, err error) {
 
 	// Compute hash for salted secret.
-	hash, err := bcrypt.GenerateFromPassword([]byte(secret), bcrypt.DefaultCost)
-	if err != nil {
-		err = fmt.Errorf("Could not compute salted password hash. %s", err)
+	if err = u.SetSecret(secret); err != nil {
 		return
 	}
 
@@ -64,9 +116,9 @@ func NewUserWithSecret(secret string) (u User, err error) {
 		return
 	}
 
-	// Encode the GUID and the hash as strings.
+	// Encode the GUID as string.
 	u.GUID = freshGUID.String()
-	u.HashedSecret = hex.EncodeToString(hash)
+	u.Role = RoleUser
 
 	return u, nil
 }
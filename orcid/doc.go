@@ -0,0 +1,5 @@
+/*
+Package orcid implements an OAuth2 client for ORCiD-compatible providers, that verifies the ORCiD of a user by the
+authorization code flow with PKCE (proof key for code exchange).
+*/
+package orcid
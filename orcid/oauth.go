@@ -0,0 +1,126 @@
+package orcid
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+import (
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/config"
+)
+
+// Identity defines the ORCiD and the name of a user, as confirmed by the provider.
+type Identity struct {
+	OrcId string `json:"orcid"`
+	Name  string `json:"name"`
+}
+
+// Client implements the authorization code flow against a single ORCiD-compatible provider.
+type Client struct {
+	conf *config.OrcIdConfiguration
+	http *http.Client
+}
+
+// NewClient creates a client for the provider specified by the configuration.
+func NewClient(conf *config.OrcIdConfiguration) *Client {
+	return &Client{conf: conf, http: &http.Client{Timeout: 30 * time.Second}}
+}
+
+// Enabled returns true, if a client ID is configured, so that ORCiDs can be verified.
+func (c *Client) Enabled() bool {
+	return c.conf.ClientId != ""
+}
+
+// AuthorizationURL returns the URL of the provider, where the user confirms that GoZer may read the user's ORCiD.
+// The provider redirects the user to the redirect URL afterwards, passing an authorization code and the state.
+// The code challenge is derived from the verifier, which has to be kept secret until the code is exchanged.
+func (c *Client) AuthorizationURL(state string, verifier string, redirectURL string) string {
+
+	params := url.Values{}
+	params.Set("client_id", c.conf.ClientId)
+	params.Set("response_type", "code")
+	params.Set("scope", c.conf.Scope)
+	params.Set("redirect_uri", redirectURL)
+	params.Set("state", state)
+	params.Set("code_challenge", Challenge(verifier))
+	params.Set("code_challenge_method", "S256")
+
+	separator := "?"
+	if strings.Contains(c.conf.AuthorizeURL, "?") {
+		separator = "&"
+	}
+
+	return c.conf.AuthorizeURL + separator + params.Encode()
+}
+
+// Exchange redeems an authorization code at the token endpoint of the provider and returns the identity of the user,
+// who has authorized GoZer. The verifier and the redirect URL must be the same as for the authorization URL.
+func (c *Client) Exchange(code string, verifier string, redirectURL string) (id Identity, err error) {
+
+	form := url.Values{}
+	form.Set("client_id", c.conf.ClientId)
+	form.Set("client_secret", c.conf.ClientSecret)
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", redirectURL)
+	form.Set("code_verifier", verifier)
+
+	req, err := http.NewRequest("POST", c.conf.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return id, fmt.Errorf("Could not create token request. %s", err)
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return id, fmt.Errorf("Token request to '%s' has failed. %s", c.conf.TokenURL, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return id, fmt.Errorf("Could not read token response. %s", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return id, fmt.Errorf("Token endpoint has rejected authorization code with HTTP status %d. %s", resp.StatusCode, body)
+	}
+
+	if err = json.Unmarshal(body, &id); err != nil {
+		return id, fmt.Errorf("Could not parse token response. %s", err)
+	}
+
+	if id.OrcId == "" {
+		return id, fmt.Errorf("Token response does not contain an ORCiD.")
+	}
+
+	return id, nil
+}
+
+// NewVerifier returns a fresh random code verifier for PKCE (43 characters, as recommended by RFC 7636).
+func NewVerifier() (string, error) {
+
+	b := make([]byte, 32)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Challenge returns the code challenge of a code verifier (method "S256").
+func Challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
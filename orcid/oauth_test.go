@@ -0,0 +1,83 @@
+package orcid
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+import (
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/config"
+)
+
+func TestAuthorizationCodeFlow(t *testing.T) {
+
+	// Setup mock provider, that grants a single authorization code
+
+	const code = "Xc83Hs"
+
+	var challenge string
+
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		r.ParseForm()
+
+		if r.PostForm.Get("grant_type") != "authorization_code" || r.PostForm.Get("code") != code ||
+			r.PostForm.Get("client_secret") != "secret" || Challenge(r.PostForm.Get("code_verifier")) != challenge {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+
+		fmt.Fprint(w, `{"access_token":"x","token_type":"bearer","orcid":"0000-0002-1825-0097","name":"Josiah Carberry"}`)
+	}))
+	defer provider.Close()
+
+	conf := config.OrcIdConfiguration{
+		ClientId:     "gozer",
+		ClientSecret: "secret",
+		AuthorizeURL: provider.URL + "/oauth/authorize",
+		TokenURL:     provider.URL + "/oauth/token",
+		Scope:        "/authenticate",
+	}
+
+	client := NewClient(&conf)
+	redirectURL := "https://example.org/orcid/callback"
+
+	// Perform test #1: the authorization URL contains the state and the code challenge
+
+	verifier, err := NewVerifier()
+	if err != nil || len(verifier) != 43 {
+		t.Errorf("Expected code verifier of length %d but got '%s'. %s", 43, verifier, err)
+		return
+	}
+
+	authURL, err := url.Parse(client.AuthorizationURL("abc", verifier, redirectURL))
+	if err != nil {
+		t.Errorf("Unexpected error. %s", err)
+		return
+	}
+
+	params := authURL.Query()
+	challenge = params.Get("code_challenge")
+
+	if params.Get("state") != "abc" || params.Get("client_id") != "gozer" || params.Get("redirect_uri") != redirectURL ||
+		params.Get("code_challenge_method") != "S256" || challenge != Challenge(verifier) {
+		t.Errorf("Unexpected parameters of authorization URL '%s'.", authURL)
+		return
+	}
+
+	// Perform test #2: the code is only exchanged with the right verifier
+
+	if _, err = client.Exchange(code, verifier+"x", redirectURL); err == nil {
+		t.Errorf("Expected error for wrong code verifier.")
+		return
+	}
+
+	id, err := client.Exchange(code, verifier, redirectURL)
+	if err != nil || id.OrcId != "0000-0002-1825-0097" {
+		t.Errorf("Expected ORCiD '%s' but got '%s'. %s", "0000-0002-1825-0097", id.OrcId, err)
+		return
+	}
+}
@@ -0,0 +1,6 @@
+/*
+Package ratelimit implements the throttling of requests by token buckets and the temporary lockout of clients after
+repeated failed authorizations. The counters are kept by a store, which is held in memory per default, but may be
+replaced by a store that is shared by several GoZer instances.
+*/
+package ratelimit
@@ -0,0 +1,152 @@
+package ratelimit
+
+import (
+	"time"
+)
+
+import (
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/config"
+)
+
+// Limiter decides whether requests of a client IP address or of a user (GUID) are permitted, according to the rate
+// limit configuration. Failed authorizations are counted per IP address and per pair of IP address and GUID; both are
+// locked temporarily after too many failures. A GUID is never locked on its own, so that other clients can not lock
+// out a user by guessing the user's secret. A nil or disabled limiter permits all requests.
+type Limiter struct {
+	conf  config.RateLimitConfiguration
+	store Store
+	now   func() time.Time
+}
+
+// NewLimiter creates a limiter, that keeps its counters in the store. If the store is nil, a memory store is used.
+func NewLimiter(conf *config.RateLimitConfiguration, store Store) *Limiter {
+
+	if store == nil {
+		store = NewMemoryStore()
+	}
+
+	return &Limiter{conf: *conf, store: store, now: time.Now}
+}
+
+// Enabled returns true if requests are limited.
+func (l *Limiter) Enabled() bool {
+	return l != nil && l.conf.Enable
+}
+
+// AllowIP takes a token of the IP address' bucket. Returns zero if the request is permitted, otherwise the duration
+// after which the client may retry.
+func (l *Limiter) AllowIP(ip string) (retryAfter time.Duration) {
+
+	if !l.Enabled() {
+		return 0
+	}
+
+	return l.allow(ipKey(ip), l.conf.IPRate/60, l.conf.IPBurst)
+}
+
+// AllowGUID takes a token of the user's bucket. It must only be called for authenticated requests, so that other
+// clients can not exhaust the bucket. Returns zero if the request is permitted, otherwise the duration after which
+// the client may retry.
+func (l *Limiter) AllowGUID(guid string) (retryAfter time.Duration) {
+
+	if !l.Enabled() {
+		return 0
+	}
+
+	return l.store.Take(guidKey(guid), l.conf.GUIDRate/60, l.conf.GUIDBurst, l.now())
+}
+
+// AllowAttempt checks whether the IP address may try to authorize as the user with the GUID, which is not the case
+// after too many failed attempts of the same IP address for the same GUID. Returns zero if the attempt is permitted,
+// otherwise the duration after which the client may retry.
+func (l *Limiter) AllowAttempt(ip string, guid string) (retryAfter time.Duration) {
+
+	if !l.Enabled() {
+		return 0
+	}
+
+	now := l.now()
+
+	if until := l.store.LockedUntil(attemptKey(ip, guid), now); !until.IsZero() {
+		return until.Sub(now)
+	}
+
+	return 0
+}
+
+// AllowProfile takes a token of the IP address' bucket for creating user profiles. Returns zero if a new profile is
+// permitted, otherwise the duration after which the client may retry.
+func (l *Limiter) AllowProfile(ip string) (retryAfter time.Duration) {
+
+	if !l.Enabled() {
+		return 0
+	}
+
+	return l.allow("profile:"+ip, l.conf.ProfileRate/3600, l.conf.ProfileBurst)
+}
+
+// Fail counts a failed authorization of the IP address and, if the GUID is not empty, of the IP address for the GUID.
+// Each of them is locked for the lockout duration, as soon as its failures within the lockout duration reach the
+// maximum.
+func (l *Limiter) Fail(ip string, guid string) {
+
+	if !l.Enabled() || l.conf.MaxFailures <= 0 {
+		return
+	}
+
+	l.fail(ipKey(ip))
+
+	if guid != "" {
+		l.fail(attemptKey(ip, guid))
+	}
+}
+
+// Succeed forgets the failed authorizations of the IP address for the GUID, after the user has authorized
+// successfully.
+func (l *Limiter) Succeed(ip string, guid string) {
+
+	if !l.Enabled() {
+		return
+	}
+
+	l.store.ResetFailures(attemptKey(ip, guid))
+}
+
+// allow checks the lock of the key and takes a token of its bucket.
+func (l *Limiter) allow(key string, rate float64, burst int) (retryAfter time.Duration) {
+
+	now := l.now()
+
+	if until := l.store.LockedUntil(key, now); !until.IsZero() {
+		return until.Sub(now)
+	}
+
+	return l.store.Take(key, rate, burst, now)
+}
+
+// fail counts a failure of the key and locks it, if the failures reach the maximum.
+func (l *Limiter) fail(key string) {
+
+	now := l.now()
+	lockout := time.Duration(l.conf.LockoutDuration) * time.Minute
+
+	if l.store.AddFailure(key, lockout, now) >= l.conf.MaxFailures {
+		l.store.Lock(key, now.Add(lockout))
+		l.store.ResetFailures(key)
+	}
+}
+
+// ipKey returns the key of an IP address in the store.
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
+
+// guidKey returns the key of a GUID in the store.
+func guidKey(guid string) string {
+	return "guid:" + guid
+}
+
+// attemptKey returns the key of the authorization attempts of an IP address for a GUID in the store.
+func attemptKey(ip string, guid string) string {
+	return "attempt:" + ip + "/" + guid
+}
@@ -0,0 +1,120 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/config"
+)
+
+func TestLimiter(t *testing.T) {
+
+	conf := config.RateLimitConfiguration{
+		Enable:          true,
+		IPRate:          60, // one request per second
+		IPBurst:         3,
+		GUIDRate:        60,
+		GUIDBurst:       1,
+		ProfileRate:     1,
+		ProfileBurst:    1,
+		MaxFailures:     3,
+		LockoutDuration: 10,
+	}
+
+	now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	l := NewLimiter(&conf, nil)
+	l.now = func() time.Time { return now }
+
+	// Perform test #1: requests are permitted up to the burst and refilled by the rate
+
+	for i := 0; i < 3; i++ {
+		if wait := l.AllowIP("10.0.0.1"); wait != 0 {
+			t.Errorf("Expected request %d to be permitted, but has to wait %v.", i, wait)
+			return
+		}
+	}
+
+	if wait := l.AllowIP("10.0.0.1"); wait != time.Second {
+		t.Errorf("Expected to wait %v but got %v.", time.Second, wait)
+		return
+	}
+
+	if wait := l.AllowIP("10.0.0.2"); wait != 0 {
+		t.Errorf("Expected requests of other IP addresses to be permitted, but has to wait %v.", wait)
+		return
+	}
+
+	now = now.Add(1500 * time.Millisecond)
+
+	if wait := l.AllowIP("10.0.0.1"); wait != 0 {
+		t.Errorf("Expected refilled token to be available, but has to wait %v.", wait)
+		return
+	}
+
+	// Perform test #2: profiles are limited per hour
+
+	l.AllowProfile("10.0.0.1")
+
+	if wait := l.AllowProfile("10.0.0.1"); wait != time.Hour {
+		t.Errorf("Expected to wait %v for a new profile but got %v.", time.Hour, wait)
+		return
+	}
+
+	// Perform test #3: failures lock the IP address and its attempts for a GUID, unless they are interrupted by a
+	// success, but never the GUID itself
+
+	l.Fail("10.0.0.3", "guid-a")
+	l.Fail("10.0.0.3", "guid-a")
+	l.Succeed("10.0.0.3", "guid-a")
+	l.Fail("10.0.0.3", "guid-a")
+
+	if wait := l.AllowAttempt("10.0.0.3", "guid-a"); wait != 0 {
+		t.Errorf("Expected attempts for GUID to be permitted after a success, but has to wait %v.", wait)
+		return
+	}
+
+	l.Fail("10.0.0.3", "guid-a")
+	l.Fail("10.0.0.3", "guid-a")
+
+	if wait := l.AllowAttempt("10.0.0.3", "guid-a"); wait != 10*time.Minute {
+		t.Errorf("Expected attempts for GUID to be locked for %v but got %v.", 10*time.Minute, wait)
+		return
+	}
+
+	if wait := l.AllowIP("10.0.0.3"); wait != 10*time.Minute {
+		t.Errorf("Expected IP address to be locked for %v but got %v.", 10*time.Minute, wait)
+		return
+	}
+
+	if wait := l.AllowAttempt("10.0.0.4", "guid-a"); wait != 0 {
+		t.Errorf("Expected attempts of other IP addresses to be permitted, but has to wait %v.", wait)
+		return
+	}
+
+	if wait := l.AllowGUID("guid-a"); wait != 0 {
+		t.Errorf("Expected authenticated requests of GUID to be permitted, but has to wait %v.", wait)
+		return
+	}
+
+	now = now.Add(10 * time.Minute)
+
+	if wait := l.AllowAttempt("10.0.0.3", "guid-a"); wait != 0 {
+		t.Errorf("Expected lock of attempts to be lifted, but has to wait %v.", wait)
+		return
+	}
+
+	// Perform test #4: disabled limiters permit all requests
+
+	conf.Enable = false
+	l = NewLimiter(&conf, nil)
+
+	for i := 0; i < 10; i++ {
+		if wait := l.AllowIP("10.0.0.1"); wait != 0 {
+			t.Errorf("Expected disabled limiter to permit all requests, but has to wait %v.", wait)
+			return
+		}
+	}
+}
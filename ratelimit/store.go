@@ -0,0 +1,182 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Store keeps the token buckets, the counts of failures and the locks of the rate limiter. Keys are opaque strings
+// (e.g. "ip:127.0.0.1"). Implementations must be safe for concurrent use.
+type Store interface {
+
+	// Take removes a token from the bucket of the key, which holds up to burst tokens and is refilled by rate tokens
+	// per second. Returns zero if a token was available, otherwise the duration until the next token is available.
+	Take(key string, rate float64, burst int, now time.Time) (wait time.Duration)
+
+	// AddFailure counts a failure of the key and returns the number of failures, since the first failure within the
+	// window. Failures before the window are forgotten.
+	AddFailure(key string, window time.Duration, now time.Time) (count int)
+
+	// ResetFailures forgets all failures of the key.
+	ResetFailures(key string)
+
+	// Lock locks the key until the specified time.
+	Lock(key string, until time.Time)
+
+	// LockedUntil returns the time when the lock of the key ends, or zero time if the key is not locked.
+	LockedUntil(key string, now time.Time) (until time.Time)
+}
+
+// Interval between two removals of outdated entries from a memory store.
+const pruneInterval = time.Minute
+
+// MemoryStore is a Store, that keeps all counters in the memory of a single GoZer instance.
+type MemoryStore struct {
+	mutex    sync.Mutex
+	buckets  map[string]*bucket
+	failures map[string]*failures
+	locks    map[string]time.Time
+	pruned   time.Time
+}
+
+// bucket holds the tokens of a key, as of the time of the last update.
+type bucket struct {
+	tokens  float64
+	rate    float64
+	burst   float64
+	updated time.Time
+}
+
+// failures holds the number of failures of a key within the window, that starts with the first failure.
+type failures struct {
+	count int
+	ends  time.Time
+}
+
+// NewMemoryStore creates an empty memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets:  make(map[string]*bucket),
+		failures: make(map[string]*failures),
+		locks:    make(map[string]time.Time),
+	}
+}
+
+// Take implements Store.Take.
+func (s *MemoryStore) Take(key string, rate float64, burst int, now time.Time) (wait time.Duration) {
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.prune(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(burst), updated: now}
+		s.buckets[key] = b
+	}
+
+	b.rate, b.burst = rate, float64(burst)
+	b.refill(now)
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0
+	}
+
+	if rate <= 0 {
+		return time.Duration(math.MaxInt64)
+	}
+
+	return time.Duration((1 - b.tokens) / rate * float64(time.Second))
+}
+
+// AddFailure implements Store.AddFailure.
+func (s *MemoryStore) AddFailure(key string, window time.Duration, now time.Time) (count int) {
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.prune(now)
+
+	f, ok := s.failures[key]
+	if !ok || !now.Before(f.ends) {
+		f = &failures{ends: now.Add(window)}
+		s.failures[key] = f
+	}
+
+	f.count++
+
+	return f.count
+}
+
+// ResetFailures implements Store.ResetFailures.
+func (s *MemoryStore) ResetFailures(key string) {
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.failures, key)
+}
+
+// Lock implements Store.Lock.
+func (s *MemoryStore) Lock(key string, until time.Time) {
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.locks[key] = until
+}
+
+// LockedUntil implements Store.LockedUntil.
+func (s *MemoryStore) LockedUntil(key string, now time.Time) (until time.Time) {
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if until = s.locks[key]; !until.After(now) {
+		return time.Time{}
+	}
+
+	return until
+}
+
+// prune removes full buckets, outdated failures and expired locks, so that the store does not grow with each client
+// that has ever sent a request. It runs at most once per prune interval.
+func (s *MemoryStore) prune(now time.Time) {
+
+	if now.Sub(s.pruned) < pruneInterval {
+		return
+	}
+
+	s.pruned = now
+
+	for key, b := range s.buckets {
+		if b.refill(now); b.tokens >= b.burst {
+			delete(s.buckets, key)
+		}
+	}
+
+	for key, f := range s.failures {
+		if !now.Before(f.ends) {
+			delete(s.failures, key)
+		}
+	}
+
+	for key, until := range s.locks {
+		if !until.After(now) {
+			delete(s.locks, key)
+		}
+	}
+}
+
+// refill adds the tokens, that have accrued since the last update, up to the burst.
+func (b *bucket) refill(now time.Time) {
+
+	if elapsed := now.Sub(b.updated).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(b.burst, b.tokens+elapsed*b.rate)
+	}
+
+	b.updated = now
+}
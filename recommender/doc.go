@@ -0,0 +1,5 @@
+/*
+Package recommender implements a job that periodically derives similarities between records from the interactions of
+all users, which are used to recommend records that similar users have found useful (collaborative filtering).
+*/
+package recommender
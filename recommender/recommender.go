@@ -0,0 +1,69 @@
+package recommender
+
+import (
+	"log"
+	"time"
+)
+
+import (
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/config"
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/storage"
+)
+
+// Recommender periodically rebuilds the similarities between records, so that recommendations reflect the latest
+// bookmarks, visits and feedback of the users.
+type Recommender struct {
+	conf *config.RecommenderConfiguration
+	st   *storage.Storage
+	stop chan bool // signal to stop periodic runs
+	down chan bool // signal for successful shutdown
+}
+
+// Open initializes the recommender accordingly to the provided global configuration.
+// Returns nil if no interval is configured.
+func Open(conf *config.RecommenderConfiguration, st *storage.Storage) *Recommender {
+
+	if conf.Interval <= 0 {
+		return nil
+	}
+
+	return &Recommender{
+		conf: conf,
+		st:   st,
+		stop: make(chan bool, 1),
+		down: make(chan bool, 1),
+	}
+}
+
+// Run rebuilds the similarities immediately and then repeatedly after the configured interval, until Shutdown is called.
+func (r *Recommender) Run() {
+
+	interval := time.Duration(r.conf.Interval) * time.Hour
+
+	log.Printf("Building record similarities every %v.", interval)
+
+	for {
+		if pairCount, err := r.st.BuildRecordSimilarities(r.conf.MinSupport); err != nil {
+			log.Printf("Building record similarities has failed. %s", err)
+		} else {
+			log.Printf("%d similar record pairs with a support of at least %d users found.", pairCount, r.conf.MinSupport)
+		}
+
+		select {
+		case <-r.stop:
+			r.down <- true
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Shutdown stops periodic runs and waits until a running one has finished.
+func (r *Recommender) Shutdown() {
+
+	r.stop <- true
+
+	<-r.down
+
+	log.Print("Stopping recommender was successful.")
+}
@@ -0,0 +1,227 @@
+package storage
+
+import (
+	"database/sql"
+	"log"
+	"time"
+)
+
+import (
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/model"
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/model/admin"
+)
+
+// Actor of audit entries for actions that were performed on the command line instead of the admin API.
+const AuditActorConsole = "console"
+
+// Number of harvester runs, that are returned as part of the ingest status.
+const ingestStatusRunCount = 20
+
+// CreateAuditEntry appends an action of an administrator to the audit log. The actor is the GUID of the administrator
+// (or AuditActorConsole), the target identifies the affected entity (e.g. a GUID or a claim ID).
+func (st *Storage) CreateAuditEntry(actor string, action string, target string) (err error) {
+
+	_, err = st.db.Exec("INSERT INTO audit_log (actor,action,target,created) VALUES (?,?,?,?)",
+		actor, action, target, time.Now().Unix())
+	if err != nil {
+		log.Printf("Database error. Could not write audit entry '%s' of '%s'. %s", action, actor, err)
+		return
+	}
+
+	return
+}
+
+// DeleteFeedback removes the feedback of a user for a record. Note that the feedback still remains in the public
+// ledger. Returns sql.ErrNoRows if the user has not given feedback for the record.
+func (st *Storage) DeleteFeedback(uid int64, recordId int64) (err error) {
+
+	res, err := st.db.Exec("DELETE FROM feedback WHERE user_id=? AND record_id=?", uid, recordId)
+	if err != nil {
+		log.Printf("Database error. Could not delete feedback of user %d for record %d. %s", uid, recordId, err)
+		return
+	}
+
+	if count, _ := res.RowsAffected(); count == 0 {
+		return sql.ErrNoRows
+	}
+
+	return
+}
+
+// ReadAllFeedback returns a segment of the feedback of all users, most recent first.
+func (st *Storage) ReadAllFeedback(offset int64, limit int64) (feedbacks admin.Feedbacks, err error) {
+
+	const query = `
+		SELECT u.guid,f.record_id,r.title,f.orcid,f.relevance,f.presentation,f.methodology
+		FROM feedback AS f, user AS u, record AS r
+		WHERE f.user_id=u.id AND f.record_id=r.id
+		ORDER BY f.rowid DESC LIMIT ? OFFSET ?`
+
+	rows, err := st.db.Query(query, limit, offset)
+	if err != nil {
+		log.Printf("Database error. Could not read feedback of all users. %s", err)
+		return
+	}
+	defer rows.Close()
+
+	feedbacks = admin.Feedbacks{}
+
+	for rows.Next() {
+
+		var f admin.Feedback
+
+		err = rows.Scan(&f.GUID, &f.RecordId, &f.RecordTitle, &f.OrcId, &f.Relevance, &f.Presentation, &f.Methodology)
+		if err != nil {
+			log.Printf("Database error. Scanning feedback failed. %s", err)
+			return
+		}
+
+		feedbacks = append(feedbacks, f)
+	}
+
+	return
+}
+
+// ReadAuditLog returns a segment of the audit log, most recent first.
+func (st *Storage) ReadAuditLog(offset int64, limit int64) (entries admin.AuditEntries, err error) {
+
+	rows, err := st.db.Query("SELECT id,actor,action,target,created FROM audit_log ORDER BY id DESC LIMIT ? OFFSET ?", limit, offset)
+	if err != nil {
+		log.Printf("Database error. Could not read audit log. %s", err)
+		return
+	}
+	defer rows.Close()
+
+	entries = admin.AuditEntries{}
+
+	for rows.Next() {
+
+		var e admin.AuditEntry
+		var created int64
+
+		if err = rows.Scan(&e.Id, &e.Actor, &e.Action, &e.Target, &created); err != nil {
+			log.Printf("Database error. Scanning audit log failed. %s", err)
+			return
+		}
+
+		e.Created = time.Unix(created, 0).UTC().Format(time.RFC3339)
+		entries = append(entries, e)
+	}
+
+	return
+}
+
+// ReadIngestStatus returns the number of records and experts and the most recent runs of the harvester.
+func (st *Storage) ReadIngestStatus() (records int64, experts int64, runs admin.HarvestRuns, err error) {
+
+	const query = `
+		SELECT id,endpoint,started,IFNULL(finished,''),IFNULL(from_date,''),until_date,record_count,deleted_count,IFNULL(error,'')
+		FROM harvest_run ORDER BY id DESC LIMIT ?`
+
+	err = st.db.QueryRow("SELECT (SELECT COUNT(*) FROM record),(SELECT COUNT(*) FROM expert)").Scan(&records, &experts)
+	if err != nil {
+		log.Printf("Database error. Could not count records and experts. %s", err)
+		return
+	}
+
+	rows, err := st.db.Query(query, ingestStatusRunCount)
+	if err != nil {
+		log.Printf("Database error. Could not read harvest runs. %s", err)
+		return
+	}
+	defer rows.Close()
+
+	runs = admin.HarvestRuns{}
+
+	for rows.Next() {
+
+		var r admin.HarvestRun
+
+		err = rows.Scan(&r.Id, &r.Endpoint, &r.Started, &r.Finished, &r.FromDate, &r.UntilDate, &r.RecordCount, &r.DeletedCount, &r.Error)
+		if err != nil {
+			log.Printf("Database error. Scanning harvest runs failed. %s", err)
+			return
+		}
+
+		runs = append(runs, r)
+	}
+
+	return
+}
+
+// ReadStatistics returns aggregated numbers about the users and the content of the database.
+func (st *Storage) ReadStatistics() (stats admin.Statistics, err error) {
+
+	const query = `
+		SELECT
+			(SELECT COUNT(*) FROM user),
+			(SELECT COUNT(*) FROM user WHERE role=?),
+			(SELECT COUNT(*) FROM user WHERE orcid IS NOT NULL AND orcid_verified=1),
+			(SELECT COUNT(*) FROM session WHERE expires>?),
+			(SELECT COUNT(*) FROM record),
+			(SELECT COUNT(*) FROM expert),
+			(SELECT COUNT(DISTINCT expert_id) FROM expert_claim WHERE status=?),
+			(SELECT COUNT(*) FROM expert_claim WHERE status=?),
+			(SELECT COUNT(*) FROM feedback),
+			(SELECT COUNT(*) FROM record_bookmark),
+			(SELECT COUNT(*) FROM expert_bookmark)`
+
+	err = st.db.QueryRow(query, model.RoleAdmin, time.Now().Unix(), ExpertClaimApproved, ExpertClaimPending).Scan(
+		&stats.Users, &stats.Administrators, &stats.VerifiedExperts, &stats.Sessions, &stats.Records, &stats.Experts,
+		&stats.ClaimedExperts, &stats.PendingClaims, &stats.Feedback, &stats.RecordBookmarks, &stats.ExpertBookmarks)
+	if err != nil {
+		log.Printf("Database error. Could not read statistics. %s", err)
+		return
+	}
+
+	return
+}
+
+// ReadUsers returns a segment of all users, ordered by their registration.
+func (st *Storage) ReadUsers(offset int64, limit int64) (users admin.Users, err error) {
+
+	const query = `
+		SELECT u.guid,u.role,IFNULL(u.orcid,''),u.orcid_verified,(SELECT COUNT(*) FROM feedback AS f WHERE f.user_id=u.id)
+		FROM user AS u
+		ORDER BY u.id ASC LIMIT ? OFFSET ?`
+
+	rows, err := st.db.Query(query, limit, offset)
+	if err != nil {
+		log.Printf("Database error. Could not read users. %s", err)
+		return
+	}
+	defer rows.Close()
+
+	users = admin.Users{}
+
+	for rows.Next() {
+
+		var u admin.User
+
+		if err = rows.Scan(&u.GUID, &u.Role, &u.OrcId, &u.OrcIdVerified, &u.FeedbackCount); err != nil {
+			log.Printf("Database error. Scanning users failed. %s", err)
+			return
+		}
+
+		users = append(users, u)
+	}
+
+	return
+}
+
+// UpdateUserRole changes the role of a user (see model.RoleUser and model.RoleAdmin). Returns sql.ErrNoRows if the
+// user does not exist.
+func (st *Storage) UpdateUserRole(guid string, role string) (err error) {
+
+	res, err := st.db.Exec("UPDATE user SET role=? WHERE guid=?", role, guid)
+	if err != nil {
+		log.Printf("Database error. Could not update role of user with GUID '%s'. %s", guid, err)
+		return
+	}
+
+	if count, _ := res.RowsAffected(); count == 0 {
+		return sql.ErrNoRows
+	}
+
+	return
+}
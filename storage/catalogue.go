@@ -0,0 +1,144 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+)
+
+import (
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/model/ploc"
+)
+
+// SearchExpertCatalogue makes a full text search among all experts, including those that are not part of the user's
+// expert feed, and returns a summary for all the experts with matching textual content, ordered by relevance (BM25).
+// The experts are returned as precomputed JSON data structure, that is extended by the flags "bookmarked" and
+// "in_feed". The search term supports the same syntax as for searching the expert feed.
+func (st *Storage) SearchExpertCatalogue(uid int64, searchTerm string, offset int64, limit int64) (rawExperts []json.RawMessage, highlights ploc.SearchHighlights, err error) {
+
+	const query = `
+		SELECT e.json_preview,
+			e.id IN (SELECT expert_id FROM expert_bookmark WHERE user_id=?),
+			e.id IN (SELECT expert_id FROM expert_feed WHERE user_id=?),
+			s.expert_id, s.heading, s.snippet
+		FROM (SELECT expert_id,
+				highlight(vexpert, 1, ` + highlightStart + `, ` + highlightEnd + `) AS heading,
+				snippet(vexpert, -1, ` + highlightStart + `, ` + highlightEnd + `, ` + snippetEllipsis + `, ` + snippetTokens + `) AS snippet,
+				bm25(vexpert, ` + expertSearchWeights + `) AS score
+			FROM vexpert
+			WHERE vexpert MATCH '- {expert_id} : (' || ? || ')'
+			ORDER BY score ASC
+			LIMIT ? OFFSET ?) AS s, expert AS e
+		WHERE e.id=s.expert_id
+		ORDER BY s.score ASC`
+
+	// Translate the user's search query to the FTS5 query syntax.
+
+	ftsQuery, err := parseSearchQuery(searchTerm, expertSearchColumns)
+	if err != nil {
+		log.Printf("Searching expert catalogue failed. %s", err)
+		return
+	}
+
+	rows, err := st.db.Query(query, uid, uid, ftsQuery, limit, offset)
+	if err != nil {
+		log.Printf("Database error. Searching expert catalogue failed. %s", err)
+		return
+	}
+	defer rows.Close()
+
+	highlights = ploc.SearchHighlights{}
+
+	for rows.Next() {
+
+		var rawExpert string
+		var bookmarked, inFeed bool
+		var hl ploc.SearchHighlight
+
+		err = rows.Scan(&rawExpert, &bookmarked, &inFeed, &hl.Id, &hl.Heading, &hl.Snippet)
+		if err != nil {
+			log.Printf("Database error. Scanning raw JSON expert failed. %s", err)
+			return
+		}
+
+		rawExperts = append(rawExperts, json.RawMessage(strings.TrimSuffix(rawExpert, "}")+catalogueFlags(bookmarked, inFeed)))
+		highlights = append(highlights, hl)
+	}
+
+	return
+}
+
+// SearchRecordCatalogue makes a full text search in the whole catalogue of publications, including those that are
+// not part of the user's record feed, and returns a summary for all the publication records with matching textual
+// content, ordered by relevance (BM25). The publications are returned as precomputed JSON data structure, that is
+// extended by the flags "visited", "bookmarked" and "in_feed". The search term supports the same syntax as for
+// searching the record feed.
+func (st *Storage) SearchRecordCatalogue(uid int64, searchTerm string, offset int64, limit int64) (rawRecords []json.RawMessage, highlights ploc.SearchHighlights, err error) {
+
+	const query = `
+		SELECT RTRIM(r.json_preview,'false}'),
+			r.id IN (SELECT record_id FROM record_visit WHERE user_id=?),
+			r.id IN (SELECT record_id FROM record_bookmark WHERE user_id=?),
+			r.id IN (SELECT record_id FROM record_feed WHERE user_id=?),
+			s.record_id, s.heading, s.snippet
+		FROM (SELECT record_id,
+				highlight(vrecord, 1, ` + highlightStart + `, ` + highlightEnd + `) AS heading,
+				snippet(vrecord, -1, ` + highlightStart + `, ` + highlightEnd + `, ` + snippetEllipsis + `, ` + snippetTokens + `) AS snippet,
+				bm25(vrecord, ` + recordSearchWeights + `) AS score
+			FROM vrecord
+			WHERE vrecord MATCH '- {record_id} : (' || ? || ')'
+			ORDER BY score ASC
+			LIMIT ? OFFSET ?) AS s
+		INNER JOIN record AS r ON r.id=s.record_id
+		ORDER BY s.score ASC`
+
+	// Translate the user's search query to the FTS5 query syntax.
+
+	ftsQuery, err := parseSearchQuery(searchTerm, recordSearchColumns)
+	if err != nil {
+		log.Printf("Searching record catalogue failed. %s", err)
+		return
+	}
+
+	rows, err := st.db.Query(query, uid, uid, uid, ftsQuery, limit, offset)
+	if err != nil {
+		log.Printf("Database error. Searching record catalogue failed. %s", err)
+		return
+	}
+	defer rows.Close()
+
+	highlights = ploc.SearchHighlights{}
+
+	for rows.Next() {
+
+		var rawRecord string
+		var visited, bookmarked, inFeed bool
+		var hl ploc.SearchHighlight
+
+		err = rows.Scan(&rawRecord, &visited, &bookmarked, &inFeed, &hl.Id, &hl.Heading, &hl.Snippet)
+		if err != nil {
+			log.Printf("Database error. Scanning raw JSON records failed. %s", err)
+			return
+		}
+
+		var postFix string
+
+		if visited {
+			postFix = "true"
+		} else {
+			postFix = "false"
+		}
+
+		rawRecords = append(rawRecords, json.RawMessage(rawRecord+postFix+catalogueFlags(bookmarked, inFeed)))
+		highlights = append(highlights, hl)
+	}
+
+	return
+}
+
+// catalogueFlags returns the JSON fields "bookmarked" and "in_feed", that complete the precomputed preview of a record
+// or expert in the search results of the catalogue.
+func catalogueFlags(bookmarked bool, inFeed bool) string {
+	return fmt.Sprintf(`,"bookmarked":%t,"in_feed":%t}`, bookmarked, inFeed)
+}
@@ -0,0 +1,316 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+import (
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/model/ploc"
+)
+
+// States of a user's claim, that an expert of the catalogue is the user (see CreateExpertClaim).
+const (
+	ExpertClaimPending  = "pending"
+	ExpertClaimApproved = "approved"
+	ExpertClaimRejected = "rejected"
+)
+
+// ErrExpertClaimed is returned if an expert is already bound to an ORCiD other than the one of the claiming user.
+var ErrExpertClaimed = errors.New("Expert is already bound to another ORCiD.")
+
+// CreateExpertClaim registers the claim of a user, that an expert of the catalogue is the user. The user must have
+// verified the user's ORCiD. The claim is approved immediately, if the expert is already bound to the user's ORCiD or
+// if the name of the ORCiD's owner matches the expert's name. Otherwise it remains pending until an administrator
+// approves or rejects it (see ApproveExpertClaim). Returns sql.ErrNoRows if the expert does not exist or the user has
+// no verified ORCiD, and ErrExpertClaimed if the expert is bound to another ORCiD.
+func (st *Storage) CreateExpertClaim(uid int64, expertId int64) (claim ploc.ExpertClaim, err error) {
+
+	tx, err := st.db.Begin()
+	if err != nil {
+		log.Printf("Database error. Could not initialize transaction for creating expert claim. %s", err)
+		return
+	}
+
+	var orcIdName, expertOrcId sql.NullString
+	var firstName, lastName string
+
+	err = tx.QueryRow("SELECT orcid,orcid_name FROM user WHERE id=? AND orcid IS NOT NULL AND orcid_verified=1", uid).Scan(&claim.OrcId, &orcIdName)
+	if err == nil {
+		err = tx.QueryRow("SELECT first_name,last_name,orcid FROM expert WHERE id=?", expertId).Scan(&firstName, &lastName, &expertOrcId)
+	}
+	if err != nil {
+		tx.Rollback()
+		if err != sql.ErrNoRows {
+			log.Printf("Database error. Could not read user and expert of claim. %s", err)
+		}
+		return
+	}
+
+	if expertOrcId.Valid && expertOrcId.String != claim.OrcId {
+		tx.Rollback()
+		return claim, ErrExpertClaimed
+	}
+
+	claim.ExpertId = expertId
+	claim.ExpertName = strings.TrimSpace(firstName + " " + lastName)
+	claim.OrcIdName = NullToString(orcIdName)
+	claim.Status = ExpertClaimPending
+
+	// Former claims of the user for the same expert are replaced.
+	if _, err = tx.Exec("DELETE FROM expert_claim WHERE user_id=? AND expert_id=?", uid, expertId); err != nil {
+		tx.Rollback()
+		log.Printf("Database error. Could not delete former claims of expert. %s", err)
+		return
+	}
+
+	res, err := tx.Exec("INSERT INTO expert_claim (user_id,expert_id,orcid,status,created) VALUES (?,?,?,?,?)",
+		uid, expertId, claim.OrcId, claim.Status, time.Now().Unix())
+	if err != nil {
+		tx.Rollback()
+		log.Printf("Database error. Could not store expert claim. %s", err)
+		return
+	}
+
+	if claim.Id, err = res.LastInsertId(); err != nil {
+		tx.Rollback()
+		log.Printf("Database error. Could not read ID of expert claim. %s", err)
+		return
+	}
+
+	if expertOrcId.Valid || matchesExpertName(claim.OrcIdName, firstName, lastName) {
+
+		if err = approveExpertClaim(tx, claim.Id, expertId, claim.OrcId); err != nil {
+			tx.Rollback()
+			log.Printf("Database error. Could not approve claim %d. %s", claim.Id, err)
+			return
+		}
+
+		claim.Status = ExpertClaimApproved
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Printf("Database error. Could not commit transaction for creating expert claim. %s", err)
+		return
+	}
+
+	return
+}
+
+// ApproveExpertClaim approves a pending claim, which binds the expert to the ORCiD of the claiming user, and rejects
+// all other pending claims for the same expert. Returns sql.ErrNoRows if the claim is not pending or the user has not
+// verified the claimed ORCiD (anymore), and ErrExpertClaimed if the expert is bound to another ORCiD meanwhile.
+func (st *Storage) ApproveExpertClaim(claimId int64) (err error) {
+
+	const query = `
+		SELECT c.expert_id,c.orcid,e.orcid
+		FROM expert_claim AS c, user AS u, expert AS e
+		WHERE c.id=? AND c.status=? AND c.user_id=u.id AND u.orcid=c.orcid AND u.orcid_verified=1 AND c.expert_id=e.id`
+
+	tx, err := st.db.Begin()
+	if err != nil {
+		log.Printf("Database error. Could not initialize transaction for approving expert claim. %s", err)
+		return
+	}
+
+	var expertId int64
+	var orcId string
+	var expertOrcId sql.NullString
+
+	err = tx.QueryRow(query, claimId, ExpertClaimPending).Scan(&expertId, &orcId, &expertOrcId)
+	if err != nil {
+		tx.Rollback()
+		if err != sql.ErrNoRows {
+			log.Printf("Database error. Could not read expert claim %d. %s", claimId, err)
+		}
+		return
+	}
+
+	if expertOrcId.Valid && expertOrcId.String != orcId {
+		tx.Rollback()
+		return ErrExpertClaimed
+	}
+
+	if err = approveExpertClaim(tx, claimId, expertId, orcId); err != nil {
+		tx.Rollback()
+		log.Printf("Database error. Could not approve claim %d. %s", claimId, err)
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Printf("Database error. Could not commit transaction for approving expert claim. %s", err)
+		return
+	}
+
+	return
+}
+
+// RejectExpertClaim rejects a pending claim. Returns sql.ErrNoRows if the claim is not pending.
+func (st *Storage) RejectExpertClaim(claimId int64) (err error) {
+
+	res, err := st.db.Exec("UPDATE expert_claim SET status=? WHERE id=? AND status=?", ExpertClaimRejected, claimId, ExpertClaimPending)
+	if err != nil {
+		log.Printf("Database error. Could not reject expert claim %d. %s", claimId, err)
+		return
+	}
+
+	if count, _ := res.RowsAffected(); count == 0 {
+		return sql.ErrNoRows
+	}
+
+	return
+}
+
+// ReadExpertClaims returns all claims of a user, most recent first.
+func (st *Storage) ReadExpertClaims(uid int64) (claims ploc.ExpertClaims, err error) {
+	return readExpertClaims(st.db, "c.user_id=? ORDER BY c.id DESC", uid)
+}
+
+// ReadPendingExpertClaims returns all claims, that await the approval of an administrator, oldest first.
+func (st *Storage) ReadPendingExpertClaims() (claims ploc.ExpertClaims, err error) {
+	return readExpertClaims(st.db, "c.status=? ORDER BY c.id ASC", ExpertClaimPending)
+}
+
+// UpdateExpertAffiliation changes the affiliation of an expert on behalf of the expert's owner, i.e. the user whose
+// claim of the expert was approved and who still has verified the expert's ORCiD. An empty affiliation removes it.
+// Returns sql.ErrNoRows if the user does not own the expert.
+func (st *Storage) UpdateExpertAffiliation(uid int64, expertId int64, affiliation string) (err error) {
+
+	const query = `
+		SELECT COUNT(*)
+		FROM expert_claim AS c, user AS u, expert AS e
+		WHERE c.user_id=? AND c.expert_id=? AND c.status=?
+			AND u.id=c.user_id AND u.orcid=c.orcid AND u.orcid_verified=1
+			AND e.id=c.expert_id AND e.orcid=c.orcid`
+
+	tx, err := st.db.Begin()
+	if err != nil {
+		log.Printf("Database error. Could not initialize transaction for updating affiliation. %s", err)
+		return
+	}
+
+	var count int64
+
+	if err = tx.QueryRow(query, uid, expertId, ExpertClaimApproved).Scan(&count); err != nil {
+		tx.Rollback()
+		log.Printf("Database error. Could not check owner of expert %d. %s", expertId, err)
+		return
+	}
+
+	if count == 0 {
+		tx.Rollback()
+		return sql.ErrNoRows
+	}
+
+	if _, err = tx.Exec("UPDATE expert SET affiliation=? WHERE id=?", StringToNull(strings.TrimSpace(affiliation)), expertId); err != nil {
+		tx.Rollback()
+		log.Printf("Database error. Could not update affiliation of expert %d. %s", expertId, err)
+		return
+	}
+
+	if err = precomputeExpertJSON(tx, expertId); err != nil {
+		tx.Rollback()
+		log.Printf("Database error. Could not precompute JSON of expert %d. %s", expertId, err)
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Printf("Database error. Could not commit transaction for updating affiliation. %s", err)
+		return
+	}
+
+	return
+}
+
+// approveExpertClaim binds the expert to the claimed ORCiD, marks the claim as approved and rejects other pending
+// claims for the same expert. The precomputed JSON of the expert is updated.
+func approveExpertClaim(tx *sql.Tx, claimId int64, expertId int64, orcId string) (err error) {
+
+	if _, err = tx.Exec("UPDATE expert SET orcid=? WHERE id=?", orcId, expertId); err != nil {
+		return
+	}
+
+	if _, err = tx.Exec("UPDATE expert_claim SET status=? WHERE id=?", ExpertClaimApproved, claimId); err != nil {
+		return
+	}
+
+	_, err = tx.Exec("UPDATE expert_claim SET status=? WHERE expert_id=? AND status=?", ExpertClaimRejected, expertId, ExpertClaimPending)
+	if err != nil {
+		return
+	}
+
+	return precomputeExpertJSON(tx, expertId)
+}
+
+// readExpertClaims returns the claims that match the condition, which may include an ordering.
+func readExpertClaims(q queryer, condition string, args ...interface{}) (claims ploc.ExpertClaims, err error) {
+
+	query := `
+		SELECT c.id,c.expert_id,e.first_name,e.last_name,c.orcid,IFNULL(u.orcid_name,''),c.status
+		FROM expert_claim AS c, expert AS e, user AS u
+		WHERE c.expert_id=e.id AND c.user_id=u.id AND ` + condition
+
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		log.Printf("Database error. Could not read expert claims. %s", err)
+		return
+	}
+	defer rows.Close()
+
+	claims = ploc.ExpertClaims{}
+
+	for rows.Next() {
+
+		var c ploc.ExpertClaim
+		var firstName, lastName string
+
+		if err = rows.Scan(&c.Id, &c.ExpertId, &firstName, &lastName, &c.OrcId, &c.OrcIdName, &c.Status); err != nil {
+			log.Printf("Database error. Scanning expert claims failed. %s", err)
+			return
+		}
+
+		c.ExpertName = strings.TrimSpace(firstName + " " + lastName)
+		claims = append(claims, c)
+	}
+
+	return
+}
+
+// matchesExpertName returns true if the full name of an ORCiD's owner matches the name of an expert, i.e. if its last
+// words equal the words of the expert's last name and the remaining words equal the expert's first name. Experts
+// without first name or with an abbreviated first name never match, since they are ambiguous.
+func matchesExpertName(fullName string, firstName string, lastName string) bool {
+
+	name, last := nameTokens(fullName), nameTokens(lastName)
+	first := normalizeName(firstName)
+
+	if len(last) == 0 || len(name) <= len(last) || utf8.RuneCountInString(first) <= 1 {
+		return false
+	}
+
+	offset := len(name) - len(last)
+
+	for i, token := range last {
+		if name[offset+i] != token {
+			return false
+		}
+	}
+
+	return strings.Join(name[:offset], "") == first
+}
+
+// nameTokens splits a name into its normalized words (e.g. "Jean-Pierre van der Berg" into "jean", "pierre", "van",
+// "der" and "berg").
+func nameTokens(name string) (tokens []string) {
+
+	for _, word := range strings.FieldsFunc(name, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }) {
+		tokens = append(tokens, normalizeName(word))
+	}
+
+	return
+}
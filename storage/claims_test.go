@@ -0,0 +1,161 @@
+package storage
+
+import (
+	"database/sql"
+	"testing"
+)
+
+import (
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/config"
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/model"
+)
+
+func TestExpertClaims(t *testing.T) {
+
+	st := Open(&config.StorageConfiguration{DBFilename: ":memory:"})
+	defer st.Close()
+
+	recordA, _ := st.UpsertRecord(newTestRecord("A", 2015, model.Creator{FirstName: "John", LastName: "Doe"}))
+	recordB, _ := st.UpsertRecord(newTestRecord("B", 2017, model.Creator{FirstName: "Joan", LastName: "van Roe"}))
+
+	if _, err := st.BuildExperts(); err != nil {
+		t.Errorf("Unexpected error. %s", err)
+		return
+	}
+
+	var johnDoeId, vanRoeId int64
+
+	st.db.QueryRow("SELECT expert_id FROM creator WHERE record_id=?", recordA).Scan(&johnDoeId)
+	st.db.QueryRow("SELECT expert_id FROM creator WHERE record_id=?", recordB).Scan(&vanRoeId)
+
+	userA := model.User{GUID: "claim-test-a", HashedSecret: "-"}
+	userB := model.User{GUID: "claim-test-b", HashedSecret: "-"}
+	st.CreateUser(&userA)
+	st.CreateUser(&userB)
+
+	// Perform test #1: only users with verified ORCiD can claim experts
+
+	st.CreateExpertProfile(userA.Id, "0000-0002-1825-0097")
+
+	if _, err := st.CreateExpertClaim(userA.Id, johnDoeId); err != sql.ErrNoRows {
+		t.Errorf("Expected sql.ErrNoRows for a claim without verified ORCiD but got '%v'.", err)
+		return
+	}
+
+	// Perform test #2: claims of experts with a different name await approval
+
+	st.VerifyOrcId(userA.Id, "0000-0002-1825-0097", "Jonathan Doe")
+
+	claim, err := st.CreateExpertClaim(userA.Id, johnDoeId)
+	if err != nil || claim.Status != ExpertClaimPending {
+		t.Errorf("Expected pending claim but got %+v (error: %v).", claim, err)
+		return
+	}
+
+	if err = st.UpdateExpertAffiliation(userA.Id, johnDoeId, "FZI"); err != sql.ErrNoRows {
+		t.Errorf("Expected sql.ErrNoRows for updating an unclaimed expert but got '%v'.", err)
+		return
+	}
+
+	if pending, _ := st.ReadPendingExpertClaims(); len(pending) != 1 || pending[0].Id != claim.Id || pending[0].ExpertName != "John Doe" {
+		t.Errorf("Expected claim %d of 'John Doe' to be pending but got %+v.", claim.Id, pending)
+		return
+	}
+
+	// Perform test #3: approved claims bind the expert to the ORCiD and permit the owner to edit the affiliation
+
+	if err = st.ApproveExpertClaim(claim.Id); err != nil {
+		t.Errorf("Unexpected error. %s", err)
+		return
+	}
+
+	if err = st.ApproveExpertClaim(claim.Id); err != sql.ErrNoRows {
+		t.Errorf("Expected sql.ErrNoRows for approving a claim twice but got '%v'.", err)
+		return
+	}
+
+	if err = st.UpdateExpertAffiliation(userA.Id, johnDoeId, " FZI "); err != nil {
+		t.Errorf("Unexpected error. %s", err)
+		return
+	}
+
+	details := readTestExpertDetails(t, st, johnDoeId)
+
+	if !details.Claimed || details.OrcId != "0000-0002-1825-0097" || details.Affiliation != "FZI" {
+		t.Errorf("Unexpected expert details %+v.", details)
+		return
+	}
+
+	// Perform test #4: experts, that are bound to another ORCiD, can not be claimed
+
+	st.VerifyOrcId(userB.Id, "0000-0002-1694-233X", "Joan van Roe")
+
+	if _, err = st.CreateExpertClaim(userB.Id, johnDoeId); err != ErrExpertClaimed {
+		t.Errorf("Expected ErrExpertClaimed but got '%v'.", err)
+		return
+	}
+
+	if _, err = st.CreateExpertClaim(userB.Id, 4711); err != sql.ErrNoRows {
+		t.Errorf("Expected sql.ErrNoRows for an unknown expert but got '%v'.", err)
+		return
+	}
+
+	// Perform test #5: claims of experts with a matching name are approved immediately
+
+	if claim, err = st.CreateExpertClaim(userB.Id, vanRoeId); err != nil || claim.Status != ExpertClaimApproved {
+		t.Errorf("Expected approved claim but got %+v (error: %v).", claim, err)
+		return
+	}
+
+	// Perform test #6: rejected claims remain rejected
+
+	st.db.Exec("UPDATE expert_claim SET status=? WHERE id=?", ExpertClaimPending, claim.Id)
+
+	if err = st.RejectExpertClaim(claim.Id); err != nil {
+		t.Errorf("Unexpected error. %s", err)
+		return
+	}
+
+	if err = st.ApproveExpertClaim(claim.Id); err != sql.ErrNoRows {
+		t.Errorf("Expected sql.ErrNoRows for approving a rejected claim but got '%v'.", err)
+		return
+	}
+
+	// Perform test #7: experts of deleted users keep their ORCiD, but are no longer claimed
+
+	st.DeleteUserById(userA.Id)
+
+	details = readTestExpertDetails(t, st, johnDoeId)
+
+	if details.Claimed || details.OrcId != "0000-0002-1825-0097" {
+		t.Errorf("Unexpected expert details %+v.", details)
+		return
+	}
+}
+
+func TestMatchesExpertName(t *testing.T) {
+
+	tests := []struct {
+		fullName, firstName, lastName string
+		match                         bool
+	}{
+		{"John Doe", "John", "Doe", true},
+		{"John Doe", "J.", "Doe", false},
+		{"J. Doe", "John", "Doe", false},
+		{"John Doe", "", "Doe", false},
+		{"Kim Ashlee", "K.", "Lee", false},
+		{"Kim Ashlee", "Kim", "Lee", false},
+		{"Jean-Pierre van der Berg", "Jean-Pierre", "van der Berg", true},
+		{"Jean-Pierre van der Berg", "Jean Pierre", "Berg", false},
+		{"Doe", "John", "Doe", false},
+		{"Jane Doe", "John", "Doe", false},
+		{"John Roe", "John", "Doe", false},
+		{"", "John", "Doe", false},
+	}
+
+	for _, test := range tests {
+		if matchesExpertName(test.fullName, test.firstName, test.lastName) != test.match {
+			t.Errorf("Expected match of '%s' with '%s %s' to be %t.", test.fullName, test.firstName, test.lastName, test.match)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// Maximum number of co-authors between two experts, that are searched for by ReadExpertPath.
+const maxExpertPathLength = 6
+
+// BuildCoauthorshipGraph repopulates the co-authorship graph, which relates experts that are creators of the same
+// records. Must be called after the experts were rebuilt (see BuildExperts).
+func (st *Storage) BuildCoauthorshipGraph() (err error) {
+
+	const query = `
+		INSERT INTO coauthorship (expert_id,coauthor_id,record_count)
+			SELECT a.expert_id, b.expert_id, COUNT(DISTINCT a.record_id)
+			FROM creator AS a, creator AS b
+			WHERE a.record_id=b.record_id
+				AND a.expert_id IS NOT NULL
+				AND b.expert_id IS NOT NULL
+				AND a.expert_id<>b.expert_id
+			GROUP BY a.expert_id, b.expert_id`
+
+	tx, err := st.db.Begin()
+	if err != nil {
+		log.Printf("Database error. Could not initialize transaction for building co-authorship graph. %s", err)
+		return
+	}
+
+	for _, q := range []string{"DELETE FROM coauthorship", query} {
+		if _, err = tx.Exec(q); err != nil {
+			tx.Rollback()
+			log.Printf("Database error. Could not build co-authorship graph. %s", err)
+			return
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Printf("Database error. Could not commit transaction for building co-authorship graph. %s", err)
+		return
+	}
+
+	// The cached graph is reloaded on next use.
+	st.graphMutex.Lock()
+	st.coauthors = nil
+	st.graphMutex.Unlock()
+
+	return
+}
+
+// ReadExpertNetwork returns the co-authors of an expert, in descending order of the number of records they have
+// co-authored with the expert. The co-authors are returned as precomputed JSON data structure, that is extended by
+// the field "record_count". Returns sql.ErrNoRows if the expert does not exist.
+func (st *Storage) ReadExpertNetwork(expertId int64, limit int64) (rawCoauthors []json.RawMessage, err error) {
+
+	const query = `
+		SELECT e.json_preview, c.record_count
+		FROM coauthorship AS c, expert AS e
+		WHERE c.expert_id=? AND e.id=c.coauthor_id AND e.json_preview IS NOT NULL
+		ORDER BY c.record_count DESC, e.id ASC
+		LIMIT ?`
+
+	if err = st.checkExpertExists(expertId); err != nil {
+		return
+	}
+
+	rows, err := st.db.Query(query, expertId, limit)
+	if err != nil {
+		log.Printf("Database error. Querying co-authors failed. %s", err)
+		return
+	}
+	defer rows.Close()
+
+	rawCoauthors = []json.RawMessage{}
+
+	for rows.Next() {
+
+		var rawExpert string
+		var recordCount int64
+
+		if err = rows.Scan(&rawExpert, &recordCount); err != nil {
+			log.Printf("Database error. Scanning co-authors failed. %s", err)
+			return
+		}
+
+		rawCoauthors = append(rawCoauthors, json.RawMessage(strings.TrimSuffix(rawExpert, "}")+fmt.Sprintf(`,"record_count":%d}`, recordCount)))
+	}
+
+	return
+}
+
+// ReadExpertPath returns the shortest chain of co-authors, that connects two experts, starting with the first and
+// ending with the second expert. The chain is empty, if both experts are not connected by at most maxExpertPathLength
+// co-authors. The experts are returned as precomputed JSON data structure. Returns sql.ErrNoRows if one of the experts
+// does not exist.
+func (st *Storage) ReadExpertPath(fromId int64, toId int64) (rawExperts []json.RawMessage, err error) {
+
+	if err = st.checkExpertExists(fromId); err != nil {
+		return
+	}
+
+	if err = st.checkExpertExists(toId); err != nil {
+		return
+	}
+
+	graph, err := st.coauthorGraph()
+	if err != nil {
+		return
+	}
+
+	rawExperts = []json.RawMessage{}
+
+	for _, expertId := range shortestPath(graph, fromId, toId, maxExpertPathLength+1) {
+
+		var rawExpert string
+
+		if err = st.db.QueryRow("SELECT IFNULL(json_preview,'{}') FROM expert WHERE id=?", expertId).Scan(&rawExpert); err != nil {
+			log.Printf("Database error. Could not read expert of path. %s", err)
+			return
+		}
+
+		rawExperts = append(rawExperts, json.RawMessage(rawExpert))
+	}
+
+	return
+}
+
+// checkExpertExists returns sql.ErrNoRows if the specified expert does not exist.
+func (st *Storage) checkExpertExists(expertId int64) (err error) {
+
+	var count int64
+
+	if err = st.db.QueryRow("SELECT COUNT(*) FROM expert WHERE id=?", expertId).Scan(&count); err != nil {
+		log.Printf("Database error. Could not check existence of expert. %s", err)
+		return
+	}
+
+	if count == 0 {
+		return sql.ErrNoRows
+	}
+
+	return
+}
+
+// coauthorGraph returns the co-authorship graph as adjacency lists, ordered by the experts' IDs. The graph is loaded
+// once and cached until it is rebuilt (see BuildCoauthorshipGraph).
+func (st *Storage) coauthorGraph() (graph map[int64][]int64, err error) {
+
+	st.graphMutex.Lock()
+	defer st.graphMutex.Unlock()
+
+	if st.coauthors != nil {
+		return st.coauthors, nil
+	}
+
+	rows, err := st.db.Query("SELECT expert_id, coauthor_id FROM coauthorship ORDER BY expert_id ASC, coauthor_id ASC")
+	if err != nil {
+		log.Printf("Database error. Could not read co-authorship graph. %s", err)
+		return
+	}
+	defer rows.Close()
+
+	graph = make(map[int64][]int64)
+
+	for rows.Next() {
+
+		var expertId, coauthorId int64
+
+		if err = rows.Scan(&expertId, &coauthorId); err != nil {
+			log.Printf("Database error. Scanning co-authorship graph failed. %s", err)
+			return nil, err
+		}
+
+		graph[expertId] = append(graph[expertId], coauthorId)
+	}
+
+	st.coauthors = graph
+
+	return
+}
+
+// shortestPath returns the shortest path between two nodes of a graph by breadth-first search, including both nodes.
+// Returns nil if the nodes are not connected by a path with at most maxEdges edges.
+func shortestPath(graph map[int64][]int64, from int64, to int64, maxEdges int) []int64 {
+
+	predecessors := map[int64]int64{from: from}
+	frontier := []int64{from}
+
+	for depth := 0; depth <= maxEdges && len(frontier) > 0; depth++ {
+
+		var next []int64
+
+		for _, node := range frontier {
+
+			if node == to {
+
+				path := []int64{to}
+				for node != from {
+					node = predecessors[node]
+					path = append([]int64{node}, path...)
+				}
+
+				return path
+			}
+
+			for _, neighbour := range graph[node] {
+				if _, visited := predecessors[neighbour]; !visited {
+					predecessors[neighbour] = node
+					next = append(next, neighbour)
+				}
+			}
+		}
+
+		frontier = next
+	}
+
+	return nil
+}
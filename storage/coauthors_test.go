@@ -0,0 +1,37 @@
+package storage
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestShortestPath(t *testing.T) {
+
+	// 1 - 2 - 3 - 4, 1 - 5 - 4, 6
+	graph := map[int64][]int64{
+		1: {2, 5},
+		2: {1, 3},
+		3: {2, 4},
+		4: {3, 5},
+		5: {1, 4},
+	}
+
+	tests := []struct {
+		from, to int64
+		maxEdges int
+		path     []int64
+	}{
+		{1, 1, 0, []int64{1}},
+		{1, 2, 1, []int64{1, 2}},
+		{1, 4, 2, []int64{1, 5, 4}},
+		{3, 5, 2, []int64{3, 4, 5}},
+		{1, 4, 1, nil},
+		{1, 6, 10, nil},
+	}
+
+	for _, test := range tests {
+		if path := shortestPath(graph, test.from, test.to, test.maxEdges); !reflect.DeepEqual(path, test.path) {
+			t.Errorf("Expected path %v from %d to %d, but got %v.", test.path, test.from, test.to, path)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package storage
+
+import (
+	"log"
+	"math"
+)
+
+// RankingEvaluation summarizes an offline evaluation of the record feed ranking. Precision is the mean precision@k
+// of all evaluated users with the preference model, BaselinePrecision the mean precision@k without it.
+type RankingEvaluation struct {
+	Users             int64
+	K                 int64
+	Precision         float64
+	BaselinePrecision float64
+}
+
+// EvaluateRanking replays the users' interactions to measure how well the record feed ranking predicts the records a
+// user finds relevant, i.e. bookmarks and records rated as relevant. For each user, the last fraction (holdout) of
+// these records is hidden (see readRelevantRecords), the preference model is learned from the remaining interactions,
+// and the precision of the top k records of the ranked feed is measured against the hidden records. Users with less
+// than two relevant records are skipped. The database is not modified.
+func (st *Storage) EvaluateRanking(k int64, holdout float64) (eval RankingEvaluation, err error) {
+
+	eval.K = k
+
+	rows, err := st.db.Query("SELECT id FROM user ORDER BY id ASC")
+	if err != nil {
+		log.Printf("Database error. Could not read users for evaluating the ranking. %s", err)
+		return
+	}
+
+	var uids []int64
+
+	for rows.Next() {
+
+		var uid int64
+
+		if err = rows.Scan(&uid); err != nil {
+			rows.Close()
+			log.Printf("Database error. Scanning user IDs failed. %s", err)
+			return
+		}
+
+		uids = append(uids, uid)
+	}
+	rows.Close()
+
+	for _, uid := range uids {
+
+		relevant, err := readRelevantRecords(st.db, uid)
+		if err != nil {
+			return eval, err
+		}
+
+		if len(relevant) < 2 {
+			continue
+		}
+
+		hiddenCount := int(math.Ceil(holdout * float64(len(relevant))))
+		if hiddenCount < 1 {
+			hiddenCount = 1
+		}
+		if hiddenCount >= len(relevant) {
+			hiddenCount = len(relevant) - 1
+		}
+
+		known := make(map[int64]bool)
+		hidden := make(map[int64]bool)
+
+		for i, recordId := range relevant {
+			if i < len(relevant)-hiddenCount {
+				known[recordId] = true
+			} else {
+				hidden[recordId] = true
+			}
+		}
+
+		// Interactions with hidden records, like visits, would reveal them to the preference model.
+		interactions, err := readInteractions(st.db, uid)
+		if err != nil {
+			return eval, err
+		}
+
+		var training []interaction
+
+		for _, i := range interactions {
+			if !hidden[i.recordId] {
+				training = append(training, i)
+			}
+		}
+
+		prefs, err := learnPreferences(st.db, training)
+		if err != nil {
+			return eval, err
+		}
+
+		precision, err := st.precisionAtK(uid, &prefs, known, hidden, k)
+		if err != nil {
+			return eval, err
+		}
+
+		baselinePrecision, err := st.precisionAtK(uid, &preferenceModel{}, known, hidden, k)
+		if err != nil {
+			return eval, err
+		}
+
+		eval.Users++
+		eval.Precision += precision
+		eval.BaselinePrecision += baselinePrecision
+	}
+
+	if eval.Users > 0 {
+		eval.Precision /= float64(eval.Users)
+		eval.BaselinePrecision /= float64(eval.Users)
+	}
+
+	return
+}
+
+// precisionAtK ranks a user's record feed with the specified preferences and returns the fraction of the top k
+// records, that are hidden relevant records. Known relevant records are excluded from the feed, like bookmarks are.
+func (st *Storage) precisionAtK(uid int64, prefs *preferenceModel, known map[int64]bool, hidden map[int64]bool, k int64) (precision float64, err error) {
+
+	if k <= 0 {
+		return
+	}
+
+	records, err := st.rankRecordFeed(st.db, uid, prefs)
+	if err != nil {
+		return
+	}
+
+	var rank, hits int64
+
+	for _, rec := range records {
+
+		if rank == k {
+			break
+		}
+
+		if known[rec.id] {
+			continue
+		}
+
+		if hidden[rec.id] {
+			hits++
+		}
+
+		rank++
+	}
+
+	return float64(hits) / float64(k), nil
+}
+
+// readRelevantRecords returns the IDs of the records a user has bookmarked or rated as relevant. Bookmarks come first,
+// each group in the order of its creation.
+func readRelevantRecords(q queryer, uid int64) (recordIds []int64, err error) {
+
+	const query = `
+		SELECT record_id FROM
+			(SELECT record_id, MIN(rowid) AS position, 0 AS source FROM record_bookmark WHERE user_id=? GROUP BY record_id
+			UNION ALL
+			SELECT record_id, rowid AS position, 1 AS source FROM feedback WHERE user_id=? AND relevance=1)
+		ORDER BY source ASC, position ASC`
+
+	rows, err := q.Query(query, uid, uid)
+	if err != nil {
+		log.Printf("Database error. Could not read relevant records of user. %s", err)
+		return
+	}
+	defer rows.Close()
+
+	seen := make(map[int64]bool)
+
+	for rows.Next() {
+
+		var recordId int64
+
+		if err = rows.Scan(&recordId); err != nil {
+			log.Printf("Database error. Scanning relevant records of user failed. %s", err)
+			return
+		}
+
+		if !seen[recordId] {
+			seen[recordId] = true
+			recordIds = append(recordIds, recordId)
+		}
+	}
+
+	return
+}
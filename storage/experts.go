@@ -0,0 +1,473 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// expertCandidate defines a creator of a record, as considered for clustering creators into experts.
+type expertCandidate struct {
+	creatorId int64
+	recordId  int64
+	firstName string
+	lastName  string
+	first     string // normalized first name
+	block     string // normalized last name and initial of the first name
+	expertId  int64  // currently related expert or 0
+	orcId     string // ORCiD of the currently related expert (optional)
+	parent    int    // union-find parent within the list of candidates
+	coAuthors map[string]bool
+}
+
+// expertCluster defines a group of creators that are considered to be the same person.
+type expertCluster struct {
+	members  []*expertCandidate
+	expertId int64
+	orcId    string
+}
+
+// Queries that remove an expert, which is given as first parameter, and all data depending on it.
+var expertDependencyQueries = []string{
+	"DELETE FROM expert_bookmark WHERE expert_id=?1",
+	"DELETE FROM expert_feed WHERE expert_id=?1",
+	"DELETE FROM expert_claim WHERE expert_id=?1",
+	"DELETE FROM expert_subject_link WHERE expert_id=?1",
+	"DELETE FROM coauthorship WHERE expert_id=?1 OR coauthor_id=?1",
+	"DELETE FROM expert WHERE id=?1",
+}
+
+// BuildExperts clusters the creators of all records into experts and relates each creator to its expert.
+// Creators are only clustered if their normalized last names and first initials match. Within such a block,
+// creators are clustered if they share the same full first name or at least one co-author. Creators related to an
+// expert with an ORCiD keep that relation, and creators of experts with different ORCiDs are never clustered.
+// Afterwards the experts' statistics, subject links and precomputed JSON are recomputed. Experts without creators
+// and without an ORCiD are removed. Returns the number of experts.
+func (st *Storage) BuildExperts() (expertCount int64, err error) {
+
+	tx, err := st.db.Begin()
+	if err != nil {
+		log.Printf("Database error. Could not initialize transaction for building experts. %s", err)
+		return
+	}
+
+	expertCount, err = buildExperts(tx)
+	if err != nil {
+		tx.Rollback()
+		log.Printf("Database error. Could not build experts. %s", err)
+		return 0, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		log.Printf("Database error. Could not commit transaction for building experts. %s", err)
+		return 0, err
+	}
+
+	return
+}
+
+// buildExperts implements BuildExperts within the specified transaction.
+func buildExperts(tx *sql.Tx) (expertCount int64, err error) {
+
+	// 1. Cluster creators into experts.
+
+	candidates, err := readExpertCandidates(tx)
+	if err != nil {
+		return
+	}
+
+	clusters := clusterExpertCandidates(candidates)
+
+	// 2. Relate each cluster to an existing expert or create a new one.
+
+	if err = assignExperts(tx, clusters); err != nil {
+		return
+	}
+
+	// 3. Remove experts that are neither related to creators nor have an ORCiD.
+
+	const orphanQuery = `
+		SELECT id FROM expert
+		WHERE orcid IS NULL AND id NOT IN (SELECT expert_id FROM creator WHERE expert_id IS NOT NULL)`
+
+	orphans, err := readIds(tx, orphanQuery)
+	if err != nil {
+		return 0, fmt.Errorf("Could not read orphaned experts. %s", err)
+	}
+
+	for _, expertId := range orphans {
+		for _, query := range expertDependencyQueries {
+			if _, err = tx.Exec(query, expertId); err != nil {
+				return 0, fmt.Errorf("Could not delete orphaned expert %d ('%s'). %s", expertId, query, err)
+			}
+		}
+	}
+
+	// 4. Recompute statistics and subject links.
+
+	const statisticsQuery = `
+		UPDATE expert SET
+			last_publication_year=(SELECT MAX(r.year) FROM creator AS c, record AS r WHERE c.expert_id=expert.id AND c.record_id=r.id),
+			total_publication_count=(SELECT COUNT(DISTINCT c.record_id) FROM creator AS c WHERE c.expert_id=expert.id)`
+
+	if _, err = tx.Exec(statisticsQuery); err != nil {
+		return 0, fmt.Errorf("Could not update expert statistics. %s", err)
+	}
+
+	const subjectLinkQuery = `
+		INSERT INTO expert_subject_link (expert_id,subject_id,record_count)
+			SELECT c.expert_id, rsl.subject_id, COUNT(DISTINCT c.record_id)
+			FROM creator AS c, record_subject_link AS rsl
+			WHERE c.expert_id IS NOT NULL AND c.record_id=rsl.record_id
+			GROUP BY c.expert_id, rsl.subject_id`
+
+	if _, err = tx.Exec("DELETE FROM expert_subject_link"); err != nil {
+		return 0, fmt.Errorf("Could not delete expert subject links. %s", err)
+	}
+
+	if _, err = tx.Exec(subjectLinkQuery); err != nil {
+		return 0, fmt.Errorf("Could not insert expert subject links. %s", err)
+	}
+
+	// 5. Precompute the JSON representations of all experts.
+
+	expertIds, err := readIds(tx, "SELECT id FROM expert ORDER BY id ASC")
+	if err != nil {
+		return 0, fmt.Errorf("Could not read expert IDs. %s", err)
+	}
+
+	for _, expertId := range expertIds {
+		if err = precomputeExpertJSON(tx, expertId); err != nil {
+			return 0, fmt.Errorf("Could not precompute JSON of expert %d. %s", expertId, err)
+		}
+	}
+
+	return int64(len(expertIds)), nil
+}
+
+// readExpertCandidates reads all creators together with their currently related experts and co-authors.
+func readExpertCandidates(tx *sql.Tx) (candidates []*expertCandidate, err error) {
+
+	const query = `
+		SELECT c.id, c.record_id, c.first_name, c.last_name, IFNULL(e.id,0), IFNULL(e.orcid,'')
+		FROM creator AS c LEFT JOIN expert AS e ON c.expert_id=e.id
+		ORDER BY c.id ASC`
+
+	rows, err := tx.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("Could not read creators. %s", err)
+	}
+	defer rows.Close()
+
+	byRecord := make(map[int64][]*expertCandidate)
+
+	for rows.Next() {
+
+		c := &expertCandidate{parent: len(candidates)}
+
+		err = rows.Scan(&c.creatorId, &c.recordId, &c.firstName, &c.lastName, &c.expertId, &c.orcId)
+		if err != nil {
+			return nil, fmt.Errorf("Could not scan creator. %s", err)
+		}
+
+		c.first = normalizeName(c.firstName)
+		c.block = normalizeName(c.lastName)
+
+		if r, _ := utf8.DecodeRuneInString(c.first); r != utf8.RuneError {
+			c.block += " " + string(r)
+		}
+
+		candidates = append(candidates, c)
+		byRecord[c.recordId] = append(byRecord[c.recordId], c)
+	}
+
+	for _, c := range candidates {
+
+		c.coAuthors = make(map[string]bool)
+
+		for _, co := range byRecord[c.recordId] {
+			if co.block != c.block {
+				c.coAuthors[co.block] = true
+			}
+		}
+	}
+
+	return candidates, nil
+}
+
+// clusterExpertCandidates groups the candidates into clusters of creators that are considered to be the same person.
+// The candidates are expected to be ordered by their creator ID, which makes the clustering deterministic.
+func clusterExpertCandidates(candidates []*expertCandidate) (clusters []*expertCluster) {
+
+	var find func(i int) int
+	find = func(i int) int {
+		if candidates[i].parent != i {
+			candidates[i].parent = find(candidates[i].parent)
+		}
+		return candidates[i].parent
+	}
+
+	// ORCiDs, co-authors and full (i.e. not abbreviated) first names of each cluster, indexed by the cluster's root
+	orcIds := make(map[int]string)
+	coAuthors := make(map[int]map[string]bool)
+	firstNames := make(map[int]map[string]bool)
+
+	// Clusters are compatible if all their full first names are compatible, so that an abbreviated first name can not
+	// bridge different full first names (e.g. "J. Smith" between "John Smith" and "Jane Smith").
+	compatible := func(ri int, rj int) bool {
+		for a := range firstNames[ri] {
+			for b := range firstNames[rj] {
+				if !compatibleFirstNames(a, b) {
+					return false
+				}
+			}
+		}
+		return true
+	}
+
+	union := func(i int, j int) {
+
+		ri, rj := find(i), find(j)
+
+		if ri == rj || (orcIds[ri] != "" && orcIds[rj] != "" && orcIds[ri] != orcIds[rj]) {
+			return
+		}
+
+		candidates[rj].parent = ri
+
+		if orcIds[ri] == "" {
+			orcIds[ri] = orcIds[rj]
+		}
+
+		for co := range coAuthors[rj] {
+			coAuthors[ri][co] = true
+		}
+
+		for name := range firstNames[rj] {
+			firstNames[ri][name] = true
+		}
+	}
+
+	blocks := make(map[string][]int)
+	var blockKeys []string
+
+	for i, c := range candidates {
+
+		orcIds[i] = c.orcId
+		coAuthors[i] = make(map[string]bool)
+		for co := range c.coAuthors {
+			coAuthors[i][co] = true
+		}
+
+		firstNames[i] = make(map[string]bool)
+		if utf8.RuneCountInString(c.first) > 1 {
+			firstNames[i][c.first] = true
+		}
+
+		if _, ok := blocks[c.block]; !ok {
+			blockKeys = append(blockKeys, c.block)
+		}
+		blocks[c.block] = append(blocks[c.block], i)
+	}
+
+	for _, key := range blockKeys {
+
+		block := blocks[key]
+
+		// Creators related to the same expert with an ORCiD are the same person.
+		for x, i := range block {
+			for _, j := range block[x+1:] {
+				if candidates[i].orcId != "" && candidates[i].expertId == candidates[j].expertId {
+					union(i, j)
+				}
+			}
+		}
+
+		// Creators with the same full first name or with compatible first names and a common co-author are the same
+		// person, if the first names of their clusters are compatible as well. Repeat until stable, since merging
+		// clusters extends their lists of co-authors.
+		for merged := true; merged; {
+
+			merged = false
+
+			for x, i := range block {
+				for _, j := range block[x+1:] {
+
+					ri, rj := find(i), find(j)
+					if ri == rj || !compatibleFirstNames(candidates[i].first, candidates[j].first) || !compatible(ri, rj) {
+						continue
+					}
+
+					sameName := candidates[i].first == candidates[j].first && utf8.RuneCountInString(candidates[i].first) > 1
+
+					if sameName || sharesKey(coAuthors[ri], coAuthors[rj]) {
+						union(i, j)
+						merged = merged || find(i) == find(j)
+					}
+				}
+			}
+		}
+	}
+
+	// Collect clusters in the order of their first creator.
+
+	byRoot := make(map[int]*expertCluster)
+
+	for i, c := range candidates {
+
+		root := find(i)
+
+		cluster, ok := byRoot[root]
+		if !ok {
+			cluster = &expertCluster{orcId: orcIds[root]}
+			byRoot[root] = cluster
+			clusters = append(clusters, cluster)
+		}
+
+		cluster.members = append(cluster.members, c)
+	}
+
+	return clusters
+}
+
+// assignExperts relates each cluster to an expert. A cluster keeps the expert with its ORCiD or else the expert that
+// is related to most of its creators, if that expert was not claimed by a preceding cluster already. Otherwise a new
+// expert is created. Names of experts without ORCiD are updated to the most complete name variant of their creators.
+func assignExperts(tx *sql.Tx, clusters []*expertCluster) (err error) {
+
+	// Larger clusters and clusters with ORCiD take precedence when claiming existing experts.
+	ordered := make([]*expertCluster, len(clusters))
+	copy(ordered, clusters)
+
+	sort.SliceStable(ordered, func(a int, b int) bool {
+		if (ordered[a].orcId != "") != (ordered[b].orcId != "") {
+			return ordered[a].orcId != ""
+		}
+		return len(ordered[a].members) > len(ordered[b].members)
+	})
+
+	claimed := make(map[int64]bool)
+
+	for _, cluster := range ordered {
+
+		votes := make(map[int64]int)
+
+		for _, c := range cluster.members {
+			if c.expertId != 0 && !claimed[c.expertId] && (cluster.orcId == "" || c.orcId == cluster.orcId) {
+				votes[c.expertId]++
+			}
+		}
+
+		for expertId, n := range votes {
+			if n > votes[cluster.expertId] || (n == votes[cluster.expertId] && expertId < cluster.expertId) {
+				cluster.expertId = expertId
+			}
+		}
+
+		if cluster.expertId != 0 {
+			claimed[cluster.expertId] = true
+		}
+	}
+
+	for _, cluster := range clusters {
+
+		firstName, lastName := preferredName(cluster.members)
+
+		if cluster.expertId == 0 {
+
+			result, err := tx.Exec("INSERT INTO expert (first_name,last_name) VALUES(?,?)", firstName, lastName)
+			if err != nil {
+				return fmt.Errorf("Could not insert expert. %s", err)
+			}
+
+			cluster.expertId, err = result.LastInsertId()
+			if err != nil {
+				return fmt.Errorf("Could not get ID for inserted expert. %s", err)
+			}
+		} else {
+
+			_, err = tx.Exec("UPDATE expert SET first_name=?, last_name=? WHERE id=? AND orcid IS NULL", firstName, lastName, cluster.expertId)
+			if err != nil {
+				return fmt.Errorf("Could not update name of expert %d. %s", cluster.expertId, err)
+			}
+		}
+
+		for _, c := range cluster.members {
+
+			if c.expertId == cluster.expertId {
+				continue
+			}
+
+			_, err = tx.Exec("UPDATE creator SET expert_id=? WHERE id=?", cluster.expertId, c.creatorId)
+			if err != nil {
+				return fmt.Errorf("Could not relate creator %d to expert. %s", c.creatorId, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// compatibleFirstNames returns true if two normalized first names may belong to the same person, i.e. if they are
+// equal or one of them is an initial of the other.
+func compatibleFirstNames(a string, b string) bool {
+
+	if a == b || a == "" || b == "" {
+		return true
+	}
+
+	if utf8.RuneCountInString(a) == 1 {
+		return strings.HasPrefix(b, a)
+	}
+
+	if utf8.RuneCountInString(b) == 1 {
+		return strings.HasPrefix(a, b)
+	}
+
+	return false
+}
+
+// normalizeName converts a name to lower case and removes all characters that are neither letters nor digits,
+// so that different spellings like "J.-P." and "JP" match.
+func normalizeName(name string) string {
+
+	var b strings.Builder
+
+	for _, r := range name {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(unicode.ToLower(r))
+		}
+	}
+
+	return b.String()
+}
+
+// preferredName returns the most complete name variant within a cluster of creators, i.e. the longest first name and
+// its last name. Ties are broken by the order of the creators.
+func preferredName(members []*expertCandidate) (firstName string, lastName string) {
+
+	for _, c := range members {
+		if lastName == "" || utf8.RuneCountInString(c.firstName) > utf8.RuneCountInString(firstName) {
+			firstName, lastName = c.firstName, c.lastName
+		}
+	}
+
+	return
+}
+
+// sharesKey returns true if both sets have at least one common key.
+func sharesKey(a map[string]bool, b map[string]bool) bool {
+
+	for key := range a {
+		if b[key] {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,204 @@
+package storage
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+import (
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/config"
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/model"
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/model/ploc"
+)
+
+func newTestRecord(sourceId string, year int64, creators ...model.Creator) *model.Record {
+	return &model.Record{
+		SourceId: sourceId,
+		Title:    "Record " + sourceId,
+		Type:     model.RecordTypePaper,
+		Year:     year,
+		Creators: creators,
+		Subjects: []string{"Banks", sourceId},
+	}
+}
+
+func readTestExpertDetails(t *testing.T, st *Storage, expertId int64) (details ploc.ReadExpertDetailsResponse) {
+
+	rawDetails, err := st.ReadExpertDetails(expertId)
+	if err != nil {
+		t.Errorf("Could not read expert details. %s", err)
+		return
+	}
+
+	if err = json.Unmarshal(rawDetails, &details); err != nil {
+		t.Errorf("Could not unmarshal expert details '%s'. %s", string(rawDetails), err)
+	}
+
+	return
+}
+
+func TestBuildExperts(t *testing.T) {
+
+	st := Open(&config.StorageConfiguration{DBFilename: ":memory:"})
+	defer st.Close()
+
+	johnDoe := model.Creator{FirstName: "John", LastName: "Doe"}
+	jDoe := model.Creator{FirstName: "J.", LastName: "Doe"}
+	janeRoe := model.Creator{FirstName: "Jane", LastName: "Roe"}
+	maxMuster := model.Creator{FirstName: "Max", LastName: "Muster"}
+
+	recordA, _ := st.UpsertRecord(newTestRecord("A", 2015, johnDoe, janeRoe))
+	st.UpsertRecord(newTestRecord("B", 2017, jDoe, janeRoe))
+	recordC, _ := st.UpsertRecord(newTestRecord("C", 2019, jDoe, maxMuster))
+	st.UpsertRecord(newTestRecord("D", 2016, model.Creator{FirstName: "John", LastName: "DOE"}))
+
+	// Perform test #1: same full names and common co-authors are clustered
+
+	count, err := st.BuildExperts()
+	if err != nil {
+		t.Errorf("Unexpected error. %s", err)
+		return
+	}
+
+	if count != 4 {
+		t.Errorf("Expected %d experts but got %d.", 4, count)
+		return
+	}
+
+	var johnDoeId, jDoeId int64
+
+	st.db.QueryRow("SELECT expert_id FROM creator WHERE record_id=? AND last_name='Doe'", recordA).Scan(&johnDoeId)
+	st.db.QueryRow("SELECT expert_id FROM creator WHERE record_id=? AND last_name='Doe'", recordC).Scan(&jDoeId)
+
+	details := readTestExpertDetails(t, st, johnDoeId)
+
+	if details.Id != johnDoeId || details.Name != "John Doe" || details.TotalPublicationCount != 3 || details.LastPublicationYear != 2017 {
+		t.Errorf("Unexpected expert details %+v.", details)
+		return
+	}
+
+	if len(details.Records) != 3 || details.Records[0].Year != 2017 || details.Records[0].Creators[1] != "J. Roe" {
+		t.Errorf("Unexpected publications of expert %+v.", details.Records)
+		return
+	}
+
+	if len(details.Subjects) != 4 || details.Subjects[0] != "Banks" {
+		t.Errorf("Unexpected subjects of expert %v.", details.Subjects)
+		return
+	}
+
+	if jDoeId == johnDoeId {
+		t.Errorf("Expected 'J. Doe' without common co-author to be a different expert.")
+		return
+	}
+
+	// Perform test #2: rebuilding keeps expert IDs and the relation to experts with ORCiD
+
+	st.db.Exec("UPDATE expert SET orcid='0000-0002-1825-0097', first_name='Jonathan' WHERE id=?", jDoeId)
+	st.UpsertRecord(newTestRecord("E", 2020, model.Creator{FirstName: "Jonathan", LastName: "Doe"}))
+
+	count, err = st.BuildExperts()
+	if err != nil {
+		t.Errorf("Unexpected error. %s", err)
+		return
+	}
+
+	if count != 5 {
+		t.Errorf("Expected %d experts but got %d.", 5, count)
+		return
+	}
+
+	details = readTestExpertDetails(t, st, jDoeId)
+
+	if details.Name != "Jonathan Doe" || details.OrcId != "0000-0002-1825-0097" || details.TotalPublicationCount != 1 {
+		t.Errorf("Unexpected expert details %+v.", details)
+		return
+	}
+
+	if readTestExpertDetails(t, st, johnDoeId).TotalPublicationCount != 3 {
+		t.Errorf("Expected expert %d to keep its publications.", johnDoeId)
+		return
+	}
+
+	// Perform test #3: rebuilding the experts of the test publications keeps the existing experts
+
+	st = Open(&config.StorageConfiguration{DBFilename: ":memory:"})
+	defer st.Close()
+
+	st.CreateTestPublications()
+
+	count, err = st.BuildExperts()
+	if err != nil {
+		t.Errorf("Unexpected error. %s", err)
+		return
+	}
+
+	var maxExpertId int64
+
+	st.db.QueryRow("SELECT MAX(id) FROM expert").Scan(&maxExpertId)
+
+	if count != 279 || maxExpertId != 54831 {
+		t.Errorf("Expected %d experts with unchanged IDs but got %d experts with maximum ID %d.", 279, count, maxExpertId)
+		return
+	}
+
+	// Perform test #4: an abbreviated first name does not bridge different full first names
+
+	st = Open(&config.StorageConfiguration{DBFilename: ":memory:"})
+	defer st.Close()
+
+	maxMuster = model.Creator{FirstName: "Max", LastName: "Muster"}
+	erikaMann := model.Creator{FirstName: "Erika", LastName: "Mann"}
+
+	recordA, _ = st.UpsertRecord(newTestRecord("A", 2015, model.Creator{FirstName: "John", LastName: "Smith"}, maxMuster))
+	recordB, _ := st.UpsertRecord(newTestRecord("B", 2016, model.Creator{FirstName: "J.", LastName: "Smith"}, maxMuster, erikaMann))
+	recordC, _ = st.UpsertRecord(newTestRecord("C", 2017, model.Creator{FirstName: "Jane", LastName: "Smith"}, erikaMann))
+
+	if _, err = st.BuildExperts(); err != nil {
+		t.Errorf("Unexpected error. %s", err)
+		return
+	}
+
+	var johnSmithId, jSmithId, janeSmithId int64
+
+	st.db.QueryRow("SELECT expert_id FROM creator WHERE record_id=? AND last_name='Smith'", recordA).Scan(&johnSmithId)
+	st.db.QueryRow("SELECT expert_id FROM creator WHERE record_id=? AND last_name='Smith'", recordB).Scan(&jSmithId)
+	st.db.QueryRow("SELECT expert_id FROM creator WHERE record_id=? AND last_name='Smith'", recordC).Scan(&janeSmithId)
+
+	if johnSmithId == janeSmithId {
+		t.Errorf("Expected 'John Smith' and 'Jane Smith' to be different experts.")
+		return
+	}
+
+	if jSmithId != johnSmithId {
+		t.Errorf("Expected 'J. Smith' to be the same expert as 'John Smith' with the first common co-author.")
+		return
+	}
+
+	// Perform test #5: orphaned experts are removed with their claims and co-authorships
+
+	st.db.Exec("INSERT INTO expert_claim (user_id,expert_id,orcid,status,created) VALUES (1,?,'0000-0002-1825-0097',?,0)", janeSmithId, ExpertClaimPending)
+	st.db.Exec("INSERT INTO coauthorship (expert_id,coauthor_id,record_count) VALUES (?,?,1),(?,?,1)", janeSmithId, johnSmithId, johnSmithId, janeSmithId)
+
+	if err = st.DeleteRecordBySourceId("C"); err != nil {
+		t.Errorf("Unexpected error. %s", err)
+		return
+	}
+
+	if _, err = st.BuildExperts(); err != nil {
+		t.Errorf("Unexpected error. %s", err)
+		return
+	}
+
+	for _, query := range []string{
+		"SELECT COUNT(*) FROM expert WHERE id=?1",
+		"SELECT COUNT(*) FROM expert_claim WHERE expert_id=?1",
+		"SELECT COUNT(*) FROM coauthorship WHERE expert_id=?1 OR coauthor_id=?1",
+	} {
+		var count int64
+		if st.db.QueryRow(query, janeSmithId).Scan(&count); count != 0 {
+			t.Errorf("Expected no rows for '%s' but got %d.", query, count)
+			return
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package storage
+
+import (
+	"log"
+	"strconv"
+	"strings"
+)
+
+import (
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/model/ploc"
+)
+
+// Maximum number of subjects that are counted as facet of a record feed.
+const subjectFacetLimit = 20
+
+// ReadRecordFeedFacets counts the records of a user's record feed, that match the filter, by year, type, open access
+// state and subject. If a search term is given, only the records that match the search term are counted. Like the
+// record feed itself, the counts exclude bookmarked records. Labels are set for subjects only.
+func (st *Storage) ReadRecordFeedFacets(uid int64, searchTerm string, filter *ploc.RecordFeedFilter) (facets ploc.RecordFeedFacets, err error) {
+
+	// Determine the set of matching records
+
+	condition, args := recordFilterCondition(filter)
+
+	matchingRecords := `
+		SELECT r.id
+		FROM record AS r, record_feed AS f
+		WHERE f.user_id=?
+			AND f.record_id=r.id
+			AND r.id NOT IN (SELECT record_id FROM record_bookmark WHERE user_id=?)` + condition
+
+	args = append([]interface{}{uid, uid}, args...)
+
+	if searchTerm != "" {
+
+		ftsQuery, err := parseSearchQuery(searchTerm, recordSearchColumns)
+		if err != nil {
+			log.Printf("Counting record feed facets failed. %s", err)
+			return facets, err
+		}
+
+		matchingRecords += ` AND r.id IN (SELECT record_id FROM vrecord WHERE vrecord MATCH '- {record_id} : (' || ? || ')')`
+		args = append(args, ftsQuery)
+	}
+
+	// Count records per facet value
+
+	queries := []struct {
+		counts *ploc.FacetCounts
+		query  string
+	}{
+		{&facets.Years, `SELECT year,'',COUNT(*) FROM record WHERE id IN (` + matchingRecords + `) GROUP BY year ORDER BY year DESC`},
+		{&facets.Types, `SELECT type,'',COUNT(*) FROM record WHERE id IN (` + matchingRecords + `) GROUP BY type ORDER BY type ASC`},
+		{&facets.OpenAccess, `SELECT IFNULL(oa,0) AS o,'',COUNT(*) FROM record WHERE id IN (` + matchingRecords + `) GROUP BY o ORDER BY o DESC`},
+		{&facets.Subjects, `
+			SELECT s.id,s.keyword,COUNT(*) AS c
+			FROM record_subject_link AS rsl, subject AS s
+			WHERE rsl.record_id IN (` + matchingRecords + `) AND rsl.subject_id=s.id
+			GROUP BY s.id
+			ORDER BY c DESC, s.keyword ASC
+			LIMIT ` + strconv.Itoa(subjectFacetLimit)},
+	}
+
+	for _, q := range queries {
+
+		*q.counts = ploc.FacetCounts{}
+
+		rows, err := st.db.Query(q.query, args...)
+		if err != nil {
+			log.Printf("Database error. Could not count record feed facets. %s", err)
+			return facets, err
+		}
+
+		for rows.Next() {
+
+			var fc ploc.FacetCount
+
+			if err = rows.Scan(&fc.Value, &fc.Label, &fc.Count); err != nil {
+				rows.Close()
+				log.Printf("Database error. Scanning record feed facets failed. %s", err)
+				return facets, err
+			}
+
+			*q.counts = append(*q.counts, fc)
+		}
+		rows.Close()
+	}
+
+	for _, fc := range facets.Types {
+		facets.RecordCount += fc.Count
+	}
+
+	return facets, nil
+}
+
+// recordFilterCondition translates a record feed filter to an SQL condition on the record table with alias "r".
+// The condition starts with " AND", so that it can be appended to a WHERE clause. Returns an empty condition, if the
+// filter is nil or no filter is enabled.
+func recordFilterCondition(filter *ploc.RecordFeedFilter) (condition string, args []interface{}) {
+
+	if filter == nil {
+		return "", nil
+	}
+
+	var b strings.Builder
+
+	if filter.YearFrom != 0 {
+		b.WriteString(" AND r.year>=?")
+		args = append(args, filter.YearFrom)
+	}
+
+	if filter.YearTo != 0 {
+		b.WriteString(" AND r.year<=?")
+		args = append(args, filter.YearTo)
+	}
+
+	if len(filter.Types) > 0 {
+		b.WriteString(" AND r.type IN (" + placeholders(len(filter.Types)) + ")")
+		for _, t := range filter.Types {
+			args = append(args, t)
+		}
+	}
+
+	if filter.OpenAccessOnly {
+		b.WriteString(" AND r.oa=1")
+	}
+
+	for _, subjectId := range filter.IncludeSubjectIds {
+		b.WriteString(" AND r.id IN (SELECT record_id FROM record_subject_link WHERE subject_id=?)")
+		args = append(args, subjectId)
+	}
+
+	if len(filter.ExcludeSubjectIds) > 0 {
+		b.WriteString(" AND r.id NOT IN (SELECT record_id FROM record_subject_link WHERE subject_id IN (" + placeholders(len(filter.ExcludeSubjectIds)) + "))")
+		for _, subjectId := range filter.ExcludeSubjectIds {
+			args = append(args, subjectId)
+		}
+	}
+
+	return b.String(), args
+}
+
+// placeholders returns a comma-separated list of n SQL parameter placeholders (e.g. "?,?,?").
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?,", n), ",")
+}
@@ -0,0 +1,338 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Columns of the full text search indices that can be used as filters in search queries (e.g. "title:crisis"),
+// mapped to the columns of the virtual FTS5 tables.
+var (
+	recordSearchColumns = map[string]string{"title": "title", "abstract": "abstract", "subjects": "subjects", "authors": "authors"}
+	expertSearchColumns = map[string]string{"name": "full_name", "titles": "titles", "subjects": "subjects"}
+)
+
+// Weights of the columns of the full text search indices for ranking search results by BM25. The first column (ID) is
+// not searchable and therefore weighted with zero. Matches in titles and names are considered as most relevant.
+const (
+	recordSearchWeights = "0.0, 10.0, 1.0, 5.0, 3.0" // record_id, title, abstract, subjects, authors
+	expertSearchWeights = "0.0, 10.0, 3.0, 5.0"      // expert_id, full_name, titles, subjects
+)
+
+// Markers and length (in tokens) of the highlighted text fragments of search results.
+const (
+	highlightStart  = "'<b>'"
+	highlightEnd    = "'</b>'"
+	snippetEllipsis = "'…'"
+	snippetTokens   = "16"
+)
+
+// SearchQueryError is returned by the search functions, if a user's search query is malformed.
+// The position refers to the character (not byte) in the query at which the error was detected.
+type SearchQueryError struct {
+	Position int
+	Message  string
+}
+
+func (e *SearchQueryError) Error() string {
+	return fmt.Sprintf("Malformed search query at position %d. %s", e.Position, e.Message)
+}
+
+// Kinds of tokens of a search query.
+const (
+	tokenWord = iota
+	tokenPhrase
+	tokenOpen
+	tokenClose
+	tokenColon
+	tokenStar
+	tokenEnd
+)
+
+type searchToken struct {
+	kind int
+	text string
+	pos  int
+}
+
+// searchQueryParser translates the search queries of users to the query syntax of SQLite's FTS5 extension.
+// Search queries support the following syntax, which is a safe subset of the FTS5 syntax:
+//
+//	crisis banks        records that contain both terms (implicit AND)
+//	crisis AND banks    the same with explicit operator
+//	crisis OR banks     records that contain any of the terms
+//	crisis NOT banks    records that contain the first, but not the second term
+//	bank*               terms with a prefix
+//	"housing market"    phrases
+//	title:crisis        terms or phrases within a specific column
+//	(a OR b) AND c      grouping
+//
+// Operators must be written in upper case. Each term and phrase is quoted in the resulting FTS5 query, so that special
+// characters of the FTS5 syntax can not be injected.
+type searchQueryParser struct {
+	tokens  []searchToken
+	pos     int
+	columns map[string]string
+}
+
+// parseSearchQuery validates a user's search query and translates it to an FTS5 query. Column filters are restricted to
+// the specified columns. Returns a *SearchQueryError if the query is malformed.
+func parseSearchQuery(query string, columns map[string]string) (string, error) {
+
+	tokens, err := tokenizeSearchQuery(query)
+	if err != nil {
+		return "", err
+	}
+
+	if len(tokens) == 1 {
+		return "", &SearchQueryError{Position: 0, Message: "Search query is empty."}
+	}
+
+	p := searchQueryParser{tokens: tokens, columns: columns}
+
+	fts, err := p.parseOr()
+	if err != nil {
+		return "", err
+	}
+
+	switch t := p.peek(); t.kind {
+	case tokenEnd:
+		return fts, nil
+	case tokenClose:
+		return "", &SearchQueryError{Position: t.pos, Message: "Closing parenthesis without matching opening parenthesis."}
+	default:
+		return "", &SearchQueryError{Position: t.pos, Message: fmt.Sprintf("Unexpected '%s'.", t.text)}
+	}
+}
+
+// tokenizeSearchQuery splits a search query into words, phrases, parentheses, colons and stars.
+// The list of tokens is terminated by an end token. Control characters (except for white space) are rejected.
+func tokenizeSearchQuery(query string) (tokens []searchToken, err error) {
+
+	runes := []rune(query)
+
+	for i, r := range runes {
+		if unicode.IsControl(r) && !unicode.IsSpace(r) {
+			return nil, &SearchQueryError{Position: i, Message: fmt.Sprintf("Control character %U is not allowed.", r)}
+		}
+	}
+
+	for i := 0; i < len(runes); i++ {
+
+		r := runes[i]
+
+		switch {
+		case unicode.IsSpace(r):
+			continue
+		case r == '(':
+			tokens = append(tokens, searchToken{kind: tokenOpen, text: "(", pos: i})
+		case r == ')':
+			tokens = append(tokens, searchToken{kind: tokenClose, text: ")", pos: i})
+		case r == ':':
+			tokens = append(tokens, searchToken{kind: tokenColon, text: ":", pos: i})
+		case r == '*':
+			tokens = append(tokens, searchToken{kind: tokenStar, text: "*", pos: i})
+		case r == '"':
+			start := i
+			i++
+			for i < len(runes) && runes[i] != '"' {
+				i++
+			}
+			if i >= len(runes) {
+				return nil, &SearchQueryError{Position: start, Message: "Phrase is not closed by a quotation mark."}
+			}
+			phrase := strings.TrimSpace(string(runes[start+1 : i]))
+			if phrase == "" {
+				return nil, &SearchQueryError{Position: start, Message: "Phrase is empty."}
+			}
+			tokens = append(tokens, searchToken{kind: tokenPhrase, text: phrase, pos: start})
+		default:
+			start := i
+			for i+1 < len(runes) && !unicode.IsSpace(runes[i+1]) && !strings.ContainsRune(`()":*`, runes[i+1]) {
+				i++
+			}
+			tokens = append(tokens, searchToken{kind: tokenWord, text: string(runes[start : i+1]), pos: start})
+		}
+	}
+
+	return append(tokens, searchToken{kind: tokenEnd, text: "end of query", pos: len(runes)}), nil
+}
+
+func (p *searchQueryParser) peek() searchToken {
+	return p.tokens[p.pos]
+}
+
+func (p *searchQueryParser) next() searchToken {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEnd {
+		p.pos++
+	}
+	return t
+}
+
+// isOperator checks whether the next token is the specified operator (e.g. "OR").
+func (p *searchQueryParser) isOperator(op string) bool {
+	t := p.peek()
+	return t.kind == tokenWord && t.text == op
+}
+
+// parseOr parses a disjunction of conjunctions (e.g. "a b OR c").
+func (p *searchQueryParser) parseOr() (string, error) {
+
+	left, err := p.parseAnd()
+	if err != nil {
+		return "", err
+	}
+
+	for p.isOperator("OR") {
+
+		p.next()
+
+		right, err := p.parseAnd()
+		if err != nil {
+			return "", err
+		}
+
+		left = left + " OR " + right
+	}
+
+	return left, nil
+}
+
+// parseAnd parses a conjunction (e.g. "a AND b" or "a b"). Like in FTS5, AND binds stronger than OR.
+func (p *searchQueryParser) parseAnd() (string, error) {
+
+	left, err := p.parseNot()
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		t := p.peek()
+
+		switch {
+		case t.kind == tokenWord && t.text == "AND":
+			p.next()
+		case t.kind == tokenWord && t.text != "OR" && t.text != "NOT", t.kind == tokenPhrase, t.kind == tokenOpen:
+			// implicit AND
+		default:
+			return left, nil
+		}
+
+		right, err := p.parseNot()
+		if err != nil {
+			return "", err
+		}
+
+		left = left + " AND " + right
+	}
+}
+
+// parseNot parses an exclusion (e.g. "a NOT b"). Like in FTS5, NOT requires a term on its left side and binds
+// stronger than AND.
+func (p *searchQueryParser) parseNot() (string, error) {
+
+	left, err := p.parseFiltered()
+	if err != nil {
+		return "", err
+	}
+
+	for p.isOperator("NOT") {
+
+		p.next()
+
+		right, err := p.parseFiltered()
+		if err != nil {
+			return "", err
+		}
+
+		left = left + " NOT " + right
+	}
+
+	return left, nil
+}
+
+// parseFiltered parses a term, phrase or group, optionally prefixed by a column filter (e.g. "title:crisis").
+func (p *searchQueryParser) parseFiltered() (string, error) {
+
+	t := p.peek()
+
+	if t.kind == tokenWord && p.tokens[p.pos+1].kind == tokenColon {
+
+		column, ok := p.columns[strings.ToLower(t.text)]
+		if !ok {
+			return "", &SearchQueryError{Position: t.pos, Message: fmt.Sprintf("Unknown column '%s'. Supported columns are %s.", t.text, p.columnNames())}
+		}
+
+		p.next()
+		p.next()
+
+		operand, err := p.parsePrimary()
+		if err != nil {
+			return "", err
+		}
+
+		return column + " : " + operand, nil
+	}
+
+	return p.parsePrimary()
+}
+
+// parsePrimary parses a term or phrase with optional prefix star (e.g. "bank*"), or a parenthesized group.
+func (p *searchQueryParser) parsePrimary() (string, error) {
+
+	t := p.next()
+
+	switch t.kind {
+	case tokenOpen:
+
+		group, err := p.parseOr()
+		if err != nil {
+			return "", err
+		}
+
+		if c := p.next(); c.kind != tokenClose {
+			return "", &SearchQueryError{Position: t.pos, Message: "Opening parenthesis is not closed."}
+		}
+
+		return "(" + group + ")", nil
+
+	case tokenWord, tokenPhrase:
+
+		if t.kind == tokenWord && (t.text == "AND" || t.text == "OR" || t.text == "NOT") {
+			return "", &SearchQueryError{Position: t.pos, Message: fmt.Sprintf("Operator %s requires a term on both sides.", t.text)}
+		}
+
+		fts := `"` + strings.Replace(t.text, `"`, `""`, -1) + `"`
+
+		if p.peek().kind == tokenStar {
+			p.next()
+			fts += " *"
+		}
+
+		return fts, nil
+
+	case tokenEnd:
+		return "", &SearchQueryError{Position: t.pos, Message: "Search query ends unexpectedly. A term is missing."}
+	case tokenStar:
+		return "", &SearchQueryError{Position: t.pos, Message: "Prefix star must directly follow a term."}
+	case tokenColon:
+		return "", &SearchQueryError{Position: t.pos, Message: fmt.Sprintf("Column filter requires a column name. Supported columns are %s.", p.columnNames())}
+	}
+
+	return "", &SearchQueryError{Position: t.pos, Message: fmt.Sprintf("Unexpected '%s'.", t.text)}
+}
+
+// columnNames returns a sorted, comma-separated list of the column names that can be used as filters.
+func (p *searchQueryParser) columnNames() string {
+
+	var names []string
+	for name := range p.columns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"testing"
+)
+
+import (
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/config"
+)
+
+func TestParseSearchQuery(t *testing.T) {
+
+	st := Open(&config.StorageConfiguration{DBFilename: ":memory:"})
+	defer st.Close()
+
+	st.CreateTestPublications()
+	st.BuildSearchIndicies()
+
+	// Perform test #1: valid queries are translated to FTS5 queries, that are accepted by SQLite
+
+	valid := map[string]string{
+		`crisis`:                            `"crisis"`,
+		`crisis banks`:                      `"crisis" AND "banks"`,
+		`crisis AND banks OR euro`:          `"crisis" AND "banks" OR "euro"`,
+		`crisis NOT banks`:                  `"crisis" NOT "banks"`,
+		`bank* "housing market"*`:           `"bank" * AND "housing market" *`,
+		`title:crisis Authors:"Doe"`:        `title : "crisis" AND authors : "Doe"`,
+		`subjects:(banks OR euro) crisis`:   `subjects : ("banks" OR "euro") AND "crisis"`,
+		`(a OR b) NOT (c d)`:                `("a" OR "b") NOT ("c" AND "d")`,
+		`U.S. covid-19 {record_id} - ^NEAR`: `"U.S." AND "covid-19" AND "{record_id}" AND "-" AND "^NEAR"`,
+		`and or not`:                        `"and" AND "or" AND "not"`,
+	}
+
+	for query, expected := range valid {
+
+		fts, err := parseSearchQuery(query, recordSearchColumns)
+		if err != nil || fts != expected {
+			t.Errorf("Expected query '%s' to be translated to '%s' but got '%s'. %v", query, expected, fts, err)
+			return
+		}
+
+		var count int64
+
+		err = st.db.QueryRow("SELECT COUNT(*) FROM vrecord WHERE vrecord MATCH '- {record_id} : (' || ? || ')'", fts).Scan(&count)
+		if err != nil {
+			t.Errorf("Expected FTS5 query '%s' to be accepted by SQLite. %s", fts, err)
+			return
+		}
+	}
+
+	// Perform test #2: malformed queries are rejected with the position of the error
+
+	malformed := map[string]int{
+		``:                   0,
+		`   `:                0,
+		`"housing market`:    0,
+		`crisis ""`:          7,
+		`crisis AND`:         10,
+		`OR crisis`:          0,
+		`crisis NOT`:         10,
+		`(crisis OR banks`:   0,
+		`crisis)`:            6,
+		`* crisis`:           0,
+		`year:2019`:          0,
+		`:crisis`:            0,
+		`title:`:             6,
+		`title:(crisis) *`:   15,
+		`crisis OR NOT euro`: 10,
+		"crisis\x00banks":    6,
+		"\"housing\x1b\"":    8,
+	}
+
+	for query, position := range malformed {
+
+		_, err := parseSearchQuery(query, recordSearchColumns)
+
+		queryErr, ok := err.(*SearchQueryError)
+		if !ok || queryErr.Position != position {
+			t.Errorf("Expected query '%s' to be rejected at position %d but got error '%v'.", query, position, err)
+			return
+		}
+	}
+
+	// Perform test #3: search the feed with a column filter
+
+	var uid int64
+	st.db.QueryRow("SELECT user_id FROM record_feed LIMIT 1").Scan(&uid)
+
+	if _, _, err := st.SearchRecordFeed(uid, "title:(", nil, 0, 10); err == nil {
+		t.Errorf("Expected error for malformed search query.")
+		return
+	}
+
+	if _, _, err := st.SearchExpertFeed(uid, "authors:doe", 0, 10); err == nil {
+		t.Errorf("Expected error for column that is not supported by the expert search.")
+		return
+	}
+}
@@ -0,0 +1,462 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+)
+
+import (
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/bibhash"
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/model"
+)
+
+// CreateHarvestRun logs the start of a harvesting run against an external repository and returns the ID of the run.
+// The harvested time window is defined by from (optional) and until.
+func (st *Storage) CreateHarvestRun(endpoint string, from string, until string) (runId int64, err error) {
+
+	const query = `
+		INSERT INTO harvest_run (endpoint,started,from_date,until_date)
+		VALUES(?,?,?,?)`
+
+	result, err := st.db.Exec(query, endpoint, time.Now().UTC().Format(time.RFC3339), StringToNull(from), until)
+	if err != nil {
+		log.Printf("Database error. Could not insert harvest run. %s", err)
+		return
+	}
+
+	runId, err = result.LastInsertId()
+	if err != nil {
+		log.Printf("Database error. Could not get ID for inserted harvest run. %s", err)
+		return
+	}
+
+	return
+}
+
+// Queries that remove all data depending on a record, which is given as first parameter.
+// Feedback is removed from the local database only, it still remains in the public ledger.
+var recordDependencyQueries = []string{
+	"DELETE FROM creator WHERE record_id=?1",
+	"DELETE FROM record_subject_link WHERE record_id=?1",
+	"DELETE FROM record_feed WHERE record_id=?1",
+	"DELETE FROM record_bookmark WHERE record_id=?1",
+	"DELETE FROM record_dislike WHERE record_id=?1",
+	"DELETE FROM record_visit WHERE record_id=?1",
+	"DELETE FROM feedback WHERE record_id=?1",
+	"DELETE FROM related_record WHERE record_id=?1 OR related_id=?1",
+	"DELETE FROM record_similarity WHERE record_id=?1 OR similar_id=?1",
+	"DELETE FROM vrecord WHERE record_id=?1",
+	"DELETE FROM record WHERE id=?1",
+}
+
+// DeleteRecordBySourceId removes a publication record that was withdrawn by its data source.
+// All data depending on the record, like its creators, subject links, feed entries, bookmarks, feedback and
+// similarities, are removed as well, and the record's terms no longer count for the TF-IDF similarity.
+func (st *Storage) DeleteRecordBySourceId(sourceId string) (err error) {
+
+	var recordId int64
+	var title, abstract string
+
+	err = st.db.QueryRow("SELECT id,title,IFNULL(abstract,'') FROM record WHERE source_id=?", sourceId).Scan(&recordId, &title, &abstract)
+	switch {
+	case err == sql.ErrNoRows:
+		return nil // nothing to delete
+	case err != nil:
+		log.Printf("Database error. Could not read ID of record with source ID '%s'. %s", sourceId, err)
+		return
+	}
+
+	tx, err := st.db.Begin()
+	if err != nil {
+		log.Printf("Database error. Could not initialize transaction for deleting record. %s", err)
+		return
+	}
+
+	for _, query := range recordDependencyQueries {
+		if _, err = tx.Exec(query, recordId); err != nil {
+			tx.Rollback()
+			log.Printf("Database error. Could not delete record %d ('%s'). %s", recordId, query, err)
+			return
+		}
+	}
+
+	for term := range termCounts(title + " " + abstract) {
+		if _, err = tx.Exec("UPDATE term_frequency SET record_count=record_count-1 WHERE term=?", term); err != nil {
+			tx.Rollback()
+			log.Printf("Database error. Could not update frequency of term '%s'. %s", term, err)
+			return
+		}
+	}
+
+	if _, err = tx.Exec("DELETE FROM term_frequency WHERE record_count<=0"); err != nil {
+		tx.Rollback()
+		log.Printf("Database error. Could not delete unused terms. %s", err)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		log.Printf("Database error. Could not commit transaction for deleting record. %s", err)
+		return
+	}
+
+	return
+}
+
+// ReadLastHarvestUntil returns the upper bound of the time window of the last successful harvesting run against
+// the specified repository. The returned value is empty if the repository has never been harvested successfully.
+func (st *Storage) ReadLastHarvestUntil(endpoint string) (until string, err error) {
+
+	const query = `
+		SELECT until_date FROM harvest_run
+		WHERE endpoint=? AND finished IS NOT NULL AND error IS NULL
+		ORDER BY id DESC
+		LIMIT 1`
+
+	err = st.db.QueryRow(query, endpoint).Scan(&until)
+	switch {
+	case err == sql.ErrNoRows:
+		return "", nil
+	case err != nil:
+		log.Printf("Database error. Could not read last harvest run for '%s'. %s", endpoint, err)
+	}
+
+	return
+}
+
+// ReadRecordIdByDoiOrBibHash returns the ID of a record that either has the specified DOI (case-insensitive) or the
+// specified bibliographic hash. Empty values are not compared. Returns 0 if there is no such record.
+func (st *Storage) ReadRecordIdByDoiOrBibHash(doi string, bibHash string) (recordId int64, err error) {
+
+	const query = `
+		SELECT id FROM record
+		WHERE (?<>'' AND LOWER(doi)=LOWER(?)) OR (?<>'' AND LOWER(bib_hash)=LOWER(?))
+		ORDER BY id ASC
+		LIMIT 1`
+
+	err = st.db.QueryRow(query, doi, doi, bibHash, bibHash).Scan(&recordId)
+	switch {
+	case err == sql.ErrNoRows:
+		return 0, nil
+	case err != nil:
+		log.Printf("Database error. Could not read record by DOI '%s' or bibliographic hash '%s'. %s", doi, bibHash, err)
+	}
+
+	return
+}
+
+// RebuildAfterIngest updates all data that is derived from the publication records, after records were added,
+// updated or removed. Missing bibliographic hashes are filled in, and the experts, the co-authorship graph, the search
+// indices and the feeds of all users are rebuilt.
+func (st *Storage) RebuildAfterIngest() (err error) {
+
+	if _, err = st.UpdateBibHashes(); err != nil {
+		return
+	}
+
+	if _, err = st.BuildExperts(); err != nil {
+		return
+	}
+
+	if err = st.BuildCoauthorshipGraph(); err != nil {
+		return
+	}
+
+	if err = st.BuildSearchIndicies(); err != nil {
+		return
+	}
+
+	return st.RebuildAllFeeds()
+}
+
+// RebuildAllFeeds precomputes the record and expert feeds of all users.
+// Must be called after records were added, updated or removed.
+func (st *Storage) RebuildAllFeeds() (err error) {
+
+	rows, err := st.db.Query("SELECT id FROM user")
+	if err != nil {
+		log.Printf("Database error. Could not read users for rebuilding feeds. %s", err)
+		return
+	}
+
+	var uids []int64
+
+	for rows.Next() {
+
+		var uid int64
+
+		err = rows.Scan(&uid)
+		if err != nil {
+			rows.Close()
+			log.Printf("Database error. Scanning user IDs failed. %s", err)
+			return
+		}
+
+		uids = append(uids, uid)
+	}
+	rows.Close()
+
+	tx, err := st.db.Begin()
+	if err != nil {
+		log.Printf("Database error. Could not initialize transaction for rebuilding feeds. %s", err)
+		return
+	}
+
+	// Feedback counts are the same for all users and therefore read only once.
+	feedbackCounts, err := st.readExpertFeedbackCounts(tx)
+	if err != nil {
+		tx.Rollback()
+		log.Printf("Database error. Could not read feedback counts for rebuilding feeds. %s", err)
+		return
+	}
+
+	for _, uid := range uids {
+		st.rebuildFeedsWithFeedbackCounts(tx, uid, feedbackCounts)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		log.Printf("Database error. Could not commit transaction for rebuilding feeds. %s", err)
+		return
+	}
+
+	return
+}
+
+// UpdateBibHashes computes the level 1 bibliographic hashes of all records, whose hash is missing or invalid.
+// Valid hashes are never changed, since they identify the records' feedback on the ledger. Returns the number of updated
+// records.
+func (st *Storage) UpdateBibHashes() (count int64, err error) {
+
+	rows, err := st.db.Query("SELECT id,bib_hash FROM record ORDER BY id ASC")
+	if err != nil {
+		log.Printf("Database error. Could not read bibliographic hashes. %s", err)
+		return
+	}
+
+	var recordIds []int64
+
+	for rows.Next() {
+
+		var recordId int64
+		var bibHash sql.NullString
+
+		err = rows.Scan(&recordId, &bibHash)
+		if err != nil {
+			rows.Close()
+			log.Printf("Database error. Scanning bibliographic hashes failed. %s", err)
+			return
+		}
+
+		if !bibhash.IsValid(NullToString(bibHash)) {
+			recordIds = append(recordIds, recordId)
+		}
+	}
+	rows.Close()
+
+	tx, err := st.db.Begin()
+	if err != nil {
+		log.Printf("Database error. Could not initialize transaction for updating bibliographic hashes. %s", err)
+		return
+	}
+
+	for _, recordId := range recordIds {
+
+		if err = updateBibHash(tx, recordId); err != nil {
+			tx.Rollback()
+			log.Printf("Database error. Could not update bibliographic hash of record %d. %s", recordId, err)
+			return 0, err
+		}
+
+		count++
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		log.Printf("Database error. Could not commit transaction for updating bibliographic hashes. %s", err)
+		return 0, err
+	}
+
+	return
+}
+
+// UpdateHarvestRun logs the end of a harvesting run, together with the number of inserted or updated records and the
+// number of deleted records. A non-empty error message marks the run as failed.
+func (st *Storage) UpdateHarvestRun(runId int64, recordCount int64, deletedCount int64, errMsg string) (err error) {
+
+	const query = `
+		UPDATE harvest_run SET finished=?, record_count=?, deleted_count=?, error=?
+		WHERE id=?`
+
+	_, err = st.db.Exec(query, time.Now().UTC().Format(time.RFC3339), recordCount, deletedCount, StringToNull(errMsg), runId)
+	if err != nil {
+		log.Printf("Database error. Could not update harvest run %d. %s", runId, err)
+		return
+	}
+
+	return
+}
+
+// UpsertRecord inserts a publication record or updates it, if a record with the same source ID already exists.
+// The record's creators and subjects are replaced. Unknown subjects are added to the list of supported subjects.
+// Returns the database ID of the record.
+func (st *Storage) UpsertRecord(rec *model.Record) (recordId int64, err error) {
+
+	tx, err := st.db.Begin()
+	if err != nil {
+		log.Printf("Database error. Could not initialize transaction for upserting record. %s", err)
+		return
+	}
+
+	recordId, err = upsertRecord(tx, rec)
+	if err != nil {
+		tx.Rollback()
+		log.Printf("Database error. Could not upsert record with source ID '%s'. %s", rec.SourceId, err)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		log.Printf("Database error. Could not commit transaction for upserting record. %s", err)
+		return
+	}
+
+	return
+}
+
+// updateBibHash computes the level 1 bibliographic hash of a record from its title, creators and year of publication
+// within the specified transaction.
+func updateBibHash(tx *sql.Tx, recordId int64) (err error) {
+
+	var title string
+	var year int64
+	var creators []model.Creator
+
+	err = tx.QueryRow("SELECT title,year FROM record WHERE id=?", recordId).Scan(&title, &year)
+	if err != nil {
+		return fmt.Errorf("Could not read record. %s", err)
+	}
+
+	rows, err := tx.Query("SELECT first_name,last_name FROM creator WHERE record_id=? ORDER BY id ASC", recordId)
+	if err != nil {
+		return fmt.Errorf("Could not read creators. %s", err)
+	}
+
+	for rows.Next() {
+
+		var c model.Creator
+
+		if err = rows.Scan(&c.FirstName, &c.LastName); err != nil {
+			rows.Close()
+			return fmt.Errorf("Could not scan creator. %s", err)
+		}
+
+		creators = append(creators, c)
+	}
+	rows.Close()
+
+	_, err = tx.Exec("UPDATE record SET bib_hash=? WHERE id=?", bibhash.Level1(title, creators, year), recordId)
+	if err != nil {
+		return fmt.Errorf("Could not update bibliographic hash. %s", err)
+	}
+
+	return
+}
+
+// upsertRecord implements UpsertRecord within the specified transaction.
+func upsertRecord(tx *sql.Tx, rec *model.Record) (recordId int64, err error) {
+
+	const insertRecord = `
+		INSERT INTO record (source_id,abstract,doi,oa,repository_link,pdf_link,title,type,year,json_bookmark,json_preview,json_detail,bib_hash)
+		VALUES(?,?,?,?,?,?,?,?,?,'','','',?)`
+
+	const updateRecord = `
+		UPDATE record SET abstract=?, doi=?, oa=?, repository_link=?, pdf_link=?, title=?, type=?, year=?, bib_hash=IFNULL(?,bib_hash)
+		WHERE id=?`
+
+	// 1. Insert or update the record itself.
+
+	err = tx.QueryRow("SELECT id FROM record WHERE source_id=?", rec.SourceId).Scan(&recordId)
+
+	switch {
+	case err == sql.ErrNoRows:
+		result, err := tx.Exec(insertRecord, rec.SourceId, StringToNull(rec.Abstract), StringToNull(rec.Doi), rec.OpenAccess,
+			StringToNull(rec.RepositoryLink), StringToNull(rec.PDFLink), rec.Title, rec.Type, rec.Year, StringToNull(rec.BibHash))
+		if err != nil {
+			return 0, fmt.Errorf("Could not insert record. %s", err)
+		}
+		recordId, err = result.LastInsertId()
+		if err != nil {
+			return 0, fmt.Errorf("Could not get ID for inserted record. %s", err)
+		}
+	case err != nil:
+		return 0, fmt.Errorf("Could not read ID of record. %s", err)
+	default:
+		_, err = tx.Exec(updateRecord, StringToNull(rec.Abstract), StringToNull(rec.Doi), rec.OpenAccess,
+			StringToNull(rec.RepositoryLink), StringToNull(rec.PDFLink), rec.Title, rec.Type, rec.Year, StringToNull(rec.BibHash), recordId)
+		if err != nil {
+			return 0, fmt.Errorf("Could not update record. %s", err)
+		}
+	}
+
+	// 2. Replace creators. Links to expert profiles are lost and need to be rebuilt.
+
+	if _, err = tx.Exec("DELETE FROM creator WHERE record_id=?", recordId); err != nil {
+		return 0, fmt.Errorf("Could not delete creators. %s", err)
+	}
+
+	for _, c := range rec.Creators {
+		_, err = tx.Exec("INSERT INTO creator (first_name,last_name,record_id) VALUES(?,?,?)", c.FirstName, c.LastName, recordId)
+		if err != nil {
+			return 0, fmt.Errorf("Could not insert creator. %s", err)
+		}
+	}
+
+	// 3. Replace subjects, adding subjects that are not known yet.
+
+	if _, err = tx.Exec("DELETE FROM record_subject_link WHERE record_id=?", recordId); err != nil {
+		return 0, fmt.Errorf("Could not delete subject links. %s", err)
+	}
+
+	for _, keyword := range rec.Subjects {
+
+		var subjectId int64
+
+		if _, err = tx.Exec("INSERT OR IGNORE INTO subject (keyword) VALUES(?)", keyword); err != nil {
+			return 0, fmt.Errorf("Could not insert subject '%s'. %s", keyword, err)
+		}
+
+		if err = tx.QueryRow("SELECT id FROM subject WHERE keyword=?", keyword).Scan(&subjectId); err != nil {
+			return 0, fmt.Errorf("Could not read ID of subject '%s'. %s", keyword, err)
+		}
+
+		_, err = tx.Exec("INSERT OR IGNORE INTO record_subject_link (record_id,subject_id,weight) VALUES(?,?,1.0)", recordId, subjectId)
+		if err != nil {
+			return 0, fmt.Errorf("Could not link subject '%s'. %s", keyword, err)
+		}
+	}
+
+	// 4. Compute the bibliographic hash, unless the record already has a valid one.
+
+	var bibHash sql.NullString
+
+	if err = tx.QueryRow("SELECT bib_hash FROM record WHERE id=?", recordId).Scan(&bibHash); err != nil {
+		return 0, fmt.Errorf("Could not read bibliographic hash. %s", err)
+	}
+
+	if !bibhash.IsValid(NullToString(bibHash)) {
+		if err = updateBibHash(tx, recordId); err != nil {
+			return 0, err
+		}
+	}
+
+	// 5. Precompute the JSON representations of the record.
+
+	if err = precomputeRecordJSON(tx, recordId); err != nil {
+		return 0, err
+	}
+
+	return recordId, nil
+}
@@ -0,0 +1,156 @@
+package storage
+
+import (
+	"testing"
+)
+
+import (
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/bibhash"
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/config"
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/model"
+)
+
+func TestUpdateBibHashes(t *testing.T) {
+
+	st := Open(&config.StorageConfiguration{DBFilename: ":memory:"})
+	defer st.Close()
+
+	st.CreateTestPublications()
+
+	var recordA, recordB, recordC int64
+	var validHash string
+
+	st.db.QueryRow("SELECT MAX(id),bib_hash FROM record").Scan(&recordC, &validHash)
+	st.db.QueryRow("SELECT MAX(id) FROM record WHERE id<?", recordC).Scan(&recordB)
+	st.db.QueryRow("SELECT MAX(id) FROM record WHERE id<?", recordB).Scan(&recordA)
+
+	st.db.Exec("UPDATE record SET bib_hash=NULL WHERE id=?", recordA)
+	st.db.Exec("UPDATE record SET bib_hash='not a hash' WHERE id=?", recordB)
+
+	// Perform test #1: missing and invalid hashes are computed, valid hashes are kept
+
+	count, err := st.UpdateBibHashes()
+	if err != nil {
+		t.Errorf("Unexpected error. %s", err)
+		return
+	}
+
+	if count != 2 {
+		t.Errorf("Expected %d updated records but got %d.", 2, count)
+		return
+	}
+
+	var title string
+	var year int64
+	var creators []model.Creator
+
+	st.db.QueryRow("SELECT title,year FROM record WHERE id=?", recordA).Scan(&title, &year)
+
+	rows, _ := st.db.Query("SELECT first_name,last_name FROM creator WHERE record_id=? ORDER BY id", recordA)
+	for rows.Next() {
+		var c model.Creator
+		rows.Scan(&c.FirstName, &c.LastName)
+		creators = append(creators, c)
+	}
+	rows.Close()
+
+	bibHash, err := st.ReadBibHashByRecordId(recordA)
+	if err != nil || bibHash != bibhash.Level1(title, creators, year) {
+		t.Errorf("Expected computed hash '%s' but got '%s'.", bibhash.Level1(title, creators, year), bibHash)
+		return
+	}
+
+	if bibHash, _ = st.ReadBibHashByRecordId(recordB); !bibhash.IsValid(bibHash) {
+		t.Errorf("Expected valid hash but got '%s'.", bibHash)
+		return
+	}
+
+	if bibHash, _ = st.ReadBibHashByRecordId(recordC); bibHash != validHash {
+		t.Errorf("Expected hash '%s' to be kept but got '%s'.", validHash, bibHash)
+		return
+	}
+
+	// Hashes of the former scheme differ from level 1 hashes, but must be kept nonetheless.
+	if bibHash, _ = st.ReadBibHashByRecordId(3702); bibHash != "ef7c5bed6d927d084d782662f9af69dd" {
+		t.Errorf("Expected hash '%s' to be kept but got '%s'.", "ef7c5bed6d927d084d782662f9af69dd", bibHash)
+		return
+	}
+
+	// Perform test #2: upserted records without hash receive a hash
+
+	rec := model.Record{SourceId: "X", Title: "Zipf zipped", Type: model.RecordTypePaper, Year: 2004}
+
+	recordId, err := st.UpsertRecord(&rec)
+	if err != nil {
+		t.Errorf("Unexpected error. %s", err)
+		return
+	}
+
+	if bibHash, _ = st.ReadBibHashByRecordId(recordId); bibHash != "6215625f17c293230971dad51301783d" {
+		t.Errorf("Expected hash '%s' but got '%s'.", "6215625f17c293230971dad51301783d", bibHash)
+		return
+	}
+}
+
+func TestDeleteRecordBySourceId(t *testing.T) {
+
+	st := Open(&config.StorageConfiguration{DBFilename: ":memory:"})
+	defer st.Close()
+
+	st.CreateTestPublications()
+	st.BuildRelatedRecordIndex()
+
+	var recordId int64
+	var sourceId, title string
+
+	st.db.QueryRow("SELECT id,source_id,title FROM record ORDER BY id LIMIT 1").Scan(&recordId, &sourceId, &title)
+
+	user := model.User{GUID: "delete-record-test", HashedSecret: "-"}
+	st.CreateUser(&user)
+	st.CreateRecordBookmark(user.Id, recordId)
+	st.CreateRecordDislike(user.Id, recordId)
+
+	if _, err := st.ReadRelatedRecords(user.Id, recordId, 5); err != nil {
+		t.Errorf("Unexpected error. %s", err)
+		return
+	}
+
+	var term string
+	var termCount int64
+
+	for term = range termCounts(title) {
+		break
+	}
+	st.db.QueryRow("SELECT record_count FROM term_frequency WHERE term=?", term).Scan(&termCount)
+
+	// Perform test #1: the record and all data depending on it are removed
+
+	if err := st.DeleteRecordBySourceId(sourceId); err != nil {
+		t.Errorf("Unexpected error. %s", err)
+		return
+	}
+
+	for _, query := range []string{
+		"SELECT COUNT(*) FROM record WHERE id=?1",
+		"SELECT COUNT(*) FROM creator WHERE record_id=?1",
+		"SELECT COUNT(*) FROM record_bookmark WHERE record_id=?1",
+		"SELECT COUNT(*) FROM record_dislike WHERE record_id=?1",
+		"SELECT COUNT(*) FROM related_record WHERE record_id=?1 OR related_id=?1",
+	} {
+		var count int64
+		if st.db.QueryRow(query, recordId).Scan(&count); count != 0 {
+			t.Errorf("Expected no rows for '%s' but got %d.", query, count)
+			return
+		}
+	}
+
+	// Perform test #2: the terms of the record no longer count
+
+	var newTermCount int64
+	st.db.QueryRow("SELECT IFNULL(SUM(record_count),0) FROM term_frequency WHERE term=?", term).Scan(&newTermCount)
+
+	if newTermCount != termCount-1 {
+		t.Errorf("Expected %d records with term '%s' but got %d.", termCount-1, term, newTermCount)
+		return
+	}
+}
@@ -5,13 +5,13 @@ import (
 	"encoding/hex"
 	"fmt"
 	"log"
-	"strings"
 
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/fzi-forschungszentrum-informatik/dream-gozer/config"
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/model"
 )
 
 // Ledger defines an abstraction to the Ethereum blockchain, that includes the contract and the private
@@ -109,28 +109,16 @@ func bibHashToByteArray(bibHash string) (data [16]byte, err error) {
 	return
 }
 
-// orcIdToByteArray converts an OrcId string representation (e.g. "0000-0001-5000-000X") to a charachter encoded byte-array of length 16.
-// Returns error if form of string does not follow standard 19-character form.
+// orcIdToByteArray converts an OrcId string representation (e.g. "0000-0002-1694-233X") to a charachter encoded byte-array of length 16.
+// Returns error if the string is no valid ORCiD (see model.ParseOrcID).
 func orcIdToByteArray(orcId string) (data [16]byte, err error) {
 
-	numChars := len(orcId)
-
-	if numChars != 19 {
-		err = fmt.Errorf("OrcId string has wrong length. Expected 19 characters but got %d.", numChars)
+	parsed, err := model.ParseOrcID(orcId)
+	if err != nil {
 		return
 	}
 
-	// Local helper function that removes dashes from strings (as part of strings.Map).
-	removeDash := func(r rune) rune {
-		if r == '-' {
-			return -1
-		}
-		return r
-	}
-
-	trimmedOrcId := strings.Map(removeDash, orcId)
-	decoded := []byte(trimmedOrcId)
-	copy(data[:], decoded)
+	copy(data[:], []byte(parsed.Digits()))
 
 	return
 }
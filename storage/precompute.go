@@ -0,0 +1,535 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+)
+
+import (
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/model"
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/model/ploc"
+)
+
+const (
+	// Trailing part of each precomputed record preview. The visited flag is replaced on the fly by the feed queries.
+	previewSuffix = `false}`
+	// Trailing part of each precomputed record bookmark. The visited flag and the collection IDs are replaced on
+	// the fly by ReadRecordBookmarks.
+	bookmarkSuffix = `false,"collection_ids":[]}`
+)
+
+// queryer abstracts from the differences between a database connection and a transaction, so that the JSON
+// generators can be used within and outside of transactions.
+type queryer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// RecordJSONDrift describes a record whose precomputed JSON columns differ from the JSON that is generated from its
+// relational data. Columns lists the names of the drifted columns (json_bookmark, json_preview or json_detail).
+type RecordJSONDrift struct {
+	RecordId int64
+	Columns  []string
+}
+
+// CheckRecordJSON compares the precomputed JSON columns of all records with the JSON that is generated from the
+// relational data and returns the records that have drifted. The comparison is based on the decoded JSON values, so
+// that differences in escaping or key order are ignored, as long as the suffixes required by the feed queries are intact.
+func (st *Storage) CheckRecordJSON() (drifts []RecordJSONDrift, err error) {
+
+	recordIds, err := readIds(st.db, "SELECT id FROM record ORDER BY id ASC")
+	if err != nil {
+		log.Printf("Database error. Could not read record IDs for checking precomputed JSON. %s", err)
+		return
+	}
+
+	for _, recordId := range recordIds {
+
+		var stored [3]string
+
+		err = st.db.QueryRow("SELECT json_bookmark,json_preview,json_detail FROM record WHERE id=?", recordId).Scan(
+			&stored[0], &stored[1], &stored[2])
+		if err != nil {
+			log.Printf("Database error. Could not read precomputed JSON of record %d. %s", recordId, err)
+			return
+		}
+
+		bookmark, preview, detail, genErr := generateRecordJSON(st.db, recordId)
+		if genErr != nil {
+			log.Printf("Database error. Could not generate JSON of record %d. %s", recordId, genErr)
+			return nil, genErr
+		}
+
+		var drift = RecordJSONDrift{RecordId: recordId}
+
+		if !sameJSON(stored[0], bookmark) || !strings.HasSuffix(stored[0], bookmarkSuffix) {
+			drift.Columns = append(drift.Columns, "json_bookmark")
+		}
+		if !sameJSON(stored[1], preview) || !strings.HasSuffix(stored[1], previewSuffix) {
+			drift.Columns = append(drift.Columns, "json_preview")
+		}
+		if !sameJSON(stored[2], detail) {
+			drift.Columns = append(drift.Columns, "json_detail")
+		}
+
+		if len(drift.Columns) > 0 {
+			drifts = append(drifts, drift)
+		}
+	}
+
+	return drifts, nil
+}
+
+// PrecomputeAllRecordJSON regenerates the precomputed JSON columns of all records from their relational data.
+// Returns the number of updated records.
+func (st *Storage) PrecomputeAllRecordJSON() (count int64, err error) {
+
+	recordIds, err := readIds(st.db, "SELECT id FROM record ORDER BY id ASC")
+	if err != nil {
+		log.Printf("Database error. Could not read record IDs for precomputing JSON. %s", err)
+		return
+	}
+
+	tx, err := st.db.Begin()
+	if err != nil {
+		log.Printf("Database error. Could not initialize transaction for precomputing record JSON. %s", err)
+		return
+	}
+
+	for _, recordId := range recordIds {
+
+		if err = precomputeRecordJSON(tx, recordId); err != nil {
+			tx.Rollback()
+			log.Printf("Database error. Could not precompute JSON of record %d. %s", recordId, err)
+			return 0, err
+		}
+
+		count++
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		log.Printf("Database error. Could not commit transaction for precomputing record JSON. %s", err)
+		return 0, err
+	}
+
+	return
+}
+
+// PrecomputeExpertJSON regenerates the precomputed JSON columns of a single expert from its relational data.
+// Must be called each time an expert's profile, its statistics, its subject links or its creators have changed.
+func (st *Storage) PrecomputeExpertJSON(expertId int64) (err error) {
+
+	err = precomputeExpertJSON(st.db, expertId)
+	if err != nil {
+		log.Printf("Database error. Could not precompute JSON of expert %d. %s", expertId, err)
+		return
+	}
+
+	return
+}
+
+// PrecomputeRecordJSON regenerates the precomputed JSON columns of a single record from its relational data.
+// Must be called each time a record, its creators or its subjects have changed.
+func (st *Storage) PrecomputeRecordJSON(recordId int64) (err error) {
+
+	err = precomputeRecordJSON(st.db, recordId)
+	if err != nil {
+		log.Printf("Database error. Could not precompute JSON of record %d. %s", recordId, err)
+		return
+	}
+
+	return
+}
+
+// precomputeRecordJSON implements PrecomputeRecordJSON for a database connection or a transaction.
+func precomputeRecordJSON(q queryer, recordId int64) (err error) {
+
+	bookmark, preview, detail, err := generateRecordJSON(q, recordId)
+	if err != nil {
+		return
+	}
+
+	_, err = q.Exec("UPDATE record SET json_bookmark=?, json_preview=?, json_detail=? WHERE id=?", bookmark, preview, detail, recordId)
+	if err != nil {
+		return fmt.Errorf("Could not update precomputed JSON. %s", err)
+	}
+
+	return
+}
+
+// generateRecordJSON creates the bookmark, preview and detailed JSON representation of a record from its relational
+// data, as it is sent to the ploc client app. Creators are ordered as they were inserted, as are the subjects.
+func generateRecordJSON(q queryer, recordId int64) (bookmark string, preview string, detail string, err error) {
+
+	rec, err := readRecord(q, recordId)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	creators := rec.Creators
+	keywords := rec.Subjects
+	if keywords == nil {
+		keywords = []string{}
+	}
+
+	// Build JSON representations
+
+	names := ploc.Names{}
+	for _, c := range creators {
+		names = append(names, abbreviatedName(c.FirstName, c.LastName))
+	}
+
+	p := ploc.RecordPreview{
+		Id:       recordId,
+		Title:    rec.Title,
+		Year:     rec.Year,
+		Creators: shortCreatorList(creators),
+		Subjects: keywords,
+		Abstract: rec.Abstract,
+		Type:     rec.Type,
+	}
+
+	b := ploc.RecordBookmark{
+		Id:            recordId,
+		Title:         rec.Title,
+		Year:          rec.Year,
+		Creators:      shortCreatorList(creators),
+		Subjects:      keywords,
+		Abstract:      rec.Abstract,
+		Type:          rec.Type,
+		CollectionIds: []int64{},
+	}
+
+	d := ploc.ReadRecordDetailsResponse{
+		Id:             recordId,
+		Title:          rec.Title,
+		Creators:       names,
+		Subjects:       ploc.Keywords(keywords),
+		Year:           rec.Year,
+		Teaser:         rec.Abstract,
+		Type:           rec.Type,
+		Doi:            rec.Doi,
+		RepositoryLink: rec.RepositoryLink,
+		PDFLink:        rec.PDFLink,
+	}
+
+	jPreview, err := json.Marshal(p)
+	if err != nil {
+		return "", "", "", fmt.Errorf("Could not marshal record preview. %s", err)
+	}
+
+	jBookmark, err := json.Marshal(b)
+	if err != nil {
+		return "", "", "", fmt.Errorf("Could not marshal record bookmark. %s", err)
+	}
+
+	// ReadRecordDetailsResponse marshals its raw details only, so the fields are marshalled via a plain copy of the type.
+	type details ploc.ReadRecordDetailsResponse
+
+	jDetail, err := json.Marshal(details(d))
+	if err != nil {
+		return "", "", "", fmt.Errorf("Could not marshal record details. %s", err)
+	}
+
+	return string(jBookmark), string(jPreview), string(jDetail), nil
+}
+
+// readRecord reads a publication record with its creators and subjects from the relational data.
+// Creators are ordered as they were ingested, subjects as they were linked to the record.
+func readRecord(q queryer, recordId int64) (rec model.Record, err error) {
+
+	var abstract, doi, repositoryLink, pdfLink, bibHash sql.NullString
+	var openAccess sql.NullInt64
+
+	const recordQuery = `
+		SELECT source_id,title,type,year,abstract,doi,oa,repository_link,pdf_link,bib_hash
+		FROM record WHERE id=?`
+
+	err = q.QueryRow(recordQuery, recordId).Scan(&rec.SourceId, &rec.Title, &rec.Type, &rec.Year, &abstract, &doi,
+		&openAccess, &repositoryLink, &pdfLink, &bibHash)
+	if err != nil {
+		return rec, fmt.Errorf("Could not read record. %s", err)
+	}
+
+	rec.Abstract = NullToString(abstract)
+	rec.Doi = NullToString(doi)
+	rec.OpenAccess = NullToInt64(openAccess)
+	rec.RepositoryLink = NullToString(repositoryLink)
+	rec.PDFLink = NullToString(pdfLink)
+	rec.BibHash = NullToString(bibHash)
+
+	// Read creators
+
+	rows, err := q.Query("SELECT first_name,last_name FROM creator WHERE record_id=? ORDER BY id ASC", recordId)
+	if err != nil {
+		return rec, fmt.Errorf("Could not read creators. %s", err)
+	}
+
+	for rows.Next() {
+
+		var c model.Creator
+
+		if err = rows.Scan(&c.FirstName, &c.LastName); err != nil {
+			rows.Close()
+			return rec, fmt.Errorf("Could not scan creator. %s", err)
+		}
+
+		rec.Creators = append(rec.Creators, c)
+	}
+	rows.Close()
+
+	// Read subjects
+
+	const subjectQuery = `
+		SELECT s.keyword
+		FROM record_subject_link AS rsl, subject AS s
+		WHERE rsl.record_id=? AND rsl.subject_id=s.id
+		ORDER BY rsl.rowid ASC`
+
+	rows, err = q.Query(subjectQuery, recordId)
+	if err != nil {
+		return rec, fmt.Errorf("Could not read subjects. %s", err)
+	}
+
+	for rows.Next() {
+
+		var keyword string
+
+		if err = rows.Scan(&keyword); err != nil {
+			rows.Close()
+			return rec, fmt.Errorf("Could not scan subject. %s", err)
+		}
+
+		rec.Subjects = append(rec.Subjects, keyword)
+	}
+	rows.Close()
+
+	return rec, nil
+}
+
+// precomputeExpertJSON implements PrecomputeExpertJSON for a database connection or a transaction.
+func precomputeExpertJSON(q queryer, expertId int64) (err error) {
+
+	bookmark, preview, detail, err := generateExpertJSON(q, expertId)
+	if err != nil {
+		return
+	}
+
+	_, err = q.Exec("UPDATE expert SET json_bookmark=?, json_preview=?, json_detail=? WHERE id=?", bookmark, preview, detail, expertId)
+	if err != nil {
+		return fmt.Errorf("Could not update precomputed JSON. %s", err)
+	}
+
+	return
+}
+
+// generateExpertJSON creates the bookmark, preview and detailed JSON representation of an expert from its relational
+// data, as it is sent to the ploc client app. Subjects are ordered by the number of related records and publications
+// by their year of publication, most recent first.
+func generateExpertJSON(q queryer, expertId int64) (bookmark string, preview string, detail string, err error) {
+
+	var (
+		firstName, lastName                        string
+		orcId, affiliation                         sql.NullString
+		lastPublicationYear, totalPublicationCount sql.NullInt64
+		claimed                                    bool
+		keywords                                   = []string{}
+		records                                    = ploc.TinyRecords{}
+	)
+
+	const expertQuery = `
+		SELECT first_name,last_name,orcid,affiliation,last_publication_year,total_publication_count
+		FROM expert WHERE id=?`
+
+	err = q.QueryRow(expertQuery, expertId).Scan(&firstName, &lastName, &orcId, &affiliation, &lastPublicationYear, &totalPublicationCount)
+	if err != nil {
+		return "", "", "", fmt.Errorf("Could not read expert. %s", err)
+	}
+
+	err = q.QueryRow("SELECT COUNT(*)>0 FROM expert_claim WHERE expert_id=? AND status=?", expertId, ExpertClaimApproved).Scan(&claimed)
+	if err != nil {
+		return "", "", "", fmt.Errorf("Could not read claims of expert. %s", err)
+	}
+
+	// Read subjects
+
+	const subjectQuery = `
+		SELECT s.keyword
+		FROM expert_subject_link AS esl, subject AS s
+		WHERE esl.expert_id=? AND esl.subject_id=s.id
+		ORDER BY esl.record_count DESC, s.keyword ASC`
+
+	rows, err := q.Query(subjectQuery, expertId)
+	if err != nil {
+		return "", "", "", fmt.Errorf("Could not read subjects. %s", err)
+	}
+
+	for rows.Next() {
+
+		var keyword string
+
+		if err = rows.Scan(&keyword); err != nil {
+			rows.Close()
+			return "", "", "", fmt.Errorf("Could not scan subject. %s", err)
+		}
+
+		keywords = append(keywords, keyword)
+	}
+	rows.Close()
+
+	// Read publications and their creators
+
+	const recordQuery = `
+		SELECT DISTINCT r.id,r.title,r.year
+		FROM creator AS c, record AS r
+		WHERE c.expert_id=? AND c.record_id=r.id
+		ORDER BY r.year DESC, r.id DESC`
+
+	rows, err = q.Query(recordQuery, expertId)
+	if err != nil {
+		return "", "", "", fmt.Errorf("Could not read records. %s", err)
+	}
+
+	for rows.Next() {
+
+		var r = ploc.TinyRecord{Creators: []string{}}
+
+		if err = rows.Scan(&r.Id, &r.Title, &r.Year); err != nil {
+			rows.Close()
+			return "", "", "", fmt.Errorf("Could not scan record. %s", err)
+		}
+
+		records = append(records, r)
+	}
+	rows.Close()
+
+	for i := range records {
+
+		rows, err = q.Query("SELECT first_name,last_name FROM creator WHERE record_id=? ORDER BY id ASC", records[i].Id)
+		if err != nil {
+			return "", "", "", fmt.Errorf("Could not read creators. %s", err)
+		}
+
+		for rows.Next() {
+
+			var first, last string
+
+			if err = rows.Scan(&first, &last); err != nil {
+				rows.Close()
+				return "", "", "", fmt.Errorf("Could not scan creator. %s", err)
+			}
+
+			records[i].Creators = append(records[i].Creators, abbreviatedName(first, last))
+		}
+		rows.Close()
+	}
+
+	// Build JSON representations
+
+	p := ploc.ExpertPreview{
+		Id:                    expertId,
+		Name:                  abbreviatedName(firstName, lastName),
+		Affiliation:           NullToString(affiliation),
+		LastPublicationYear:   NullToInt64(lastPublicationYear),
+		TotalPublicationCount: NullToInt64(totalPublicationCount),
+		Subjects:              keywords,
+	}
+
+	d := ploc.ReadExpertDetailsResponse{
+		Id:                    expertId,
+		Name:                  strings.TrimSpace(firstName + " " + lastName),
+		Affiliation:           NullToString(affiliation),
+		LastPublicationYear:   NullToInt64(lastPublicationYear),
+		TotalPublicationCount: NullToInt64(totalPublicationCount),
+		OrcId:                 NullToString(orcId),
+		Claimed:               claimed,
+		Subjects:              ploc.Keywords(keywords),
+		Records:               records,
+	}
+
+	jPreview, err := json.Marshal(p)
+	if err != nil {
+		return "", "", "", fmt.Errorf("Could not marshal expert preview. %s", err)
+	}
+
+	// ReadExpertDetailsResponse marshals its raw details only, so the fields are marshalled via a plain copy of the type.
+	type details ploc.ReadExpertDetailsResponse
+
+	jDetail, err := json.Marshal(details(d))
+	if err != nil {
+		return "", "", "", fmt.Errorf("Could not marshal expert details. %s", err)
+	}
+
+	// Bookmarks of experts are identical to their previews.
+	return string(jPreview), string(jPreview), string(jDetail), nil
+}
+
+// readIds returns the IDs of the first column of a query with the given arguments.
+func readIds(q queryer, query string, args ...interface{}) (ids []int64, err error) {
+
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+
+		var id int64
+
+		if err = rows.Scan(&id); err != nil {
+			return
+		}
+
+		ids = append(ids, id)
+	}
+
+	return
+}
+
+// sameJSON returns true if both strings decode to equal JSON values.
+func sameJSON(a string, b string) bool {
+
+	var va, vb interface{}
+
+	if json.Unmarshal([]byte(a), &va) != nil || json.Unmarshal([]byte(b), &vb) != nil {
+		return false
+	}
+
+	return reflect.DeepEqual(va, vb)
+}
+
+// abbreviatedName returns the abbreviated form of a creator's or expert's name (e.g. "J. Doe" for "John Doe").
+func abbreviatedName(firstName string, lastName string) string {
+
+	if firstName == "" {
+		return lastName
+	}
+
+	initial, _ := utf8.DecodeRuneInString(firstName)
+
+	return string(initial) + ". " + lastName
+}
+
+// shortCreatorList returns a short summary of the creators' names, as it is shown in the record previews
+// (e.g. "Doe", "Doe and Roe" or "Doe et al.").
+func shortCreatorList(creators []model.Creator) string {
+
+	switch len(creators) {
+	case 0:
+		return ""
+	case 1:
+		return creators[0].LastName
+	case 2:
+		return creators[0].LastName + " and " + creators[1].LastName
+	default:
+		return creators[0].LastName + " et al."
+	}
+}
@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+)
+
+import (
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/config"
+)
+
+func TestPrecomputeRecordJSON(t *testing.T) {
+
+	st := Open(&config.StorageConfiguration{DBFilename: ":memory:"})
+	defer st.Close()
+
+	st.CreateTestPublications()
+
+	// Perform test #1: duplicate subjects in the precomputed JSON of some test records are detected as drift,
+	// since each subject is linked only once to a record
+
+	drifts, err := st.CheckRecordJSON()
+	if err != nil {
+		t.Errorf("Unexpected error. %s", err)
+		return
+	}
+
+	if len(drifts) != 23 {
+		t.Errorf("Expected %d drifted records but got %d.", 23, len(drifts))
+		return
+	}
+
+	// Perform test #2: regenerating the JSON of all records resolves the drift
+
+	count, err := st.PrecomputeAllRecordJSON()
+	if err != nil {
+		t.Errorf("Unexpected error. %s", err)
+		return
+	}
+
+	if count != 175 {
+		t.Errorf("Expected %d regenerated records but got %d.", 175, count)
+		return
+	}
+
+	drifts, err = st.CheckRecordJSON()
+	if err != nil {
+		t.Errorf("Unexpected error. %s", err)
+		return
+	}
+
+	if len(drifts) != 0 {
+		t.Errorf("Expected no drifted records after regeneration but got %+v.", drifts)
+		return
+	}
+
+	var bookmark string
+
+	st.db.QueryRow("SELECT json_bookmark FROM record ORDER BY id LIMIT 1").Scan(&bookmark)
+
+	if !strings.HasSuffix(bookmark, `"visited":`+bookmarkSuffix) {
+		t.Errorf("Expected precomputed bookmark to end with the visited flag and collection IDs but got '%s'.", bookmark)
+		return
+	}
+
+	// Perform test #3: changes of the relational data and broken suffixes are detected
+
+	var recordId int64
+
+	st.db.QueryRow("SELECT MIN(id) FROM record").Scan(&recordId)
+	st.db.Exec("UPDATE record SET title='Changed title' WHERE id=?", recordId)
+	st.db.Exec("UPDATE record SET json_preview=REPLACE(json_preview,'\"visited\":false','\"visited\":true') WHERE id>?", recordId)
+
+	drifts, err = st.CheckRecordJSON()
+	if err != nil {
+		t.Errorf("Unexpected error. %s", err)
+		return
+	}
+
+	if len(drifts) != 175 {
+		t.Errorf("Expected %d drifted records but got %d.", 175, len(drifts))
+		return
+	}
+
+	if len(drifts[0].Columns) != 3 || len(drifts[1].Columns) != 1 || drifts[1].Columns[0] != "json_preview" {
+		t.Errorf("Unexpected drifted columns %v and %v.", drifts[0].Columns, drifts[1].Columns)
+		return
+	}
+
+	// Perform test #4: regenerating the JSON of a single record resolves its drift
+
+	if err := st.PrecomputeRecordJSON(recordId); err != nil {
+		t.Errorf("Unexpected error. %s", err)
+		return
+	}
+
+	drifts, err = st.CheckRecordJSON()
+	if err != nil {
+		t.Errorf("Unexpected error. %s", err)
+		return
+	}
+
+	if len(drifts) != 174 || drifts[0].RecordId == recordId {
+		t.Errorf("Expected %d drifted records but got %d.", 174, len(drifts))
+		return
+	}
+}
@@ -0,0 +1,246 @@
+package storage
+
+import (
+	"log"
+	"math"
+)
+
+// Signals, that a user's interactions with a record provide for the user's preference model. Positive signals
+// increase the affinity to the record's subjects and authors, while negative signals decrease it.
+const (
+	bookmarkSignal   = 1.0
+	relevantSignal   = 1.0  // feedback rating the record as relevant
+	irrelevantSignal = -1.0 // feedback rating the record as not relevant
+	visitSignal      = 0.25
+	dislikeSignal    = -1.0
+)
+
+// Maximum number of records, whose subjects and authors are read with a single query (SQLite limits the number of
+// variables per statement).
+const recordLinkChunkSize = 500
+
+// interaction is an implicit signal, that a user has provided by interacting with a record.
+type interaction struct {
+	recordId int64
+	signal   float64
+}
+
+// subjectLink relates a record to one of its subjects.
+type subjectLink struct {
+	subjectId int64
+	weight    float64
+}
+
+// recordLinks holds the subjects and the authors (experts) of records.
+type recordLinks struct {
+	subjects map[int64][]subjectLink
+	experts  map[int64][]int64
+}
+
+// preferenceModel holds a user's affinities (-1..1) to subjects and authors (experts), that are learned from the
+// user's interactions with records.
+type preferenceModel struct {
+	subjects map[int64]float64
+	experts  map[int64]float64
+}
+
+// empty returns true if the model holds no affinities, e.g. because the user has not interacted with any record yet.
+func (m *preferenceModel) empty() bool {
+	return len(m.subjects) == 0 && len(m.experts) == 0
+}
+
+// score returns the preference score (-2..2) of a record, i.e. the mean affinity to its subjects weighted by the
+// subject links, plus the mean affinity to its authors.
+func (m *preferenceModel) score(subjects []subjectLink, experts []int64) (score float64) {
+
+	var affinity, weights float64
+
+	for _, s := range subjects {
+		affinity += s.weight * m.subjects[s.subjectId]
+		weights += s.weight
+	}
+
+	if weights > 0 {
+		score += affinity / weights
+	}
+
+	if len(experts) > 0 {
+
+		affinity = 0
+
+		for _, e := range experts {
+			affinity += m.experts[e]
+		}
+
+		score += affinity / float64(len(experts))
+	}
+
+	return
+}
+
+// readInteractions returns all of a user's interactions with records, i.e. visits, bookmarks, feedback and dislikes.
+func readInteractions(q queryer, uid int64) (interactions []interaction, err error) {
+
+	const query = `
+		SELECT record_id, ? FROM record_visit WHERE user_id=?
+		UNION ALL
+		SELECT DISTINCT record_id, ? FROM record_bookmark WHERE user_id=?
+		UNION ALL
+		SELECT record_id, CASE WHEN relevance=1 THEN ? ELSE ? END FROM feedback WHERE user_id=?
+		UNION ALL
+		SELECT record_id, ? FROM record_dislike WHERE user_id=?`
+
+	rows, err := q.Query(query, visitSignal, uid, bookmarkSignal, uid, relevantSignal, irrelevantSignal, uid, dislikeSignal, uid)
+	if err != nil {
+		log.Printf("Database error. Could not read interactions of user. %s", err)
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+
+		var i interaction
+
+		if err = rows.Scan(&i.recordId, &i.signal); err != nil {
+			log.Printf("Database error. Scanning interactions of user failed. %s", err)
+			return
+		}
+
+		interactions = append(interactions, i)
+	}
+
+	return
+}
+
+// readRecordLinks returns the subjects and the authors (experts) of the specified records.
+func readRecordLinks(q queryer, recordIds []int64) (links recordLinks, err error) {
+
+	links = recordLinks{subjects: make(map[int64][]subjectLink), experts: make(map[int64][]int64)}
+
+	for start := 0; start < len(recordIds); start += recordLinkChunkSize {
+
+		end := start + recordLinkChunkSize
+		if end > len(recordIds) {
+			end = len(recordIds)
+		}
+
+		args := make([]interface{}, end-start)
+		for i, id := range recordIds[start:end] {
+			args[i] = id
+		}
+
+		subjectQuery := "SELECT record_id, subject_id, weight FROM record_subject_link WHERE record_id IN (" + placeholders(len(args)) + ")"
+		expertQuery := "SELECT DISTINCT record_id, expert_id FROM creator WHERE expert_id IS NOT NULL AND record_id IN (" + placeholders(len(args)) + ")"
+
+		rows, err := q.Query(subjectQuery, args...)
+		if err != nil {
+			log.Printf("Database error. Could not read subjects of records. %s", err)
+			return links, err
+		}
+
+		for rows.Next() {
+
+			var recordId int64
+			var s subjectLink
+
+			if err = rows.Scan(&recordId, &s.subjectId, &s.weight); err != nil {
+				rows.Close()
+				log.Printf("Database error. Scanning subjects of records failed. %s", err)
+				return links, err
+			}
+
+			links.subjects[recordId] = append(links.subjects[recordId], s)
+		}
+		rows.Close()
+
+		rows, err = q.Query(expertQuery, args...)
+		if err != nil {
+			log.Printf("Database error. Could not read experts of records. %s", err)
+			return links, err
+		}
+
+		for rows.Next() {
+
+			var recordId, expertId int64
+
+			if err = rows.Scan(&recordId, &expertId); err != nil {
+				rows.Close()
+				log.Printf("Database error. Scanning experts of records failed. %s", err)
+				return links, err
+			}
+
+			links.experts[recordId] = append(links.experts[recordId], expertId)
+		}
+		rows.Close()
+	}
+
+	return
+}
+
+// learnPreferences learns a preference model from a user's interactions. The signal of each interaction with a record
+// is distributed to the record's subjects (according to the subject links' weights) and authors.
+// Finally, each affinity is divided by the total strength of its signals, but at least by 1, so that contradicting
+// signals cancel each other and weak signals (e.g. a single visit) remain weak.
+func learnPreferences(q queryer, interactions []interaction) (model preferenceModel, err error) {
+
+	model = preferenceModel{subjects: make(map[int64]float64), experts: make(map[int64]float64)}
+
+	var recordIds []int64
+	seen := make(map[int64]bool)
+
+	for _, i := range interactions {
+		if !seen[i.recordId] {
+			seen[i.recordId] = true
+			recordIds = append(recordIds, i.recordId)
+		}
+	}
+
+	links, err := readRecordLinks(q, recordIds)
+	if err != nil {
+		return
+	}
+
+	subjectStrengths := make(map[int64]float64)
+	expertStrengths := make(map[int64]float64)
+
+	for _, i := range interactions {
+
+		for _, s := range links.subjects[i.recordId] {
+			model.subjects[s.subjectId] += i.signal * s.weight
+			subjectStrengths[s.subjectId] += math.Abs(i.signal) * s.weight
+		}
+
+		for _, e := range links.experts[i.recordId] {
+			model.experts[e] += i.signal
+			expertStrengths[e] += math.Abs(i.signal)
+		}
+	}
+
+	normalizeAffinities(model.subjects, subjectStrengths)
+	normalizeAffinities(model.experts, expertStrengths)
+
+	return
+}
+
+// normalizeAffinities divides the affinities by the strengths of their signals (at least 1) and removes neutral ones.
+func normalizeAffinities(affinities map[int64]float64, strengths map[int64]float64) {
+
+	for id, a := range affinities {
+		if a == 0 {
+			delete(affinities, id)
+			continue
+		}
+		affinities[id] = a / math.Max(1, strengths[id])
+	}
+}
+
+// readPreferenceModel learns the preference model of a user from all the user's interactions with records.
+func readPreferenceModel(q queryer, uid int64) (model preferenceModel, err error) {
+
+	interactions, err := readInteractions(q, uid)
+	if err != nil {
+		return
+	}
+
+	return learnPreferences(q, interactions)
+}
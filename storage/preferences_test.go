@@ -0,0 +1,160 @@
+package storage
+
+import (
+	"testing"
+)
+
+import (
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/config"
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/model"
+)
+
+func TestPreferenceModel(t *testing.T) {
+
+	st := Open(&config.StorageConfiguration{DBFilename: ":memory:"})
+	defer st.Close()
+
+	st.CreateTestPublications()
+
+	user := model.User{GUID: "preference-test", HashedSecret: "-"}
+	st.CreateUser(&user)
+
+	var subjectId int64
+	st.db.QueryRow("SELECT id FROM subject WHERE keyword='Financial Economics'").Scan(&subjectId)
+	st.CreateInterest(user.Id, subjectId)
+
+	// Find a record in the feed, whose author has written another record in the feed.
+
+	const query = `
+		SELECT c1.record_id, c2.record_id, c1.expert_id
+		FROM creator AS c1, creator AS c2, record_feed AS f1, record_feed AS f2
+		WHERE c1.expert_id=c2.expert_id AND c1.record_id<c2.record_id
+			AND f1.user_id=? AND f1.record_id=c1.record_id
+			AND f2.user_id=? AND f2.record_id=c2.record_id
+		LIMIT 1`
+
+	var bookmarkedId, relatedId, expertId int64
+
+	if err := st.db.QueryRow(query, user.Id, user.Id).Scan(&bookmarkedId, &relatedId, &expertId); err != nil {
+		t.Errorf("Expected records of the same author in feed. %s", err)
+		return
+	}
+
+	// Perform test #1: no interactions, no preferences
+
+	prefs, err := readPreferenceModel(st.db, user.Id)
+	if err != nil || !prefs.empty() {
+		t.Errorf("Expected empty preference model, but got %v. %v", prefs, err)
+		return
+	}
+
+	var recordScore, expertScore float64
+	st.db.QueryRow("SELECT score FROM record_feed WHERE user_id=? AND record_id=?", user.Id, relatedId).Scan(&recordScore)
+	st.db.QueryRow("SELECT score FROM expert_feed WHERE user_id=? AND expert_id=?", user.Id, expertId).Scan(&expertScore)
+
+	// Perform test #2: bookmarks increase the affinity to the record's subjects and authors
+
+	st.CreateRecordBookmark(user.Id, bookmarkedId)
+
+	prefs, err = readPreferenceModel(st.db, user.Id)
+	if err != nil || prefs.experts[expertId] != 1 {
+		t.Errorf("Expected affinity %f to author, but got %f. %v", 1.0, prefs.experts[expertId], err)
+		return
+	}
+
+	for id, a := range prefs.subjects {
+		if a <= 0 || a > 1 {
+			t.Errorf("Expected normalized positive affinity to subject %d, but got %f.", id, a)
+			return
+		}
+	}
+
+	// Perform test #3: related records and experts are ranked higher
+
+	var newRecordScore, newExpertScore float64
+	st.db.QueryRow("SELECT score FROM record_feed WHERE user_id=? AND record_id=?", user.Id, relatedId).Scan(&newRecordScore)
+	st.db.QueryRow("SELECT score FROM expert_feed WHERE user_id=? AND expert_id=?", user.Id, expertId).Scan(&newExpertScore)
+
+	if newRecordScore <= recordScore || newExpertScore <= expertScore {
+		t.Errorf("Expected higher scores for record and expert of the same author, but got %f -> %f and %f -> %f.", recordScore, newRecordScore, expertScore, newExpertScore)
+		return
+	}
+
+	// Perform test #4: contradicting signals cancel each other
+
+	st.ReadRecordDetails(user.Id, relatedId)
+	st.CreateRecordDislike(user.Id, relatedId)
+
+	prefs, _ = readPreferenceModel(st.db, user.Id)
+
+	if prefs.experts[expertId] >= 1 {
+		t.Errorf("Expected lower affinity to author after dislike, but got %f.", prefs.experts[expertId])
+		return
+	}
+}
+
+func TestEvaluateRanking(t *testing.T) {
+
+	st := Open(&config.StorageConfiguration{DBFilename: ":memory:"})
+	defer st.Close()
+
+	st.CreateTestPublications()
+
+	user := model.User{GUID: "evaluation-test", HashedSecret: "-"}
+	st.CreateUser(&user)
+
+	var subjectId int64
+	st.db.QueryRow("SELECT id FROM subject WHERE keyword='Financial Economics'").Scan(&subjectId)
+	st.CreateInterest(user.Id, subjectId)
+
+	// Perform test #1: users without relevant records are skipped
+
+	if eval, err := st.EvaluateRanking(10, 0.2); err != nil || eval.Users != 0 {
+		t.Errorf("Expected no evaluated users, but got %d. %v", eval.Users, err)
+		return
+	}
+
+	// Perform test #2: bookmarked records are hidden and searched for in the ranked feed
+
+	rows, _ := st.db.Query("SELECT record_id FROM record_feed WHERE user_id=? ORDER BY score DESC LIMIT 5", user.Id)
+
+	var recordIds []int64
+
+	for rows.Next() {
+		var recordId int64
+		rows.Scan(&recordId)
+		recordIds = append(recordIds, recordId)
+	}
+	rows.Close()
+
+	for _, recordId := range recordIds {
+		st.CreateRecordBookmark(user.Id, recordId)
+	}
+
+	var feedCount int64
+	st.db.QueryRow("SELECT COUNT(*) FROM record_feed WHERE user_id=?", user.Id).Scan(&feedCount)
+
+	eval, err := st.EvaluateRanking(feedCount, 0.2)
+	if err != nil {
+		t.Errorf("Unexpected error. %s", err)
+		return
+	}
+
+	// All records of the feed are evaluated, so the hidden record is always found.
+	expected := 1 / float64(feedCount)
+
+	if eval.Users != 1 || eval.K != feedCount || eval.Precision != expected || eval.BaselinePrecision != expected {
+		t.Errorf("Expected one user with precision %f, but got %v.", expected, eval)
+		return
+	}
+
+	// Perform test #3: the database is not modified
+
+	var bookmarkCount int64
+	st.db.QueryRow("SELECT COUNT(*) FROM record_bookmark WHERE user_id=?", user.Id).Scan(&bookmarkCount)
+
+	if bookmarkCount != int64(len(recordIds)) {
+		t.Errorf("Expected %d bookmarks but got %d.", len(recordIds), bookmarkCount)
+		return
+	}
+}
@@ -3,6 +3,7 @@ package storage
 import (
 	"database/sql"
 	"encoding/json"
+	"fmt"
 	"log"
 	"strings"
 )
@@ -13,7 +14,8 @@ import (
 	_ "github.com/mattn/go-sqlite3"
 )
 
-// BuildSearchIndicies repopulates the search indices for publication and experts.
+// BuildSearchIndicies repopulates the search indices for publication and experts, the suggestion index and the index
+// for finding related records.
 // Must be called after new records were added to the database.
 func (st *Storage) BuildSearchIndicies() (err error) {
 
@@ -64,7 +66,11 @@ func (st *Storage) BuildSearchIndicies() (err error) {
 		return
 	}
 
-	return
+	if err = st.BuildSuggestionIndex(); err != nil {
+		return
+	}
+
+	return st.BuildRelatedRecordIndex()
 }
 
 // CreateCollection creates a new named bookmark collection.
@@ -99,10 +105,10 @@ func (st *Storage) CreateExpertBookmark(uid int64, expertId int64) (err error) {
 	return
 }
 
-// CreateExpertProfile registers an user that has an ORCiD as an expert.
+// CreateExpertProfile registers an user that has an ORCiD as an expert. The ORCiD is not verified (see VerifyOrcId).
 func (st *Storage) CreateExpertProfile(uid int64, orcId string) (err error) {
 
-	_, err = st.db.Exec("UPDATE user SET orcid=? WHERE id=?", orcId, uid)
+	_, err = st.db.Exec("UPDATE user SET orcid=?, orcid_verified=0, orcid_name=NULL WHERE id=?", orcId, uid)
 	if err != nil {
 		log.Printf("Database error. Could not update ORCiD for user %d. %s", uid, err)
 		return
@@ -112,17 +118,48 @@ func (st *Storage) CreateExpertProfile(uid int64, orcId string) (err error) {
 }
 
 // CreateFeedback adds a user's feedback to a record. The feedback consists of binary flags for relevance,
-// quality of presentation and the soundness of the methodology (0 = false, 1 = true).
+// quality of presentation and the soundness of the methodology (0 = false, 1 = true). The feedback is stored under the
+// canonical form of the user's ORCiD. Returns an error if the user has no valid ORCiD.
 func (st *Storage) CreateFeedback(uid int64, recordId int64, relevance int64, presentation int64, methodology int64) (err error) {
 
-	q := "INSERT OR IGNORE INTO feedback (user_id,record_id,orcid,relevance,presentation,methodology) VALUES(?,?,(SELECT orcid FROM user WHERE id=?),?,?,?)"
+	q := "INSERT OR IGNORE INTO feedback (user_id,record_id,orcid,relevance,presentation,methodology) VALUES(?,?,?,?,?,?)"
+
+	tx, err := st.db.Begin()
+	if err != nil {
+		log.Printf("Database error. Could not initialize transaction for creating feedback. %s", err)
+		return
+	}
+
+	var rawOrcId sql.NullString
 
-	_, err = st.db.Exec(q, uid, recordId, uid, relevance, presentation, methodology)
+	if err = tx.QueryRow("SELECT orcid FROM user WHERE id=?", uid).Scan(&rawOrcId); err != nil {
+		tx.Rollback()
+		log.Printf("Database error. Could not read ORCiD of user for feedback. %s", err)
+		return
+	}
+
+	orcId, err := model.ParseOrcID(NullToString(rawOrcId))
 	if err != nil {
+		tx.Rollback()
+		log.Printf("Could not insert feedback. %s", err)
+		return
+	}
+
+	_, err = tx.Exec(q, uid, recordId, orcId.String(), relevance, presentation, methodology)
+	if err != nil {
+		tx.Rollback()
 		log.Printf("Database error. Could not insert feedback. %s", err)
 		return
 	}
 
+	// The relevance rating is a signal for the user's preferences.
+	st.rebuildExpertAndRecordFeed(tx, uid)
+
+	err = tx.Commit()
+	if err != nil {
+		log.Printf("Database error. Could not commit transaction for creating feedback. %s", err)
+	}
+
 	return
 }
 
@@ -153,12 +190,27 @@ func (st *Storage) CreateInterest(uid int64, subjectId int64) (err error) {
 // The user-ID specifies the user to which bookmark is related.
 func (st *Storage) CreateRecordBookmark(uid int64, recordId int64) (err error) {
 
-	_, err = st.db.Exec("INSERT OR IGNORE INTO record_bookmark (user_id,record_id) VALUES(?,?)", uid, recordId)
+	tx, err := st.db.Begin()
 	if err != nil {
+		log.Printf("Database error. Could not initialize transaction for bookmarking a record. %s", err)
+		return
+	}
+
+	_, err = tx.Exec("INSERT OR IGNORE INTO record_bookmark (user_id,record_id) VALUES(?,?)", uid, recordId)
+	if err != nil {
+		tx.Rollback()
 		log.Printf("Database error. Could not insert record bookmark. %s", err)
 		return
 	}
 
+	// Bookmarks are a signal for the user's preferences.
+	st.rebuildExpertAndRecordFeed(tx, uid)
+
+	err = tx.Commit()
+	if err != nil {
+		log.Printf("Database error. Could not commit transaction for bookmarking a record. %s", err)
+	}
+
 	return
 }
 
@@ -174,7 +226,9 @@ func (st *Storage) CreateRecordDislike(uid int64, recordId int64) (err error) {
 	}
 
 	tx.Exec("INSERT OR IGNORE INTO record_dislike (user_id,record_id) VALUES(?,?)", uid, recordId)
-	tx.Exec("DELETE FROM record_feed WHERE user_id=? AND record_id=?", uid, recordId)
+
+	// Records and experts that share subjects or authors with the disliked record are penalized.
+	st.rebuildExpertAndRecordFeed(tx, uid)
 
 	err = tx.Commit()
 
@@ -188,11 +242,22 @@ func (st *Storage) CreateRecordDislike(uid int64, recordId int64) (err error) {
 
 // CreateUser registers a new user by creating a new user-ID.
 // Access to profile is controlled by a user-specified, secret passphrase, or its hash in specific.
+// Users without role are created as regular users.
 func (st *Storage) CreateUser(u *model.User) (err error) {
+	return insertUser(st.db, u)
+}
+
+// insertUser inserts a new user and sets the user's ID.
+func insertUser(q queryer, u *model.User) (err error) {
+
+	if u.Role == "" {
+		u.Role = model.RoleUser
+	}
 
-	result, err := st.db.Exec("INSERT INTO user (guid,hashed_secret) VALUES(?,?);",
+	result, err := q.Exec("INSERT INTO user (guid,hashed_secret,role) VALUES(?,?,?);",
 		u.GUID,
-		u.HashedSecret)
+		u.HashedSecret,
+		u.Role)
 
 	if err != nil {
 		log.Printf("Database error. Could not insert new user into database. %s", err)
@@ -280,7 +345,7 @@ func (st *Storage) DeleteInterest(uid int64, subjectId int64) (err error) {
 // DeleteOrcId withdraws a user's expert status by removing its ORCiD identity.
 func (st *Storage) DeleteOrcId(uid int64) (err error) {
 
-	_, err = st.db.Exec("UPDATE user SET orcid=NULL WHERE id=?", uid)
+	_, err = st.db.Exec("UPDATE user SET orcid=NULL, orcid_verified=0, orcid_name=NULL WHERE id=?", uid)
 
 	if err != nil {
 		log.Printf("Database error. Could not delete ORCiD for user %d. %s", uid, err)
@@ -324,6 +389,28 @@ func (st *Storage) DeleteUserById(uid int64) (err error) {
 	tx.Exec("DELETE FROM feedback WHERE user_id=?", uid)
 	tx.Exec("DELETE FROM record_dislike WHERE user_id=?", uid)
 	tx.Exec("DELETE FROM record_visit WHERE user_id=?", uid)
+	tx.Exec("DELETE FROM feed_token WHERE user_id=?", uid)
+	tx.Exec("DELETE FROM session WHERE user_id=?", uid)
+	tx.Exec("DELETE FROM recovery_code WHERE user_id=?", uid)
+	tx.Exec("DELETE FROM orcid_authorization WHERE user_id=?", uid)
+
+	// Experts claimed by the user keep their ORCiD, but are no longer marked as claimed.
+	claimedIds, err := readIds(tx, "SELECT expert_id FROM expert_claim WHERE user_id=? AND status=?", uid, ExpertClaimApproved)
+	if err != nil {
+		tx.Rollback()
+		log.Printf("Database error. Could not read experts claimed by user %d. %s", uid, err)
+		return
+	}
+
+	tx.Exec("DELETE FROM expert_claim WHERE user_id=?", uid)
+	for _, expertId := range claimedIds {
+		if err = precomputeExpertJSON(tx, expertId); err != nil {
+			tx.Rollback()
+			log.Printf("Database error. Could not update expert %d claimed by user %d. %s", expertId, uid, err)
+			return
+		}
+	}
+
 	tx.Exec("DELETE FROM user WHERE id=?", uid)
 
 	err = tx.Commit()
@@ -378,6 +465,54 @@ func (st *Storage) ReadBibHashByRecordId(recordId int64) (bibHash string, err er
 	return
 }
 
+// ReadBookmarkedRecords returns the full bibliographic metadata of all records that were bookmarked by a user, e.g. for
+// exporting them to a reference manager. If a collection ID other than 0 is specified, only the records in that
+// collection are returned. Records are ordered by the time of bookmarking, latest first.
+func (st *Storage) ReadBookmarkedRecords(uid int64, collectionId int64) (records []model.Record, err error) {
+
+	const query = `
+		SELECT record_id
+		FROM record_bookmark
+		WHERE user_id=? AND (?=0 OR collection_id=?)
+		GROUP BY record_id
+		ORDER BY MIN(rowid) DESC`
+
+	rows, err := st.db.Query(query, uid, collectionId, collectionId)
+	if err != nil {
+		log.Printf("Database error. Could not read bookmarked records. %s", err)
+		return
+	}
+
+	var recordIds []int64
+
+	for rows.Next() {
+
+		var recordId int64
+
+		if err = rows.Scan(&recordId); err != nil {
+			rows.Close()
+			log.Printf("Database error. Scanning IDs of bookmarked records failed. %s", err)
+			return
+		}
+
+		recordIds = append(recordIds, recordId)
+	}
+	rows.Close()
+
+	for _, recordId := range recordIds {
+
+		rec, err := readRecord(st.db, recordId)
+		if err != nil {
+			log.Printf("Database error. Could not read bookmarked record with ID %d. %s", recordId, err)
+			return nil, err
+		}
+
+		records = append(records, rec)
+	}
+
+	return records, nil
+}
+
 // ReadCollections returns all the bookmark collections without the records for a user.
 func (st *Storage) ReadCollections(uid int64) (collections ploc.Collections, err error) {
 
@@ -455,18 +590,19 @@ func (st *Storage) ReadExpertDetails(expertId int64) (rawDetails json.RawMessage
 }
 
 // ReadExpertFeed returns the specified subsegment of a list with popular experts that match a user's interest.
-// The experts' short profiles are returned as a precomputed JSON data structure for performance reasons.
+// The experts' short profiles are returned as a precomputed JSON data structure for performance reasons, that is
+// extended by the field "score" (see RankingConfiguration).
 // The list is descendingly ordered by the relevance of the experts. The returned list depend on the user's defined interests and dislikes.
 // Access to the full list is handled in subsegments via offset and limit, so that a client can read only the segments that are shown to the user.
 func (st *Storage) ReadExpertFeed(uid int64, offset int64, limit int64) (rawExperts []json.RawMessage, err error) {
 
 	const query = `
-		SELECT e.json_preview 
+		SELECT e.json_preview, f.score
 		FROM expert AS e, expert_feed AS f
 		WHERE f.user_id=?
 			AND f.expert_id=e.id 
 			AND f.expert_id NOT IN (SELECT expert_id FROM expert_bookmark WHERE user_id=?)
-		ORDER BY f.rowid ASC
+		ORDER BY f.score DESC, f.rowid ASC
 		LIMIT ?
 		OFFSET ?`
 
@@ -480,14 +616,15 @@ func (st *Storage) ReadExpertFeed(uid int64, offset int64, limit int64) (rawExpe
 	for rows.Next() {
 
 		var rawExpert string
+		var score float64
 
-		err = rows.Scan(&rawExpert)
+		err = rows.Scan(&rawExpert, &score)
 		if err != nil {
 			log.Printf("Database error. Scanning raw JSON expert failed. %s", err)
 			return
 		}
 
-		rawExperts = append(rawExperts, json.RawMessage(rawExpert))
+		rawExperts = append(rawExperts, json.RawMessage(strings.TrimSuffix(rawExpert, "}")+fmt.Sprintf(`,"score":%g}`, score)))
 	}
 
 	return
@@ -571,12 +708,13 @@ func (st *Storage) ReadFeedbackFeed(uid int64, offset int64, limit int64) (rawRe
 	return
 }
 
-// ReadOrcId returns a user's associated ORCiD identifiert, which is empty in the case the user has none.
-func (st *Storage) ReadOrcId(uid int64) (orcId string, err error) {
+// ReadOrcId returns a user's associated ORCiD identifiert, which is empty in the case the user has none, and whether
+// the user has verified the ORCiD.
+func (st *Storage) ReadOrcId(uid int64) (orcId string, verified bool, err error) {
 
 	var nullableOrcId sql.NullString
 
-	err = st.db.QueryRow(`SELECT orcid FROM user WHERE id=?`, uid).Scan(&nullableOrcId)
+	err = st.db.QueryRow(`SELECT orcid,orcid_verified FROM user WHERE id=?`, uid).Scan(&nullableOrcId, &verified)
 	switch {
 	case err == sql.ErrNoRows:
 		log.Printf("Database error. Could not read ORCiD of user. User with ID %d seems not to exist.", uid)
@@ -584,7 +722,7 @@ func (st *Storage) ReadOrcId(uid int64) (orcId string, err error) {
 		log.Printf("Database error. Could not read ORCiD of user with ID %d. %s", uid, err)
 	}
 
-	return NullToString(nullableOrcId), err
+	return NullToString(nullableOrcId), verified, err
 }
 
 // ReadRecordBookmarks returns all records that were bookmarked by a user.
@@ -628,7 +766,7 @@ func (st *Storage) ReadRecordBookmarks(uid int64) (rawBookmarks []json.RawMessag
 			inFix = "true,\"collection_ids\":["
 		}
 
-		rightTrimmedRawBookmark := rawBookmark[0 : len(rawBookmark)-26] // 26 == len(`false,"collection_ids":[]}`)
+		rightTrimmedRawBookmark := rawBookmark[0 : len(rawBookmark)-len(bookmarkSuffix)]
 
 		rawBookmarks = append(rawBookmarks, json.RawMessage(rightTrimmedRawBookmark+inFix+collectionIds+"]}"))
 	}
@@ -684,25 +822,31 @@ func (st *Storage) ReadRecordFeedCount(uid int64) (recordCount int64, err error)
 }
 
 // ReadRecordFeed returns a list of publications that match the user's subjects of interest.
-// The list is descendingly ordered by year of publication and within that year by relevance.
+// The list is descendingly ordered by the records' scores (see RankingConfiguration).
 // The list is accessed segment-wise, so that a client can read only that segment that is shown to the user and not the whole list.
 // The publications are returned as a precomputed JSON data structure for performance reasons.
-func (st *Storage) ReadRecordFeed(uid int64, offset int64, limit int64) (rawRecords []json.RawMessage, err error) {
+// The optional filter (may be nil) restricts the feed to records with specific years, types, open access state or subjects.
+func (st *Storage) ReadRecordFeed(uid int64, filter *ploc.RecordFeedFilter, offset int64, limit int64) (rawRecords []json.RawMessage, err error) {
+
+	condition, conditionArgs := recordFilterCondition(filter)
 
 	// Query needs to exclude bookmarked records (NOT IN), and must determine each record's visited status (LEFT JOIN).
 	// TODO: Check performance of left join to determine visited status
-	const query = `
+	query := `
 		 SELECT RTRIM(f.json_preview,'false}'), f.record_id=IFNULL(v.record_id,0)
 		 FROM (SELECT r.id AS record_id, r.json_preview AS json_preview 
 		 		FROM record AS r, record_feed AS f
 		 		WHERE f.user_id=?
 		 			AND f.record_id=r.id 
-		 			AND f.record_id NOT IN (SELECT record_id FROM record_bookmark WHERE user_id=?) 
-		 		ORDER BY f.rowid ASC LIMIT ? OFFSET ?) AS f
+		 			AND f.record_id NOT IN (SELECT record_id FROM record_bookmark WHERE user_id=?)` + condition + `
+		 		ORDER BY f.score DESC, f.rowid ASC LIMIT ? OFFSET ?) AS f
 		 LEFT JOIN (SELECT record_id FROM record_visit WHERE user_id=?) AS v 
 		 ON f.record_id=v.record_id`
 
-	rows, err := st.db.Query(query, uid, uid, limit, offset, uid)
+	args := append([]interface{}{uid, uid}, conditionArgs...)
+	args = append(args, limit, offset, uid)
+
+	rows, err := st.db.Query(query, args...)
 	if err != nil {
 		log.Printf("Database error. Querying record feed failed. %s", err)
 		return
@@ -766,77 +910,89 @@ func (st *Storage) ReadUserInterests(uid int64) (subjects ploc.Subjects, err err
 }
 
 // rebuildExpertAndRecordFeed precomputes the record and expert feed for the specified user, based on the subjects
-// the user has specified as interesting. The feeds are precomputed because of performance reasons. The function
-// needs to be called each time the subjects of interest change or if new records are added to the database.
+// the user has specified as interesting and the preferences learned from the user's interactions (see preferenceModel).
+// The feeds are precomputed because of performance reasons. The function needs to be called each time the subjects of
+// interest change, the user bookmarks, rates or dislikes a record, or if new records are added to the database.
+// Visits are weak signals and take effect with the next rebuild only.
 func (st *Storage) rebuildExpertAndRecordFeed(tx *sql.Tx, uid int64) {
 
-	const createUserExpertFeed = `
-		INSERT OR IGNORE INTO expert_feed (user_id,expert_id)
-			SELECT DISTINCT ?,esl.expert_id 
-			FROM interest AS i, expert_subject_link AS esl 
-			WHERE i.user_id=? AND i.subject_id=esl.subject_id 
-			GROUP by esl.expert_id
-			ORDER BY SUM(esl.record_count) DESC`
-
-	const createUserRecordFeed = `
-		INSERT OR IGNORE INTO record_feed (user_id,record_id)
-		SELECT DISTINCT ?,rsl.record_id 
-		FROM record AS r, interest AS i, record_subject_link AS rsl
-		WHERE i.user_id=?
-			AND i.subject_id=rsl.subject_id
-			AND rsl.record_id NOT IN
-				(SELECT record_id FROM record_dislike WHERE user_id=?)
-			AND r.id=rsl.record_id
-		ORDER BY r.year DESC;`
-
-	tx.Exec("DELETE FROM expert_feed WHERE user_id=?;", uid)
-	tx.Exec(createUserExpertFeed, uid, uid)
-	tx.Exec("DELETE FROM record_feed WHERE user_id=?;", uid)
-	tx.Exec(createUserRecordFeed, uid, uid, uid)
+	feedbackCounts, err := st.readExpertFeedbackCounts(tx)
+	if err != nil {
+		log.Printf("Database error. Expert feed of user %d is ranked without feedback. %s", uid, err)
+	}
+
+	st.rebuildFeedsWithFeedbackCounts(tx, uid, feedbackCounts)
+}
+
+// rebuildFeedsWithFeedbackCounts implements rebuildExpertAndRecordFeed with the feedback counts of all experts, that
+// were read beforehand (see readExpertFeedbackCounts).
+func (st *Storage) rebuildFeedsWithFeedbackCounts(tx *sql.Tx, uid int64, feedbackCounts map[int64]int64) {
+
+	// Without a preference model, the feeds are still ranked by the user's interests.
+	prefs, err := readPreferenceModel(tx, uid)
+	if err != nil {
+		prefs = preferenceModel{}
+	}
+
+	st.rebuildExpertFeed(tx, uid, &prefs, feedbackCounts)
+	st.rebuildRecordFeed(tx, uid, &prefs)
 
 	return
 }
 
 // SearchExpertFeed makes a full text search within a user's expert feed and returns a summary for all the
 // experts with matching textual content. The searched fields include name, publication titles and subjects.
-// The experts are returned as a precomputed JSON data structure for performance reasons.
-func (st *Storage) SearchExpertFeed(uid int64, searchTerm string, offset int64, limit int64) (rawExperts []json.RawMessage, err error) {
+// The experts are returned as a precomputed JSON data structure for performance reasons, ordered by relevance (BM25).
+// The highlights contain the expert's name and a snippet with the matching terms for each expert.
+// The search term supports operators, phrases, prefixes and column filters (see searchQueryParser). Column filters are
+// "name:", "titles:" and "subjects:". A *SearchQueryError is returned if the search term is malformed.
+func (st *Storage) SearchExpertFeed(uid int64, searchTerm string, offset int64, limit int64) (rawExperts []json.RawMessage, highlights ploc.SearchHighlights, err error) {
 
 	const query = `
-		SELECT json_preview 
-			FROM expert 
-			WHERE id IN 
-				(SELECT expert_id 
-					FROM vexpert 
-					WHERE expert_id IN (SELECT expert_id FROM expert_feed WHERE user_id=?) 
-						AND expert_id NOT IN (SELECT expert_id FROM expert_bookmark WHERE user_id=?) 
-						AND vexpert MATCH '- {expert_id} : ' || ?
-						LIMIT ? OFFSET ?)`
-
-	// Quote search term with leading and trailing " to include special characters in FTS.
-	// First remove leading and trailing " to avoid duplications.
-	// TODO: Remove, as it hides SQLite search query language
-
-	searchTerm = `"` + strings.Trim(searchTerm, `"`) + `"`
+		SELECT e.json_preview, s.expert_id, s.heading, s.snippet
+		FROM (SELECT expert_id,
+				highlight(vexpert, 1, ` + highlightStart + `, ` + highlightEnd + `) AS heading,
+				snippet(vexpert, -1, ` + highlightStart + `, ` + highlightEnd + `, ` + snippetEllipsis + `, ` + snippetTokens + `) AS snippet,
+				bm25(vexpert, ` + expertSearchWeights + `) AS score
+			FROM vexpert
+			WHERE expert_id IN (SELECT expert_id FROM expert_feed WHERE user_id=?)
+				AND expert_id NOT IN (SELECT expert_id FROM expert_bookmark WHERE user_id=?)
+				AND vexpert MATCH '- {expert_id} : (' || ? || ')'
+			ORDER BY score ASC
+			LIMIT ? OFFSET ?) AS s, expert AS e
+		WHERE e.id=s.expert_id
+		ORDER BY s.score ASC`
+
+	// Translate the user's search query to the FTS5 query syntax.
+
+	ftsQuery, err := parseSearchQuery(searchTerm, expertSearchColumns)
+	if err != nil {
+		log.Printf("Searching expert feed failed. %s", err)
+		return
+	}
 
-	rows, err := st.db.Query(query, uid, uid, searchTerm, limit, offset)
+	rows, err := st.db.Query(query, uid, uid, ftsQuery, limit, offset)
 	if err != nil {
 		log.Printf("Database error. Searching expert feed failed. %s", err)
 		return
 	}
 	defer rows.Close()
 
+	highlights = ploc.SearchHighlights{}
+
 	for rows.Next() {
 
 		var rawExpert string
+		var hl ploc.SearchHighlight
 
-		err = rows.Scan(&rawExpert)
+		err = rows.Scan(&rawExpert, &hl.Id, &hl.Heading, &hl.Snippet)
 		if err != nil {
 			log.Printf("Database error. Scanning raw JSON expert failed. %s", err)
 			return
 		}
 
 		rawExperts = append(rawExperts, json.RawMessage(rawExpert))
+		highlights = append(highlights, hl)
 	}
 
 	return
@@ -844,39 +1000,65 @@ func (st *Storage) SearchExpertFeed(uid int64, searchTerm string, offset int64,
 
 // SearchRecordFeed makes a full text search within a user's publication feed and returns a summary for all the
 // publication records with matching textual content. The searched fields include title, abstract, subjects and author names.
-// The publications are returned as a precomputed JSON data structure for performance reasons.
-func (st *Storage) SearchRecordFeed(uid int64, searchTerm string, offset int64, limit int64) (rawRecords []json.RawMessage, err error) {
+// The publications are returned as a precomputed JSON data structure for performance reasons, ordered by relevance (BM25).
+// The highlights contain the record's title and a snippet with the matching terms for each record.
+// The search term supports operators, phrases, prefixes and column filters (see searchQueryParser). Column filters are
+// "title:", "abstract:", "subjects:" and "authors:". A *SearchQueryError is returned if the search term is malformed.
+// The optional filter (may be nil) is applied like for reading the record feed.
+func (st *Storage) SearchRecordFeed(uid int64, searchTerm string, filter *ploc.RecordFeedFilter, offset int64, limit int64) (rawRecords []json.RawMessage, highlights ploc.SearchHighlights, err error) {
+
+	condition, conditionArgs := recordFilterCondition(filter)
+
+	var filterSubquery string
+	if condition != "" {
+		filterSubquery = "AND record_id IN (SELECT r.id FROM record AS r WHERE 1" + condition + ")"
+	}
 
-	const query = `
-		SELECT RTRIM(f.json_preview,'false}'), f.id=IFNULL(v.record_id,0)
-		FROM (SELECT id, json_preview FROM record WHERE
-			id IN (SELECT record_id FROM vrecord WHERE
-				record_id IN (SELECT record_id FROM record_feed WHERE user_id=?) 
+	query := `
+		SELECT RTRIM(r.json_preview,'false}'), r.id=IFNULL(v.record_id,0), s.record_id, s.heading, s.snippet
+		FROM (SELECT record_id,
+				highlight(vrecord, 1, ` + highlightStart + `, ` + highlightEnd + `) AS heading,
+				snippet(vrecord, -1, ` + highlightStart + `, ` + highlightEnd + `, ` + snippetEllipsis + `, ` + snippetTokens + `) AS snippet,
+				bm25(vrecord, ` + recordSearchWeights + `) AS score
+			FROM vrecord
+			WHERE record_id IN (SELECT record_id FROM record_feed WHERE user_id=?)
 				AND record_id NOT IN (SELECT record_id FROM record_bookmark WHERE user_id=?)
-			AND vrecord MATCH '- {record_id} : ' || ?
-			LIMIT ? OFFSET ?)) AS f 
-		LEFT JOIN (SELECT record_id FROM record_visit WHERE user_id=?) AS v 
-		ON f.id=v.record_id`
+				` + filterSubquery + `
+				AND vrecord MATCH '- {record_id} : (' || ? || ')'
+			ORDER BY score ASC
+			LIMIT ? OFFSET ?) AS s
+		INNER JOIN record AS r ON r.id=s.record_id
+		LEFT JOIN (SELECT record_id FROM record_visit WHERE user_id=?) AS v
+		ON r.id=v.record_id
+		ORDER BY s.score ASC`
 
-	// Quote search term with leading and trailing " to include special characters in FTS.
-	// First remove leading and trailing " to avoid duplications.
-	// TODO: Remove, as it hides SQLite search query language
+	// Translate the user's search query to the FTS5 query syntax.
 
-	searchTerm = `"` + strings.Trim(searchTerm, `"`) + `"`
+	ftsQuery, err := parseSearchQuery(searchTerm, recordSearchColumns)
+	if err != nil {
+		log.Printf("Searching record feed failed. %s", err)
+		return
+	}
 
-	rows, err := st.db.Query(query, uid, uid, searchTerm, limit, offset, uid)
+	args := append([]interface{}{uid, uid}, conditionArgs...)
+	args = append(args, ftsQuery, limit, offset, uid)
+
+	rows, err := st.db.Query(query, args...)
 	if err != nil {
 		log.Printf("Database error. Searching record feed failed. %s", err)
 		return
 	}
 	defer rows.Close()
 
+	highlights = ploc.SearchHighlights{}
+
 	for rows.Next() {
 
 		var rawRecord string
 		var visited int64
+		var hl ploc.SearchHighlight
 
-		err = rows.Scan(&rawRecord, &visited)
+		err = rows.Scan(&rawRecord, &visited, &hl.Id, &hl.Heading, &hl.Snippet)
 		if err != nil {
 			log.Printf("Database error. Scanning raw JSON records failed. %s", err)
 			return
@@ -891,6 +1073,7 @@ func (st *Storage) SearchRecordFeed(uid int64, searchTerm string, offset int64,
 		}
 
 		rawRecords = append(rawRecords, json.RawMessage(rawRecord+postFix))
+		highlights = append(highlights, hl)
 	}
 
 	return
@@ -949,13 +1132,15 @@ func (st *Storage) UpdateCollectionsBookmarkLink(uid int64, recordId int64, coll
 func (st *Storage) UserByGUID(guid string) (user *model.User, err error) {
 
 	var (
-		id           int64
-		hashedSecret string
-		orcId        sql.NullString
+		id            int64
+		hashedSecret  string
+		orcId         sql.NullString
+		orcIdVerified bool
+		role          string
 	)
 
-	err = st.db.QueryRow("SELECT id,hashed_secret,orcid FROM user WHERE guid = $1 LIMIT 1;", guid).Scan(
-		&id, &hashedSecret, &orcId)
+	err = st.db.QueryRow("SELECT id,hashed_secret,orcid,orcid_verified,role FROM user WHERE guid = $1 LIMIT 1;", guid).Scan(
+		&id, &hashedSecret, &orcId, &orcIdVerified, &role)
 
 	switch {
 	case err == sql.ErrNoRows:
@@ -965,10 +1150,12 @@ func (st *Storage) UserByGUID(guid string) (user *model.User, err error) {
 	}
 
 	u := model.User{
-		Id:           id,
-		GUID:         guid,
-		HashedSecret: hashedSecret,
-		OrcId:        NullToString(orcId),
+		Id:            id,
+		GUID:          guid,
+		HashedSecret:  hashedSecret,
+		OrcId:         NullToString(orcId),
+		OrcIdVerified: orcIdVerified,
+		Role:          role,
 	}
 
 	return &u, nil
@@ -0,0 +1,339 @@
+package storage
+
+import (
+	"database/sql"
+	"log"
+	"math"
+	"sort"
+	"time"
+)
+
+import (
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/config"
+)
+
+// scoredRecord holds the features of a record, that determine its score within a user's record feed.
+type scoredRecord struct {
+	id              int64
+	year            int64
+	matchCount      int64   // number of subjects that match the user's interests
+	subjectWeight   float64 // summed weights of the matching subjects
+	dislikedOverlap int64   // number of subjects shared with records the user disliked
+	preference      float64 // learned affinity to the record's subjects and authors (see preferenceModel)
+	score           float64
+}
+
+// scoredExpert holds the features of an expert, that determine the expert's score within a user's expert feed.
+type scoredExpert struct {
+	id                  int64
+	recordCount         int64 // number of the expert's records with subjects that match the user's interests
+	lastPublicationYear int64 // zero if unknown
+	hasOrcId            bool
+	feedbackCount       int64 // number of relevance ratings from verified experts for the expert's records
+	preference          float64
+	score               float64
+}
+
+// Strategies for ranking the experts of a user's expert feed (see RankingConfiguration).
+const (
+	ExpertRankingPublications = "publications"
+	ExpertRankingRecency      = "recency"
+	ExpertRankingFeedback     = "feedback"
+)
+
+// expertRanking computes the score of an expert within a user's expert feed, before learned preferences are added.
+type expertRanking func(conf *config.RankingConfiguration, exp *scoredExpert, currentYear int64) float64
+
+// expertRankings maps the names of the expert ranking strategies to their implementations.
+var expertRankings = map[string]expertRanking{
+	ExpertRankingPublications: publicationRanking,
+	ExpertRankingRecency:      recencyRanking,
+	ExpertRankingFeedback:     feedbackRanking,
+}
+
+// publicationRanking scores an expert by the number of records that match the user's interests.
+func publicationRanking(conf *config.RankingConfiguration, exp *scoredExpert, currentYear int64) float64 {
+	return float64(exp.recordCount)
+}
+
+// recencyRanking scores an expert by the number of records that match the user's interests, which decays by half each
+// half-life (in years) since the expert's last publication. Experts without known year of publication score zero.
+func recencyRanking(conf *config.RankingConfiguration, exp *scoredExpert, currentYear int64) float64 {
+
+	if exp.lastPublicationYear <= 0 || conf.RecencyHalfLife <= 0 {
+		return 0
+	}
+
+	age := math.Max(0, float64(currentYear-exp.lastPublicationYear))
+
+	return float64(exp.recordCount) * math.Pow(0.5, age/conf.RecencyHalfLife)
+}
+
+// feedbackRanking scores an expert by the number of records rated as relevant by verified experts, plus one if the
+// expert has an ORCiD. The number of records that match the user's interests is added with diminishing returns, so
+// that it mainly ranks experts without feedback.
+func feedbackRanking(conf *config.RankingConfiguration, exp *scoredExpert, currentYear int64) float64 {
+
+	score := float64(exp.feedbackCount) + math.Log1p(float64(exp.recordCount))
+
+	if exp.hasOrcId {
+		score++
+	}
+
+	return score
+}
+
+// interestScore computes the score of a record within a user's record feed, according to the ranking configuration.
+// Records without a year of publication receive no recency score.
+func interestScore(conf *config.RankingConfiguration, rec *scoredRecord, currentYear int64) float64 {
+
+	score := conf.MatchWeight*float64(rec.matchCount) + conf.SubjectWeight*rec.subjectWeight
+
+	if rec.year > 0 && conf.RecencyHalfLife > 0 {
+		age := math.Max(0, float64(currentYear-rec.year))
+		score += conf.RecencyWeight * math.Pow(0.5, age/conf.RecencyHalfLife)
+	}
+
+	return score + conf.PreferenceWeight*rec.preference - conf.DislikePenalty*float64(rec.dislikedOverlap)
+}
+
+// expertScore computes the score of an expert within a user's expert feed, according to the configured strategy
+// (publications per default) plus the learned preferences.
+func expertScore(conf *config.RankingConfiguration, exp *scoredExpert, currentYear int64) float64 {
+
+	ranking, ok := expertRankings[conf.ExpertStrategy]
+	if !ok {
+		ranking = publicationRanking
+	}
+
+	return ranking(conf, exp, currentYear) + conf.PreferenceWeight*exp.preference
+}
+
+// rankRecordFeed scores all records with subjects that match the user's interests, except disliked records,
+// and returns them in descending order of their scores (see interestScore).
+func (st *Storage) rankRecordFeed(q queryer, uid int64, prefs *preferenceModel) (records []scoredRecord, err error) {
+
+	const query = `
+		SELECT r.id, IFNULL(r.year,0), COUNT(*), SUM(rsl.weight),
+			(SELECT COUNT(*) FROM record_subject_link AS l
+				WHERE l.record_id=r.id
+					AND l.subject_id IN (SELECT dl.subject_id
+						FROM record_dislike AS d, record_subject_link AS dl
+						WHERE d.user_id=? AND d.record_id=dl.record_id))
+		FROM record AS r, interest AS i, record_subject_link AS rsl
+		WHERE i.user_id=?
+			AND i.subject_id=rsl.subject_id
+			AND rsl.record_id NOT IN
+				(SELECT record_id FROM record_dislike WHERE user_id=?)
+			AND r.id=rsl.record_id
+		GROUP BY r.id`
+
+	rows, err := q.Query(query, uid, uid, uid)
+	if err != nil {
+		log.Printf("Database error. Could not read records for the record feed. %s", err)
+		return
+	}
+
+	for rows.Next() {
+
+		var rec scoredRecord
+
+		if err = rows.Scan(&rec.id, &rec.year, &rec.matchCount, &rec.subjectWeight, &rec.dislikedOverlap); err != nil {
+			rows.Close()
+			log.Printf("Database error. Scanning records for the record feed failed. %s", err)
+			return
+		}
+
+		records = append(records, rec)
+	}
+	rows.Close()
+
+	// Subjects and authors of the records are only needed, if the user has any preferences.
+	if !prefs.empty() {
+
+		recordIds := make([]int64, len(records))
+		for i := range records {
+			recordIds[i] = records[i].id
+		}
+
+		links, err := readRecordLinks(q, recordIds)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range records {
+			records[i].preference = prefs.score(links.subjects[records[i].id], links.experts[records[i].id])
+		}
+	}
+
+	currentYear := int64(time.Now().Year())
+
+	for i := range records {
+		records[i].score = interestScore(&st.ranking, &records[i], currentYear)
+	}
+
+	// Newer records come first if scores are equal.
+	sort.SliceStable(records, func(i, j int) bool {
+		if records[i].score != records[j].score {
+			return records[i].score > records[j].score
+		}
+		return records[i].year > records[j].year
+	})
+
+	return
+}
+
+// rebuildRecordFeed precomputes the record feed for the specified user. Each record is stored with its score
+// (see rankRecordFeed).
+func (st *Storage) rebuildRecordFeed(tx *sql.Tx, uid int64, prefs *preferenceModel) {
+
+	records, err := st.rankRecordFeed(tx, uid, prefs)
+	if err != nil {
+		return
+	}
+
+	tx.Exec("DELETE FROM record_feed WHERE user_id=?;", uid)
+
+	stmt, err := tx.Prepare("INSERT OR IGNORE INTO record_feed (user_id,record_id,score) VALUES (?,?,?)")
+	if err != nil {
+		log.Printf("Database error. Could not prepare statement for storing the record feed. %s", err)
+		return
+	}
+	defer stmt.Close()
+
+	for _, rec := range records {
+		if _, err = stmt.Exec(uid, rec.id, rec.score); err != nil {
+			log.Printf("Database error. Could not store record of the record feed. %s", err)
+			return
+		}
+	}
+}
+
+// rebuildExpertFeed precomputes the expert feed for the specified user. The feed contains all experts with records
+// that match the user's interests. Each expert is stored with its score (see expertScore), in descending order of the
+// scores. The preference for an expert is the learned affinity to the expert, plus the mean affinity to the expert's
+// matching subjects, weighted by the expert's number of records per subject. The feedback counts are only required by
+// the feedback strategy (see readExpertFeedbackCounts).
+func (st *Storage) rebuildExpertFeed(tx *sql.Tx, uid int64, prefs *preferenceModel, feedbackCounts map[int64]int64) {
+
+	const query = `
+		SELECT esl.expert_id, esl.subject_id, esl.record_count, IFNULL(e.last_publication_year,0), e.orcid IS NOT NULL
+		FROM interest AS i, expert_subject_link AS esl, expert AS e
+		WHERE i.user_id=? AND i.subject_id=esl.subject_id AND e.id=esl.expert_id
+		ORDER BY esl.expert_id ASC`
+
+	rows, err := tx.Query(query, uid)
+	if err != nil {
+		log.Printf("Database error. Could not read experts for the expert feed. %s", err)
+		return
+	}
+
+	var experts []scoredExpert
+	var affinity float64
+
+	for rows.Next() {
+
+		var expertId, subjectId, recordCount, lastPublicationYear int64
+		var hasOrcId bool
+
+		if err = rows.Scan(&expertId, &subjectId, &recordCount, &lastPublicationYear, &hasOrcId); err != nil {
+			rows.Close()
+			log.Printf("Database error. Scanning experts for the expert feed failed. %s", err)
+			return
+		}
+
+		if len(experts) == 0 || experts[len(experts)-1].id != expertId {
+			experts = append(experts, scoredExpert{
+				id:                  expertId,
+				lastPublicationYear: lastPublicationYear,
+				hasOrcId:            hasOrcId,
+				feedbackCount:       feedbackCounts[expertId],
+			})
+			affinity = 0
+		}
+
+		exp := &experts[len(experts)-1]
+		exp.recordCount += recordCount
+		affinity += float64(recordCount) * prefs.subjects[subjectId]
+
+		if exp.recordCount > 0 {
+			exp.preference = prefs.experts[expertId] + affinity/float64(exp.recordCount)
+		}
+	}
+	rows.Close()
+
+	currentYear := int64(time.Now().Year())
+
+	for i := range experts {
+		experts[i].score = expertScore(&st.ranking, &experts[i], currentYear)
+	}
+
+	sort.SliceStable(experts, func(i, j int) bool { return experts[i].score > experts[j].score })
+
+	tx.Exec("DELETE FROM expert_feed WHERE user_id=?;", uid)
+
+	stmt, err := tx.Prepare("INSERT OR IGNORE INTO expert_feed (user_id,expert_id,score) VALUES (?,?,?)")
+	if err != nil {
+		log.Printf("Database error. Could not prepare statement for storing the expert feed. %s", err)
+		return
+	}
+	defer stmt.Close()
+
+	for _, exp := range experts {
+		if _, err = stmt.Exec(uid, exp.id, exp.score); err != nil {
+			log.Printf("Database error. Could not store expert of the expert feed. %s", err)
+			return
+		}
+	}
+}
+
+// readExpertFeedbackCounts returns the feedback counts of all experts, if the expert ranking strategy requires them
+// (see ExpertRankingFeedback), and nil otherwise. The counts are the same for all users, so they are read only once when
+// rebuilding the feeds of several users.
+func (st *Storage) readExpertFeedbackCounts(q queryer) (feedbackCounts map[int64]int64, err error) {
+
+	if st.ranking.ExpertStrategy != ExpertRankingFeedback {
+		return nil, nil
+	}
+
+	return readVerifiedFeedbackCounts(q)
+}
+
+// readVerifiedFeedbackCounts returns for each expert the number of relevance ratings of the expert's records, that were
+// provided by verified experts, i.e. by users that have verified their ORCiD via OAuth2 and whose ORCiD belongs to an
+// expert of the catalogue.
+func readVerifiedFeedbackCounts(q queryer) (counts map[int64]int64, err error) {
+
+	const query = `
+		SELECT c.expert_id, COUNT(DISTINCT f.rowid)
+		FROM feedback AS f, creator AS c, user AS u
+		WHERE f.record_id=c.record_id
+			AND f.relevance=1
+			AND c.expert_id IS NOT NULL
+			AND f.user_id=u.id AND u.orcid=f.orcid AND u.orcid_verified=1
+			AND f.orcid IN (SELECT orcid FROM expert WHERE orcid IS NOT NULL)
+		GROUP BY c.expert_id`
+
+	rows, err := q.Query(query)
+	if err != nil {
+		log.Printf("Database error. Could not count feedback of verified experts. %s", err)
+		return
+	}
+	defer rows.Close()
+
+	counts = make(map[int64]int64)
+
+	for rows.Next() {
+
+		var expertId, count int64
+
+		if err = rows.Scan(&expertId, &count); err != nil {
+			log.Printf("Database error. Scanning feedback counts failed. %s", err)
+			return
+		}
+
+		counts[expertId] = count
+	}
+
+	return
+}
@@ -0,0 +1,229 @@
+package storage
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+import (
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/config"
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/model"
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/model/ploc"
+)
+
+func TestRecordFeedRanking(t *testing.T) {
+
+	st := Open(&config.StorageConfiguration{DBFilename: ":memory:"})
+	defer st.Close()
+
+	st.CreateTestPublications()
+
+	// Perform test #1: score combines matches, subject weights, recency and dislikes
+
+	conf := config.RankingConfiguration{MatchWeight: 1, SubjectWeight: 2, RecencyWeight: 4, RecencyHalfLife: 5, DislikePenalty: 0.5}
+	rec := scoredRecord{year: 2010, matchCount: 2, subjectWeight: 0.5, dislikedOverlap: 1}
+
+	if score := interestScore(&conf, &rec, 2015); score != 2+1+2-0.5 {
+		t.Errorf("Expected score %f but got %f.", 2+1+2-0.5, score)
+		return
+	}
+
+	rec.year = 0
+
+	if score := interestScore(&conf, &rec, 2015); score != 2+1-0.5 {
+		t.Errorf("Expected score %f for record without year but got %f.", 2+1-0.5, score)
+		return
+	}
+
+	// Perform test #2: the feed is ordered by score
+
+	user := model.User{GUID: "ranking-test", HashedSecret: "-"}
+	st.CreateUser(&user)
+
+	var subjectA, subjectB int64
+	st.db.QueryRow("SELECT id FROM subject WHERE keyword='Financial Economics'").Scan(&subjectA)
+	st.db.QueryRow("SELECT id FROM subject WHERE keyword='International Financial Markets'").Scan(&subjectB)
+
+	st.CreateInterest(user.Id, subjectA)
+	st.CreateInterest(user.Id, subjectB)
+
+	previews := readFeedPreviews(st, user.Id)
+
+	if len(previews) == 0 {
+		t.Errorf("Expected records in feed.")
+		return
+	}
+
+	var lastScore float64
+
+	for i, p := range previews {
+
+		var score float64
+		st.db.QueryRow("SELECT score FROM record_feed WHERE user_id=? AND record_id=?", user.Id, p.Id).Scan(&score)
+
+		if i > 0 && score > lastScore {
+			t.Errorf("Expected feed to be ordered by descending score, but got %f after %f.", score, lastScore)
+			return
+		}
+
+		lastScore = score
+	}
+
+	// Perform test #3: with recency as only criterion, the feed is ordered by year
+
+	st.SetRankingConfiguration(&config.RankingConfiguration{RecencyWeight: 1, RecencyHalfLife: 5})
+	st.RebuildAllFeeds()
+
+	previews = readFeedPreviews(st, user.Id)
+
+	for i := 1; i < len(previews); i++ {
+		if previews[i].Year > previews[i-1].Year {
+			t.Errorf("Expected feed to be ordered by year, but got %d after %d.", previews[i].Year, previews[i-1].Year)
+			return
+		}
+	}
+
+	// Perform test #4: records that share subjects with disliked records are penalized
+
+	st.SetRankingConfiguration(&config.RankingConfiguration{MatchWeight: 1, DislikePenalty: 10})
+	st.RebuildAllFeeds()
+
+	var dislikedId int64
+	st.db.QueryRow("SELECT record_id FROM record_feed WHERE user_id=? ORDER BY score DESC LIMIT 1", user.Id).Scan(&dislikedId)
+
+	st.CreateRecordDislike(user.Id, dislikedId)
+
+	var penalized, inFeed int64
+	st.db.QueryRow("SELECT COUNT(*) FROM record_feed WHERE user_id=? AND score<0", user.Id).Scan(&penalized)
+	st.db.QueryRow("SELECT COUNT(*) FROM record_feed WHERE user_id=? AND record_id=?", user.Id, dislikedId).Scan(&inFeed)
+
+	if penalized == 0 || inFeed != 0 {
+		t.Errorf("Expected penalized records and no disliked record in feed, but got %d and %d.", penalized, inFeed)
+		return
+	}
+}
+
+func TestExpertRanking(t *testing.T) {
+
+	st := Open(&config.StorageConfiguration{DBFilename: ":memory:"})
+	defer st.Close()
+
+	// Perform test #1: strategies score publications, recency and feedback
+
+	conf := config.RankingConfiguration{RecencyHalfLife: 5, PreferenceWeight: 2}
+	exp := scoredExpert{recordCount: 8, lastPublicationYear: 2010, hasOrcId: true, feedbackCount: 3, preference: 0.5}
+
+	tests := []struct {
+		strategy string
+		score    float64
+	}{
+		{ExpertRankingPublications, 8 + 1},
+		{ExpertRankingRecency, 8*0.5 + 1},
+		{ExpertRankingFeedback, 3 + math.Log1p(8) + 1 + 1},
+		{"", 8 + 1},
+	}
+
+	for _, test := range tests {
+		conf.ExpertStrategy = test.strategy
+		if score := expertScore(&conf, &exp, 2015); math.Abs(score-test.score) > 1e-9 {
+			t.Errorf("Expected score %f for strategy '%s' but got %f.", test.score, test.strategy, score)
+		}
+	}
+
+	exp.lastPublicationYear = 0
+	conf.ExpertStrategy = ExpertRankingRecency
+
+	if score := expertScore(&conf, &exp, 2015); score != 1 {
+		t.Errorf("Expected score %f for expert without year but got %f.", 1.0, score)
+		return
+	}
+
+	// Perform test #2: unknown strategies are rejected
+
+	if err := st.SetRankingConfiguration(&config.RankingConfiguration{ExpertStrategy: "unknown"}); err == nil {
+		t.Errorf("Expected error for unknown expert ranking strategy.")
+		return
+	}
+
+	// Perform test #3: the expert feed is ordered by the configured strategy
+
+	st.CreateTestPublications()
+
+	user := model.User{GUID: "expert-ranking-test", HashedSecret: "-"}
+	st.CreateUser(&user)
+
+	var subjectId int64
+	st.db.QueryRow("SELECT id FROM subject WHERE keyword='Financial Economics'").Scan(&subjectId)
+	st.CreateInterest(user.Id, subjectId)
+
+	st.SetRankingConfiguration(&config.RankingConfiguration{RecencyHalfLife: 1, ExpertStrategy: ExpertRankingRecency})
+	st.RebuildAllFeeds()
+
+	rows, _ := st.db.Query(`
+		SELECT IFNULL(e.last_publication_year,0), f.score
+		FROM expert_feed AS f, expert AS e
+		WHERE f.user_id=? AND f.expert_id=e.id
+		ORDER BY f.score DESC, f.rowid ASC`, user.Id)
+	defer rows.Close()
+
+	var count int64
+
+	for rows.Next() {
+
+		var year int64
+		var score float64
+		rows.Scan(&year, &score)
+
+		if year == 0 && score != 0 {
+			t.Errorf("Expected score %f for expert without year but got %f.", 0.0, score)
+			return
+		}
+
+		count++
+	}
+
+	if count == 0 {
+		t.Errorf("Expected experts in feed.")
+		return
+	}
+}
+
+func TestAddColumnIfMissing(t *testing.T) {
+
+	st := Open(&config.StorageConfiguration{DBFilename: ":memory:"})
+	defer st.Close()
+
+	// Perform test: a column is added once to a table of an older schema
+
+	st.db.Exec("CREATE TABLE legacy_feed (user_id INTEGER NOT NULL, record_id INTEGER NOT NULL)")
+	st.db.Exec("INSERT INTO legacy_feed (user_id,record_id) VALUES (1,2)")
+
+	for i := 0; i < 2; i++ {
+		if err := addColumnIfMissing(st.db, "legacy_feed", "score", "REAL NOT NULL DEFAULT 0"); err != nil {
+			t.Errorf("Unexpected error. %s", err)
+			return
+		}
+	}
+
+	var score float64
+
+	if err := st.db.QueryRow("SELECT score FROM legacy_feed WHERE user_id=1").Scan(&score); err != nil || score != 0 {
+		t.Errorf("Expected migrated column with default value. %v", err)
+		return
+	}
+}
+
+// readFeedPreviews returns the previews of a user's record feed in the order of the feed.
+func readFeedPreviews(st *Storage, uid int64) (previews ploc.RecordPreviews) {
+
+	rawRecords, _ := st.ReadRecordFeed(uid, nil, 0, 1000)
+
+	for _, raw := range rawRecords {
+		var p ploc.RecordPreview
+		json.Unmarshal(raw, &p)
+		previews = append(previews, p)
+	}
+
+	return
+}
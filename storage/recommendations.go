@@ -0,0 +1,117 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+)
+
+// MinRecordSimilaritySupport is the lowest minimum support for building record similarities. Pairs of records, that
+// only a single user engaged with, would reveal the behaviour of that user.
+const MinRecordSimilaritySupport = 2
+
+// engagementQuery selects the records each user has engaged with, i.e. bookmarked, visited or rated as relevant.
+const engagementQuery = `
+	SELECT user_id, record_id FROM record_bookmark
+	UNION
+	SELECT user_id, record_id FROM record_visit
+	UNION
+	SELECT user_id, record_id FROM feedback WHERE relevance=1`
+
+// BuildRecordSimilarities derives the similarities between records from the engagement of all users (item-to-item
+// collaborative filtering). The similarity of two records is the Jaccard index of the users, that engaged with them.
+// Only pairs of records, that at least minSupport different users engaged with, are stored, so that no single user's
+// behaviour can be inferred from the recommendations. Returns the number of stored pairs or an error if minSupport is
+// lower than MinRecordSimilaritySupport.
+func (st *Storage) BuildRecordSimilarities(minSupport int64) (pairCount int64, err error) {
+
+	if minSupport < MinRecordSimilaritySupport {
+		return 0, fmt.Errorf("Minimum support of %d users is lower than %d.", minSupport, MinRecordSimilaritySupport)
+	}
+
+	const query = `
+		INSERT INTO record_similarity (record_id,similar_id,user_count,score)
+			SELECT a.record_id, b.record_id, COUNT(*), CAST(COUNT(*) AS REAL) / (pa.user_count + pb.user_count - COUNT(*))
+			FROM (` + engagementQuery + `) AS a,
+				(` + engagementQuery + `) AS b,
+				(SELECT record_id, COUNT(*) AS user_count FROM (` + engagementQuery + `) GROUP BY record_id) AS pa,
+				(SELECT record_id, COUNT(*) AS user_count FROM (` + engagementQuery + `) GROUP BY record_id) AS pb
+			WHERE a.user_id=b.user_id
+				AND a.record_id<>b.record_id
+				AND pa.record_id=a.record_id
+				AND pb.record_id=b.record_id
+			GROUP BY a.record_id, b.record_id
+			HAVING COUNT(*)>=?`
+
+	tx, err := st.db.Begin()
+	if err != nil {
+		log.Printf("Database error. Could not initialize transaction for building record similarities. %s", err)
+		return
+	}
+
+	if _, err = tx.Exec("DELETE FROM record_similarity"); err != nil {
+		tx.Rollback()
+		log.Printf("Database error. Could not delete record similarities. %s", err)
+		return
+	}
+
+	result, err := tx.Exec(query, minSupport)
+	if err != nil {
+		tx.Rollback()
+		log.Printf("Database error. Could not build record similarities. %s", err)
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Printf("Database error. Could not commit transaction for building record similarities. %s", err)
+		return
+	}
+
+	pairCount, err = result.RowsAffected()
+
+	return
+}
+
+// ReadRecordRecommendations returns the specified segment of the records, that users with a similar engagement have
+// found useful (see BuildRecordSimilarities). Records are scored by their summed similarity to the records the user has
+// engaged with and are returned in descending order of their scores. Records the user has already engaged with or
+// disliked are excluded. The records are returned as precomputed JSON data structure for performance reasons.
+func (st *Storage) ReadRecordRecommendations(uid int64, offset int64, limit int64) (rawRecords []json.RawMessage, err error) {
+
+	const query = `
+		SELECT r.json_preview
+		FROM (SELECT s.similar_id AS record_id, SUM(s.score) AS score
+				FROM record_similarity AS s
+				WHERE s.record_id IN (SELECT record_id FROM (` + engagementQuery + `) WHERE user_id=?)
+					AND s.similar_id NOT IN (SELECT record_id FROM (` + engagementQuery + `) WHERE user_id=?)
+					AND s.similar_id NOT IN (SELECT record_id FROM record_dislike WHERE user_id=?)
+				GROUP BY s.similar_id
+				ORDER BY score DESC, s.similar_id ASC
+				LIMIT ? OFFSET ?) AS rec, record AS r
+		WHERE r.id=rec.record_id
+		ORDER BY rec.score DESC, rec.record_id ASC`
+
+	rows, err := st.db.Query(query, uid, uid, uid, limit, offset)
+	if err != nil {
+		log.Printf("Database error. Querying record recommendations failed. %s", err)
+		return
+	}
+	defer rows.Close()
+
+	rawRecords = []json.RawMessage{}
+
+	for rows.Next() {
+
+		var rawRecord string
+
+		if err = rows.Scan(&rawRecord); err != nil {
+			log.Printf("Database error. Scanning raw JSON records failed. %s", err)
+			return
+		}
+
+		// Visited records are never recommended, hence the precomputed visited flag is always valid.
+		rawRecords = append(rawRecords, json.RawMessage(rawRecord))
+	}
+
+	return
+}
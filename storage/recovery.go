@@ -0,0 +1,206 @@
+package storage
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"log"
+	"strings"
+)
+
+import (
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/model"
+)
+
+// Number of recovery codes, that are created for a user at once.
+const recoveryCodeCount = 10
+
+// CreateRecoveryCodes creates fresh one-time codes, that allow a user to reset the secret of the user's profile.
+// Already existing codes of the user are revoked. Only hashes of the codes are stored.
+func (st *Storage) CreateRecoveryCodes(uid int64) (codes []string, err error) {
+
+	tx, err := st.db.Begin()
+	if err != nil {
+		log.Printf("Database error. Could not initialize transaction for creating recovery codes. %s", err)
+		return nil, err
+	}
+
+	if codes, err = replaceRecoveryCodes(tx, uid); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Printf("Database error. Could not commit transaction for creating recovery codes. %s", err)
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// CreateUserWithRecoveryCodes registers a new user like CreateUser and creates the user's recovery codes within the
+// same transaction, so that there are no users without recovery codes.
+func (st *Storage) CreateUserWithRecoveryCodes(u *model.User) (codes []string, err error) {
+
+	tx, err := st.db.Begin()
+	if err != nil {
+		log.Printf("Database error. Could not initialize transaction for creating user. %s", err)
+		return nil, err
+	}
+
+	if err = insertUser(tx, u); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if codes, err = replaceRecoveryCodes(tx, u.Id); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Printf("Database error. Could not commit transaction for creating user. %s", err)
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// replaceRecoveryCodes revokes the recovery codes of a user and stores the hashes of fresh ones.
+func replaceRecoveryCodes(tx *sql.Tx, uid int64) (codes []string, err error) {
+
+	for i := 0; i < recoveryCodeCount; i++ {
+
+		var code string
+
+		if code, err = randomRecoveryCode(); err != nil {
+			log.Printf("Could not create random recovery code. %s", err)
+			return nil, err
+		}
+
+		codes = append(codes, code)
+	}
+
+	if _, err = tx.Exec("DELETE FROM recovery_code WHERE user_id=?", uid); err != nil {
+		log.Printf("Database error. Could not delete recovery codes. %s", err)
+		return nil, err
+	}
+
+	for _, code := range codes {
+		if _, err = tx.Exec("INSERT INTO recovery_code (user_id,hashed_code) VALUES (?,?)", uid, hashRecoveryCode(code)); err != nil {
+			log.Printf("Database error. Could not store recovery code. %s", err)
+			return nil, err
+		}
+	}
+
+	return codes, nil
+}
+
+// UpdateUserSecret replaces the hashed secret of a user. All sessions of the user are revoked.
+func (st *Storage) UpdateUserSecret(uid int64, hashedSecret string) (err error) {
+
+	tx, err := st.db.Begin()
+	if err != nil {
+		log.Printf("Database error. Could not initialize transaction for updating secret. %s", err)
+		return
+	}
+
+	if err = updateUserSecret(tx, uid, hashedSecret); err != nil {
+		tx.Rollback()
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Printf("Database error. Could not commit transaction for updating secret. %s", err)
+		return
+	}
+
+	return
+}
+
+// RecoverUser replaces the hashed secret of the user with the specified GUID, if the recovery code belongs to the
+// user. The recovery code is used up and all sessions of the user are revoked. Returns sql.ErrNoRows if the user does
+// not exist or the recovery code is unknown.
+func (st *Storage) RecoverUser(guid string, code string, hashedSecret string) (err error) {
+
+	tx, err := st.db.Begin()
+	if err != nil {
+		log.Printf("Database error. Could not initialize transaction for recovering user. %s", err)
+		return
+	}
+
+	var uid int64
+
+	if err = tx.QueryRow("SELECT id FROM user WHERE guid=?", guid).Scan(&uid); err != nil {
+		tx.Rollback()
+		if err != sql.ErrNoRows {
+			log.Printf("Database error. Could not read user for recovery. %s", err)
+		}
+		return
+	}
+
+	res, err := tx.Exec("DELETE FROM recovery_code WHERE user_id=? AND hashed_code=?", uid, hashRecoveryCode(code))
+	if err != nil {
+		tx.Rollback()
+		log.Printf("Database error. Could not use up recovery code. %s", err)
+		return
+	}
+
+	if count, _ := res.RowsAffected(); count == 0 {
+		tx.Rollback()
+		return sql.ErrNoRows
+	}
+
+	if err = updateUserSecret(tx, uid, hashedSecret); err != nil {
+		tx.Rollback()
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Printf("Database error. Could not commit transaction for recovering user. %s", err)
+		return
+	}
+
+	return
+}
+
+// updateUserSecret replaces the hashed secret of a user and revokes all sessions of the user.
+func updateUserSecret(tx *sql.Tx, uid int64, hashedSecret string) (err error) {
+
+	if _, err = tx.Exec("UPDATE user SET hashed_secret=? WHERE id=?", hashedSecret, uid); err != nil {
+		log.Printf("Database error. Could not update secret of user. %s", err)
+		return
+	}
+
+	if _, err = tx.Exec("DELETE FROM session WHERE user_id=?", uid); err != nil {
+		log.Printf("Database error. Could not revoke sessions of user. %s", err)
+		return
+	}
+
+	return
+}
+
+// randomRecoveryCode returns a fresh random recovery code, that consists of four groups of five hex digits (80 bits).
+func randomRecoveryCode() (string, error) {
+
+	b := make([]byte, 10)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	code := hex.EncodeToString(b)
+
+	return code[0:5] + "-" + code[5:10] + "-" + code[10:15] + "-" + code[15:20], nil
+}
+
+// hashRecoveryCode returns the hash of a recovery code, ignoring case, spaces and dashes, which users might type
+// differently. Like refresh tokens, recovery codes are random, so that a fast, unsalted hash suffices.
+func hashRecoveryCode(code string) string {
+
+	code = strings.ToLower(code)
+	code = strings.Replace(code, "-", "", -1)
+	code = strings.Replace(code, " ", "", -1)
+
+	return hashToken(code)
+}
@@ -0,0 +1,542 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Weights of the similarity measures, that are combined to the similarity of two records.
+const (
+	relatedSubjectWeight = 0.4 // overlap of subjects, weighted by their relevance for the records
+	relatedCreatorWeight = 0.2 // shared experts
+	relatedTextWeight    = 0.4 // TF-IDF similarity of titles and abstracts
+)
+
+// Limits for finding candidates of related records and for caching the most similar ones.
+const (
+	relatedCandidateLimit = 200 // maximum number of candidates per kind (subjects, experts, terms)
+	relatedQueryTerms     = 10  // number of most distinctive terms, that are used to find candidates by full text search
+	relatedCacheLimit     = 50  // maximum number of related records that are cached per record
+)
+
+// Frequent English words, that are ignored when comparing titles and abstracts.
+var stopWords = map[string]bool{
+	"about": true, "after": true, "also": true, "and": true, "are": true, "because": true, "been": true,
+	"between": true, "both": true, "but": true, "can": true, "does": true, "for": true, "from": true, "has": true,
+	"have": true, "how": true, "into": true, "its": true, "more": true, "most": true, "not": true, "only": true,
+	"other": true, "our": true, "over": true, "paper": true, "such": true, "than": true, "that": true, "the": true,
+	"their": true, "then": true, "there": true, "these": true, "this": true, "those": true, "through": true,
+	"under": true, "using": true, "was": true, "were": true, "what": true, "when": true, "whether": true,
+	"which": true, "while": true, "who": true, "will": true, "with": true, "within": true, "would": true,
+}
+
+// recordFeatures holds the properties of a record, that determine its similarity to other records.
+type recordFeatures struct {
+	subjects map[int64]float64  // subject IDs and their weights
+	experts  map[int64]bool     // expert IDs of the creators
+	terms    map[string]float64 // TF-IDF weighted terms of title and abstract
+}
+
+// BuildRelatedRecordIndex counts the records that contain each term of the titles and abstracts, which is required
+// for computing the TF-IDF similarity of records, and clears the cached related records.
+// Must be called after new records were added to the database.
+func (st *Storage) BuildRelatedRecordIndex() (err error) {
+
+	rows, err := st.db.Query("SELECT title, IFNULL(abstract,'') FROM record")
+	if err != nil {
+		log.Printf("Database error. Could not read records for counting terms. %s", err)
+		return
+	}
+
+	counts := make(map[string]int64)
+
+	for rows.Next() {
+
+		var title, abstract string
+
+		if err = rows.Scan(&title, &abstract); err != nil {
+			rows.Close()
+			log.Printf("Database error. Scanning records for counting terms failed. %s", err)
+			return
+		}
+
+		for term := range termCounts(title + " " + abstract) {
+			counts[term]++
+		}
+	}
+	rows.Close()
+
+	tx, err := st.db.Begin()
+	if err != nil {
+		log.Printf("Database error. Could not initialize transaction for building related record index. %s", err)
+		return
+	}
+
+	tx.Exec("DELETE FROM term_frequency")
+	tx.Exec("DELETE FROM related_record")
+
+	stmt, err := tx.Prepare("INSERT INTO term_frequency (term,record_count) VALUES (?,?)")
+	if err != nil {
+		tx.Rollback()
+		log.Printf("Database error. Could not prepare statement for storing term frequencies. %s", err)
+		return
+	}
+	defer stmt.Close()
+
+	for term, count := range counts {
+		if _, err = stmt.Exec(term, count); err != nil {
+			tx.Rollback()
+			log.Printf("Database error. Could not store term frequency. %s", err)
+			return
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Printf("Database error. Could not commit transaction for building related record index. %s", err)
+	}
+
+	return
+}
+
+// ReadRelatedRecords returns the records that are most similar to the specified record, in descending order of their
+// similarity. The similarity combines the overlap of subjects, shared experts and the TF-IDF similarity of titles and
+// abstracts. Similarities are computed on first request and cached until the next ingest. The records are returned
+// as precomputed JSON data structure with the user's visited status. Returns sql.ErrNoRows if the record does not exist.
+func (st *Storage) ReadRelatedRecords(uid int64, recordId int64, limit int64) (rawRecords []json.RawMessage, err error) {
+
+	var exists, cached int64
+
+	if err = st.db.QueryRow("SELECT COUNT(*) FROM record WHERE id=?", recordId).Scan(&exists); err != nil {
+		log.Printf("Database error. Could not check existence of record. %s", err)
+		return
+	}
+
+	if exists == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	if err = st.db.QueryRow("SELECT COUNT(*) FROM related_record WHERE record_id=?", recordId).Scan(&cached); err != nil {
+		log.Printf("Database error. Could not read cached related records. %s", err)
+		return
+	}
+
+	if cached == 0 {
+		if err = st.cacheRelatedRecords(recordId); err != nil {
+			return
+		}
+	}
+
+	const query = `
+		SELECT RTRIM(r.json_preview,'false}'), r.id IN (SELECT record_id FROM record_visit WHERE user_id=?)
+		FROM related_record AS rr, record AS r
+		WHERE rr.record_id=? AND rr.related_id=r.id
+		ORDER BY rr.score DESC
+		LIMIT ?`
+
+	rows, err := st.db.Query(query, uid, recordId, limit)
+	if err != nil {
+		log.Printf("Database error. Querying related records failed. %s", err)
+		return
+	}
+	defer rows.Close()
+
+	rawRecords = []json.RawMessage{}
+
+	for rows.Next() {
+
+		var rawRecord string
+		var visited bool
+
+		if err = rows.Scan(&rawRecord, &visited); err != nil {
+			log.Printf("Database error. Scanning raw JSON records failed. %s", err)
+			return
+		}
+
+		if visited {
+			rawRecords = append(rawRecords, json.RawMessage(rawRecord+"true}"))
+		} else {
+			rawRecords = append(rawRecords, json.RawMessage(rawRecord+"false}"))
+		}
+	}
+
+	return
+}
+
+// cacheRelatedRecords computes the similarity of a record to all candidates, i.e. records that share subjects,
+// experts or distinctive terms, and stores the most similar ones.
+func (st *Storage) cacheRelatedRecords(recordId int64) (err error) {
+
+	var recordCount int64
+
+	if err = st.db.QueryRow("SELECT COUNT(*) FROM record").Scan(&recordCount); err != nil {
+		log.Printf("Database error. Could not count records. %s", err)
+		return
+	}
+
+	frequencies := make(map[string]int64)
+
+	own, err := st.readRecordFeatures([]int64{recordId}, recordCount, frequencies)
+	if err != nil {
+		return
+	}
+
+	features := own[recordId]
+
+	candidateIds, err := st.readRelatedCandidates(recordId, features)
+	if err != nil {
+		return
+	}
+
+	candidates, err := st.readRecordFeatures(candidateIds, recordCount, frequencies)
+	if err != nil {
+		return
+	}
+
+	type relatedRecord struct {
+		id    int64
+		score float64
+	}
+
+	var related []relatedRecord
+
+	for _, candidateId := range candidateIds {
+
+		candidate := candidates[candidateId]
+
+		score := relatedSubjectWeight*weightedJaccard(features.subjects, candidate.subjects) +
+			relatedCreatorWeight*expertJaccard(features.experts, candidate.experts) +
+			relatedTextWeight*cosineSimilarity(features.terms, candidate.terms)
+
+		if score > 0 {
+			related = append(related, relatedRecord{candidateId, score})
+		}
+	}
+
+	sort.SliceStable(related, func(i, j int) bool { return related[i].score > related[j].score })
+
+	if len(related) > relatedCacheLimit {
+		related = related[:relatedCacheLimit]
+	}
+
+	tx, err := st.db.Begin()
+	if err != nil {
+		log.Printf("Database error. Could not initialize transaction for caching related records. %s", err)
+		return
+	}
+
+	// A record without related records is related to the non-existing ID 0, so that it is cached nonetheless.
+	if len(related) == 0 {
+		related = append(related, relatedRecord{0, 0})
+	}
+
+	for _, rel := range related {
+		if _, err = tx.Exec("INSERT OR REPLACE INTO related_record (record_id,related_id,score) VALUES (?,?,?)", recordId, rel.id, rel.score); err != nil {
+			tx.Rollback()
+			log.Printf("Database error. Could not cache related record. %s", err)
+			return
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Printf("Database error. Could not commit transaction for caching related records. %s", err)
+	}
+
+	return
+}
+
+// readRelatedCandidates returns the IDs of records, that share subjects, experts or distinctive terms with a record.
+func (st *Storage) readRelatedCandidates(recordId int64, features recordFeatures) (candidateIds []int64, err error) {
+
+	const subjectQuery = `
+		SELECT l2.record_id
+		FROM record_subject_link AS l1, record_subject_link AS l2
+		WHERE l1.record_id=? AND l1.subject_id=l2.subject_id AND l2.record_id<>l1.record_id
+		GROUP BY l2.record_id
+		ORDER BY SUM(MIN(l1.weight,l2.weight)) DESC
+		LIMIT ?`
+
+	const expertQuery = `
+		SELECT c2.record_id
+		FROM creator AS c1, creator AS c2
+		WHERE c1.record_id=? AND c1.expert_id=c2.expert_id AND c2.record_id<>c1.record_id
+		GROUP BY c2.record_id
+		ORDER BY COUNT(DISTINCT c2.expert_id) DESC
+		LIMIT ?`
+
+	const textQuery = `
+		SELECT record_id
+		FROM vrecord
+		WHERE vrecord MATCH ? AND record_id<>?
+		ORDER BY bm25(vrecord, ` + recordSearchWeights + `) ASC
+		LIMIT ?`
+
+	queries := []struct {
+		query string
+		args  []interface{}
+	}{
+		{subjectQuery, []interface{}{recordId, relatedCandidateLimit}},
+		{expertQuery, []interface{}{recordId, relatedCandidateLimit}},
+	}
+
+	if terms := distinctiveTerms(features.terms, relatedQueryTerms); len(terms) > 0 {
+		ftsQuery := `{title abstract} : ("` + strings.Join(terms, `" OR "`) + `")`
+		queries = append(queries, struct {
+			query string
+			args  []interface{}
+		}{textQuery, []interface{}{ftsQuery, recordId, relatedCandidateLimit}})
+	}
+
+	seen := make(map[int64]bool)
+
+	for _, q := range queries {
+
+		rows, err := st.db.Query(q.query, q.args...)
+		if err != nil {
+			log.Printf("Database error. Could not read candidates of related records. %s", err)
+			return nil, err
+		}
+
+		for rows.Next() {
+
+			var candidateId int64
+
+			if err = rows.Scan(&candidateId); err != nil {
+				rows.Close()
+				log.Printf("Database error. Scanning candidates of related records failed. %s", err)
+				return nil, err
+			}
+
+			if !seen[candidateId] {
+				seen[candidateId] = true
+				candidateIds = append(candidateIds, candidateId)
+			}
+		}
+		rows.Close()
+	}
+
+	return candidateIds, nil
+}
+
+// readRecordFeatures reads the subjects, experts and TF-IDF weighted terms of records in chunks. The record count is
+// the total number of records, which is required for the inverse document frequency. Term frequencies that were read
+// from the database are kept in the frequencies map, so that they are read only once for several records.
+func (st *Storage) readRecordFeatures(recordIds []int64, recordCount int64, frequencies map[string]int64) (features map[int64]recordFeatures, err error) {
+
+	links, err := readRecordLinks(st.db, recordIds)
+	if err != nil {
+		return
+	}
+
+	features = make(map[int64]recordFeatures)
+
+	for _, recordId := range recordIds {
+
+		f := recordFeatures{
+			subjects: make(map[int64]float64),
+			experts:  make(map[int64]bool),
+			terms:    make(map[string]float64),
+		}
+
+		for _, s := range links.subjects[recordId] {
+			f.subjects[s.subjectId] = s.weight
+		}
+
+		for _, expertId := range links.experts[recordId] {
+			f.experts[expertId] = true
+		}
+
+		features[recordId] = f
+	}
+
+	counts := make(map[int64]map[string]int64)
+	var unknownTerms []interface{}
+
+	for start := 0; start < len(recordIds); start += recordLinkChunkSize {
+
+		end := start + recordLinkChunkSize
+		if end > len(recordIds) {
+			end = len(recordIds)
+		}
+
+		args := make([]interface{}, end-start)
+		for i, id := range recordIds[start:end] {
+			args[i] = id
+		}
+
+		rows, err := st.db.Query("SELECT id, title, IFNULL(abstract,'') FROM record WHERE id IN ("+placeholders(len(args))+")", args...)
+		if err != nil {
+			log.Printf("Database error. Could not read titles and abstracts of records. %s", err)
+			return nil, err
+		}
+
+		for rows.Next() {
+
+			var recordId int64
+			var title, abstract string
+
+			if err = rows.Scan(&recordId, &title, &abstract); err != nil {
+				rows.Close()
+				log.Printf("Database error. Scanning titles and abstracts of records failed. %s", err)
+				return nil, err
+			}
+
+			counts[recordId] = termCounts(title + " " + abstract)
+
+			for term := range counts[recordId] {
+				if _, ok := frequencies[term]; !ok {
+					frequencies[term] = 0
+					unknownTerms = append(unknownTerms, term)
+				}
+			}
+		}
+		rows.Close()
+	}
+
+	for start := 0; start < len(unknownTerms); start += recordLinkChunkSize {
+
+		end := start + recordLinkChunkSize
+		if end > len(unknownTerms) {
+			end = len(unknownTerms)
+		}
+
+		args := unknownTerms[start:end]
+
+		rows, err := st.db.Query("SELECT term, record_count FROM term_frequency WHERE term IN ("+placeholders(len(args))+")", args...)
+		if err != nil {
+			log.Printf("Database error. Could not read term frequencies. %s", err)
+			return nil, err
+		}
+
+		for rows.Next() {
+
+			var term string
+			var recordsWithTerm int64
+
+			if err = rows.Scan(&term, &recordsWithTerm); err != nil {
+				rows.Close()
+				log.Printf("Database error. Scanning term frequencies failed. %s", err)
+				return nil, err
+			}
+
+			frequencies[term] = recordsWithTerm
+		}
+		rows.Close()
+	}
+
+	for recordId, termCount := range counts {
+		for term, count := range termCount {
+			idf := math.Log(float64(recordCount+1)/float64(frequencies[term]+1)) + 1
+			features[recordId].terms[term] = float64(count) * idf
+		}
+	}
+
+	return features, nil
+}
+
+// termCounts splits a text into lower case words and counts them. Short words and stop words are ignored.
+func termCounts(text string) map[string]int64 {
+
+	counts := make(map[string]int64)
+
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	for _, w := range words {
+		if len([]rune(w)) > 2 && !stopWords[w] {
+			counts[w]++
+		}
+	}
+
+	return counts
+}
+
+// distinctiveTerms returns the terms with the highest TF-IDF weights.
+func distinctiveTerms(terms map[string]float64, n int) (distinctive []string) {
+
+	for term := range terms {
+		distinctive = append(distinctive, term)
+	}
+
+	sort.Slice(distinctive, func(i, j int) bool {
+		if terms[distinctive[i]] != terms[distinctive[j]] {
+			return terms[distinctive[i]] > terms[distinctive[j]]
+		}
+		return distinctive[i] < distinctive[j]
+	})
+
+	if len(distinctive) > n {
+		distinctive = distinctive[:n]
+	}
+
+	return distinctive
+}
+
+// weightedJaccard returns the weighted Jaccard similarity (0..1) of two sets of weighted subjects.
+func weightedJaccard(a map[int64]float64, b map[int64]float64) float64 {
+
+	var minSum, maxSum float64
+
+	for id, wa := range a {
+		wb := b[id]
+		minSum += math.Min(wa, wb)
+		maxSum += math.Max(wa, wb)
+	}
+
+	for id, wb := range b {
+		if _, ok := a[id]; !ok {
+			maxSum += wb
+		}
+	}
+
+	if maxSum == 0 {
+		return 0
+	}
+
+	return minSum / maxSum
+}
+
+// expertJaccard returns the Jaccard similarity (0..1) of two sets of experts.
+func expertJaccard(a map[int64]bool, b map[int64]bool) float64 {
+
+	var shared int
+
+	for id := range a {
+		if b[id] {
+			shared++
+		}
+	}
+
+	union := len(a) + len(b) - shared
+	if union == 0 {
+		return 0
+	}
+
+	return float64(shared) / float64(union)
+}
+
+// cosineSimilarity returns the cosine similarity (0..1) of two TF-IDF weighted term vectors.
+func cosineSimilarity(a map[string]float64, b map[string]float64) float64 {
+
+	var dot, normA, normB float64
+
+	for term, wa := range a {
+		dot += wa * b[term]
+		normA += wa * wa
+	}
+
+	for _, wb := range b {
+		normB += wb * wb
+	}
+
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
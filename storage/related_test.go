@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"math"
+	"testing"
+)
+
+import (
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/config"
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/model"
+)
+
+func TestRecordSimilarity(t *testing.T) {
+
+	// Perform test #1: terms are lower case words without stop words
+
+	counts := termCounts("The Stock Market and the stock prices of 2010.")
+
+	if counts["stock"] != 2 || counts["market"] != 1 || counts["2010"] != 1 || counts["the"] != 0 || counts["of"] != 0 {
+		t.Errorf("Unexpected term counts %v.", counts)
+		return
+	}
+
+	// Perform test #2: weighted subject overlap
+
+	a := map[int64]float64{1: 1.0, 2: 0.5}
+	b := map[int64]float64{1: 0.5, 3: 0.5}
+
+	if s := weightedJaccard(a, b); math.Abs(s-0.5/2.0) > 1e-9 {
+		t.Errorf("Expected weighted Jaccard similarity %f but got %f.", 0.25, s)
+		return
+	}
+
+	if s := weightedJaccard(a, a); s != 1 {
+		t.Errorf("Expected weighted Jaccard similarity %f for identical subjects but got %f.", 1.0, s)
+		return
+	}
+
+	// Perform test #3: shared experts
+
+	if s := expertJaccard(map[int64]bool{1: true, 2: true}, map[int64]bool{2: true, 3: true}); math.Abs(s-1.0/3.0) > 1e-9 {
+		t.Errorf("Expected Jaccard similarity %f but got %f.", 1.0/3.0, s)
+		return
+	}
+
+	// Perform test #4: cosine similarity of term vectors
+
+	if s := cosineSimilarity(map[string]float64{"stock": 1}, map[string]float64{"stock": 2}); math.Abs(s-1) > 1e-9 {
+		t.Errorf("Expected cosine similarity %f but got %f.", 1.0, s)
+		return
+	}
+
+	if s := cosineSimilarity(map[string]float64{"stock": 1}, map[string]float64{"bond": 1}); s != 0 {
+		t.Errorf("Expected cosine similarity %f but got %f.", 0.0, s)
+		return
+	}
+
+	if s := cosineSimilarity(map[string]float64{}, map[string]float64{"bond": 1}); s != 0 {
+		t.Errorf("Expected cosine similarity %f for empty vector but got %f.", 0.0, s)
+		return
+	}
+}
+
+func TestRelatedRecordCache(t *testing.T) {
+
+	st := Open(&config.StorageConfiguration{DBFilename: ":memory:"})
+	defer st.Close()
+
+	st.CreateTestPublications()
+
+	recordId, _ := st.UpsertRecord(&model.Record{SourceId: "X", Title: "Zipf zipped", Type: model.RecordTypePaper, Year: 2004})
+	st.BuildRelatedRecordIndex()
+
+	// Perform test #1: related records of a record are computed and cached
+
+	var relatedId int64
+
+	st.db.QueryRow("SELECT MIN(id) FROM record").Scan(&relatedId)
+
+	rawRecords, err := st.ReadRelatedRecords(0, relatedId, 10)
+	if err != nil || len(rawRecords) == 0 {
+		t.Errorf("Expected related records but got %d. %v", len(rawRecords), err)
+		return
+	}
+
+	// Perform test #2: a record without related records is cached as well
+
+	rawRecords, err = st.ReadRelatedRecords(0, recordId, 10)
+	if err != nil || len(rawRecords) != 0 {
+		t.Errorf("Expected no related records but got %d. %v", len(rawRecords), err)
+		return
+	}
+
+	var cached int64
+
+	if st.db.QueryRow("SELECT COUNT(*) FROM related_record WHERE record_id=?", recordId).Scan(&cached); cached != 1 {
+		t.Errorf("Expected record %d to be cached without related records, but got %d cache entries.", recordId, cached)
+		return
+	}
+}
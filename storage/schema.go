@@ -53,22 +53,25 @@ CREATE TABLE IF NOT EXISTS feedback ( -- open feedback provided by domain expert
   UNIQUE(user_id,record_id)
 );
 
-CREATE TABLE IF NOT EXISTS subject (
+CREATE TABLE IF NOT EXISTS subject ( -- a subject represents a topic or category of interest
   id INTEGER PRIMARY KEY, -- unique subject ID
   keyword TEXT NOT NULL UNIQUE -- the keyword itself
 );
 
-CREATE TABLE IF NOT EXISTS collection (
+CREATE TABLE IF NOT EXISTS collection ( -- a named list of bookmarks
   id INTEGER PRIMARY KEY, -- unique bookmark collection ID
   user_id INTEGER NOT NULL, -- the owner of the bookmark collection
   title TEXT NOT NULL -- a user-given name
 );
 
-CREATE TABLE IF NOT EXISTS user (
+CREATE TABLE IF NOT EXISTS user ( -- information related to a user's identity
   id INTEGER PRIMARY KEY, -- we do not use the GUID as primary key for performance and security reasons
   guid TEXT NOT NULL UNIQUE, -- globaly unique identifier for the user (used for access identifikation)
   hashed_secret TEXT NOT NULL, -- the user's secret in its hashed form
-  orcid TEXT DEFAULT NULL -- optional ORCiD (identification ID)
+  orcid TEXT DEFAULT NULL, -- optional ORCiD (identification ID)
+  orcid_verified INTEGER NOT NULL DEFAULT 0, -- 1 if the user has proven to own the ORCiD via OAuth2, otherwise 0
+  orcid_name TEXT DEFAULT NULL, -- the name of the ORCiD's owner, as returned by the provider on verification
+  role TEXT NOT NULL DEFAULT 'user' -- 'user' or 'admin', administrators may use the admin API
 );
 
 CREATE TABLE IF NOT EXISTS interest ( -- a number of disjunct subjects define a user's interest
@@ -105,12 +108,14 @@ CREATE TABLE IF NOT EXISTS expert_bookmark ( -- experts that have been bookmarke
 CREATE TABLE IF NOT EXISTS record_feed ( -- precomputed record feeds for all the users (performance hack)
   user_id INTEGER NOT NULL, -- reference to the user that owns the feed
   record_id INTEGER NOT NULL, -- reference to the record that shows up in the feed
+  score REAL NOT NULL DEFAULT 0, -- how interesting is the record for that user (see RankingConfiguration)
   UNIQUE(user_id,record_id)
 );
 
 CREATE TABLE IF NOT EXISTS expert_feed ( -- precomputed expert feeds for all the users (performance hack)
   user_id INTEGER NOT NULL, -- reference to the user that owns the feed
   expert_id INTEGER NOT NULL, -- reference to the expert that shows up in the feed
+  score REAL NOT NULL DEFAULT 0, -- how interesting is the expert for that user (see RankingConfiguration)
   UNIQUE(user_id,expert_id)
 );
 
@@ -120,6 +125,95 @@ CREATE TABLE IF NOT EXISTS record_visit ( -- keeps track of the records a user h
   UNIQUE(user_id,record_id)
 );
 
+CREATE TABLE IF NOT EXISTS feed_token ( -- secret tokens that grant read access to a user's syndication feeds (Atom/RSS)
+  user_id INTEGER NOT NULL UNIQUE, -- the user that owns the feed
+  hashed_token TEXT NOT NULL UNIQUE, -- SHA-256 hash of the token (the token itself is only known to the user)
+  etag TEXT DEFAULT NULL, -- entity tag of the last delivered feed
+  modified TEXT DEFAULT NULL -- time when the delivered feed has changed last (RFC 3339)
+);
+
+CREATE TABLE IF NOT EXISTS session ( -- login sessions of users, that authenticate with signed access tokens
+  id INTEGER PRIMARY KEY, -- the session ID, which is part of the signed access tokens
+  user_id INTEGER NOT NULL, -- the user that has logged in
+  hashed_refresh_token TEXT NOT NULL UNIQUE, -- SHA-256 hash of the current refresh token of the session
+  expires INTEGER NOT NULL -- time when the refresh token expires (Unix time)
+);
+
+CREATE INDEX IF NOT EXISTS session_user_idx ON session(user_id);
+
+CREATE TABLE IF NOT EXISTS recovery_code ( -- one-time codes, that allow users to reset their secret (e.g. on a new device)
+  user_id INTEGER NOT NULL, -- the user that owns the code
+  hashed_code TEXT NOT NULL UNIQUE -- SHA-256 hash of the code (the code itself is only known to the user)
+);
+
+CREATE INDEX IF NOT EXISTS recovery_code_user_idx ON recovery_code(user_id);
+
+CREATE TABLE IF NOT EXISTS orcid_authorization ( -- pending verifications of ORCiDs via OAuth2
+  hashed_state TEXT NOT NULL UNIQUE, -- SHA-256 hash of the state, that relates the provider's callback to the user
+  user_id INTEGER NOT NULL, -- the user whose ORCiD is verified
+  code_verifier TEXT NOT NULL, -- PKCE code verifier, that is required to exchange the authorization code
+  expires INTEGER NOT NULL -- time when the authorization expires (Unix time)
+);
+
+CREATE TABLE IF NOT EXISTS expert_claim ( -- claims of users with verified ORCiD, that an expert of the catalogue is them
+  id INTEGER PRIMARY KEY, -- unique claim ID
+  user_id INTEGER NOT NULL, -- the user that has claimed the expert
+  expert_id INTEGER NOT NULL, -- the claimed expert
+  orcid TEXT NOT NULL, -- the user's verified ORCiD at the time of the claim
+  status TEXT NOT NULL, -- 'pending' until approved by an administrator, 'approved' or 'rejected'
+  created INTEGER NOT NULL -- time when the claim was made (Unix time)
+);
+
+CREATE INDEX IF NOT EXISTS expert_claim_user_idx ON expert_claim(user_id);
+CREATE INDEX IF NOT EXISTS expert_claim_expert_idx ON expert_claim(expert_id);
+
+CREATE TABLE IF NOT EXISTS term_frequency ( -- number of records whose title or abstract contain a term (for TF-IDF)
+  term TEXT NOT NULL PRIMARY KEY, -- a lower case word
+  record_count INTEGER NOT NULL -- number of records that contain the word
+);
+
+CREATE TABLE IF NOT EXISTS related_record ( -- cached similarities between records (computed on demand)
+  record_id INTEGER NOT NULL, -- a record
+  related_id INTEGER NOT NULL, -- a record that is similar to the first one
+  score REAL NOT NULL, -- how similar are both records (0..1)
+  UNIQUE(record_id,related_id)
+);
+
+CREATE TABLE IF NOT EXISTS record_similarity ( -- similarities between records derived from the interactions of all users (collaborative filtering)
+  record_id INTEGER NOT NULL, -- a record
+  similar_id INTEGER NOT NULL, -- a record that users interacted with, who also interacted with the first one
+  user_count INTEGER NOT NULL, -- number of different users that interacted with both records (at least the minimum support)
+  score REAL NOT NULL, -- how similar are both records (0..1)
+  UNIQUE(record_id,similar_id)
+);
+
+CREATE TABLE IF NOT EXISTS coauthorship ( -- precomputed co-authorship graph between experts (both directions)
+  expert_id INTEGER NOT NULL, -- an expert
+  coauthor_id INTEGER NOT NULL, -- an expert that has co-authored records with the first one
+  record_count INTEGER NOT NULL, -- number of records both experts have co-authored
+  UNIQUE(expert_id,coauthor_id)
+);
+
+CREATE TABLE IF NOT EXISTS harvest_run ( -- log of harvesting runs against external OAI-PMH repositories
+  id INTEGER PRIMARY KEY, -- unique harvest run ID
+  endpoint TEXT NOT NULL, -- base URL of the harvested repository
+  started TEXT NOT NULL, -- time when the run has started (RFC 3339)
+  finished TEXT DEFAULT NULL, -- time when the run has finished (RFC 3339), NULL while running
+  from_date TEXT DEFAULT NULL, -- lower bound of the harvested time window (optional)
+  until_date TEXT NOT NULL, -- upper bound of the harvested time window
+  record_count INTEGER NOT NULL DEFAULT 0, -- number of inserted or updated records
+  deleted_count INTEGER NOT NULL DEFAULT 0, -- number of deleted records
+  error TEXT DEFAULT NULL -- error message if the run has failed
+);
+
+CREATE TABLE IF NOT EXISTS audit_log ( -- actions of administrators via the admin API or the command line
+  id INTEGER PRIMARY KEY, -- unique entry ID
+  actor TEXT NOT NULL, -- GUID of the administrator, or 'console' for actions on the command line
+  action TEXT NOT NULL, -- name of the action (e.g. 'user/delete')
+  target TEXT NOT NULL, -- the affected entity (e.g. a GUID), or an empty string
+  created INTEGER NOT NULL -- time of the action (Unix time)
+);
+
 -- Junction tables
 
 CREATE TABLE IF NOT EXISTS record_subject_link (
@@ -140,6 +234,7 @@ CREATE TABLE IF NOT EXISTS expert_subject_link (
 
 CREATE VIRTUAL TABLE IF NOT EXISTS vrecord USING FTS5(record_id,title,abstract,subjects,authors,tokenize = 'porter ascii');
 CREATE VIRTUAL TABLE IF NOT EXISTS vexpert USING FTS5(expert_id,full_name,titles,subjects,tokenize = 'porter ascii');
+CREATE VIRTUAL TABLE IF NOT EXISTS vsuggestion USING FTS5(kind UNINDEXED,ref_id UNINDEXED,popularity UNINDEXED,text,prefix = '1 2 3',tokenize = 'unicode61 remove_diacritics 1');
 
 COMMIT TRANSACTION;
 `
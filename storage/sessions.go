@@ -0,0 +1,143 @@
+package storage
+
+import (
+	"database/sql"
+	"log"
+	"time"
+)
+
+import (
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/model"
+)
+
+// CreateSession starts a new login session of a user, that lasts until the specified expiry unless it is refreshed
+// or revoked. Returns the ID of the session and a fresh refresh token. Only a hash of the token is stored.
+func (st *Storage) CreateSession(uid int64, expires time.Time) (sessionId int64, refreshToken string, err error) {
+
+	if refreshToken, err = randomToken(); err != nil {
+		log.Printf("Could not create random refresh token. %s", err)
+		return
+	}
+
+	res, err := st.db.Exec("INSERT INTO session (user_id,hashed_refresh_token,expires) VALUES (?,?,?)",
+		uid, hashToken(refreshToken), expires.Unix())
+	if err != nil {
+		log.Printf("Database error. Could not store session. %s", err)
+		return
+	}
+
+	sessionId, err = res.LastInsertId()
+	if err != nil {
+		log.Printf("Database error. Could not read ID of session. %s", err)
+		return
+	}
+
+	return
+}
+
+// RefreshSession replaces the refresh token of a session by a fresh one and extends the session until the specified
+// expiry. Each refresh token can only be used once. Returns the session ID, the user that owns the session and the
+// new refresh token. The user is nil, if the refresh token is unknown, has expired or its session was revoked.
+func (st *Storage) RefreshSession(refreshToken string, expires time.Time) (sessionId int64, user *model.User, newRefreshToken string, err error) {
+
+	const query = `
+		SELECT s.id,u.id,u.guid,u.hashed_secret,u.orcid,u.orcid_verified,u.role
+		FROM session AS s, user AS u
+		WHERE s.hashed_refresh_token=? AND s.expires>? AND s.user_id=u.id`
+
+	if newRefreshToken, err = randomToken(); err != nil {
+		log.Printf("Could not create random refresh token. %s", err)
+		return
+	}
+
+	tx, err := st.db.Begin()
+	if err != nil {
+		log.Printf("Database error. Could not initialize transaction for refreshing session. %s", err)
+		return
+	}
+
+	var u model.User
+	var orcId sql.NullString
+
+	err = tx.QueryRow(query, hashToken(refreshToken), time.Now().Unix()).Scan(&sessionId, &u.Id, &u.GUID, &u.HashedSecret, &orcId, &u.OrcIdVerified, &u.Role)
+
+	switch {
+	case err == sql.ErrNoRows:
+		tx.Rollback()
+		return 0, nil, "", nil
+	case err != nil:
+		tx.Rollback()
+		log.Printf("Database error. Could not read session. %s", err)
+		return
+	}
+
+	u.OrcId = NullToString(orcId)
+
+	_, err = tx.Exec("UPDATE session SET hashed_refresh_token=?, expires=? WHERE id=?", hashToken(newRefreshToken), expires.Unix(), sessionId)
+	if err != nil {
+		tx.Rollback()
+		log.Printf("Database error. Could not update refresh token of session. %s", err)
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Printf("Database error. Could not commit transaction for refreshing session. %s", err)
+		return
+	}
+
+	return sessionId, &u, newRefreshToken, nil
+}
+
+// DeleteSession revokes the session of a user, that the refresh token belongs to. Access tokens of the session are
+// no longer accepted. Returns sql.ErrNoRows if the user has no session with the refresh token.
+func (st *Storage) DeleteSession(uid int64, refreshToken string) (err error) {
+
+	res, err := st.db.Exec("DELETE FROM session WHERE user_id=? AND hashed_refresh_token=?", uid, hashToken(refreshToken))
+	if err != nil {
+		log.Printf("Database error. Could not delete session. %s", err)
+		return
+	}
+
+	if count, _ := res.RowsAffected(); count == 0 {
+		return sql.ErrNoRows
+	}
+
+	return
+}
+
+// DeleteAllSessions revokes all sessions of a user (e.g. if a device was lost).
+func (st *Storage) DeleteAllSessions(uid int64) (err error) {
+
+	_, err = st.db.Exec("DELETE FROM session WHERE user_id=?", uid)
+	if err != nil {
+		log.Printf("Database error. Could not delete sessions. %s", err)
+	}
+
+	return
+}
+
+// UserBySession returns the major user information like database ID, GUID and ORCiD identifier of the user that owns
+// a session. Returns nil if the session has expired or was revoked.
+func (st *Storage) UserBySession(sessionId int64) (user *model.User, err error) {
+
+	var u model.User
+	var orcId sql.NullString
+
+	const query = `
+		SELECT u.id,u.guid,u.hashed_secret,u.orcid,u.orcid_verified,u.role
+		FROM session AS s, user AS u
+		WHERE s.id=? AND s.expires>? AND s.user_id=u.id`
+
+	err = st.db.QueryRow(query, sessionId, time.Now().Unix()).Scan(&u.Id, &u.GUID, &u.HashedSecret, &orcId, &u.OrcIdVerified, &u.Role)
+
+	switch {
+	case err == sql.ErrNoRows:
+		return nil, nil
+	case err != nil:
+		return nil, err
+	}
+
+	u.OrcId = NullToString(orcId)
+
+	return &u, nil
+}
@@ -5,7 +5,9 @@ package storage
 
 import (
 	"database/sql"
+	"fmt"
 	"log"
+	"sync"
 )
 
 import (
@@ -16,7 +18,23 @@ import (
 // Storage encapsulates the state of the database.
 // All CRUD-operations are defined for this type, which makes the code more readable.
 type Storage struct {
-	db *sql.DB
+	db      *sql.DB
+	ranking config.RankingConfiguration
+
+	graphMutex sync.Mutex        // guards the cached co-authorship graph
+	coauthors  map[int64][]int64 // cached co-authorship graph (see coauthorGraph), nil if not loaded yet
+}
+
+// Columns that were added to existing tables after the first release. Databases that were created before are migrated
+// on startup, since "CREATE TABLE IF NOT EXISTS" does not add columns to existing tables.
+var addedColumns = []struct {
+	table, column, definition string
+}{
+	{"record_feed", "score", "REAL NOT NULL DEFAULT 0"},
+	{"expert_feed", "score", "REAL NOT NULL DEFAULT 0"},
+	{"user", "orcid_verified", "INTEGER NOT NULL DEFAULT 0"},
+	{"user", "orcid_name", "TEXT DEFAULT NULL"},
+	{"user", "role", "TEXT NOT NULL DEFAULT 'user'"},
 }
 
 // Open connects to the SQLite database and initializes the schema if not done yet.
@@ -32,9 +50,71 @@ func Open(conf *config.StorageConfiguration) *Storage {
 		log.Fatalf("Setting up database failed. %s", err)
 	}
 
+	for _, c := range addedColumns {
+		if err = addColumnIfMissing(db, c.table, c.column, c.definition); err != nil {
+			log.Fatalf("Migrating database failed. %s", err)
+		}
+	}
+
 	log.Printf("Connecting to database '%s' was successfull.", conf.DBFilename)
 
-	return &Storage{db: db}
+	return &Storage{db: db, ranking: config.DefaultConfiguration().Ranking}
+}
+
+// SetRankingConfiguration defines the weights for scoring the records of the users' record feeds and the strategy for
+// ranking the experts of the users' expert feeds. The configuration applies to feeds that are rebuilt afterwards
+// (see RebuildAllFeeds). Returns an error if the expert ranking strategy is unknown. An empty strategy selects the
+// default one (ExpertRankingPublications).
+func (st *Storage) SetRankingConfiguration(conf *config.RankingConfiguration) (err error) {
+
+	if _, ok := expertRankings[conf.ExpertStrategy]; !ok && conf.ExpertStrategy != "" {
+		return fmt.Errorf("Unknown expert ranking strategy '%s'.", conf.ExpertStrategy)
+	}
+
+	st.ranking = *conf
+
+	return
+}
+
+// addColumnIfMissing adds a column with the specified definition (e.g. "REAL NOT NULL DEFAULT 0") to an existing table,
+// unless the table already has such a column.
+func addColumnIfMissing(db *sql.DB, table string, column string, definition string) (err error) {
+
+	rows, err := db.Query("PRAGMA table_info(" + table + ")")
+	if err != nil {
+		return fmt.Errorf("Could not read columns of table '%s'. %s", table, err)
+	}
+
+	exists := false
+
+	for rows.Next() {
+
+		var cid, notNull, primaryKey int64
+		var name, columnType string
+		var defaultValue sql.NullString
+
+		if err = rows.Scan(&cid, &name, &columnType, &notNull, &defaultValue, &primaryKey); err != nil {
+			rows.Close()
+			return fmt.Errorf("Could not scan columns of table '%s'. %s", table, err)
+		}
+
+		if name == column {
+			exists = true
+		}
+	}
+	rows.Close()
+
+	if exists {
+		return nil
+	}
+
+	if _, err = db.Exec("ALTER TABLE " + table + " ADD COLUMN " + column + " " + definition); err != nil {
+		return fmt.Errorf("Could not add column '%s' to table '%s'. %s", column, table, err)
+	}
+
+	log.Printf("Added column '%s' to table '%s'.", column, table)
+
+	return nil
 }
 
 // Close writes all pending transactions to the database and disconnects from it.
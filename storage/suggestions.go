@@ -0,0 +1,117 @@
+package storage
+
+import (
+	"log"
+	"strings"
+	"unicode"
+)
+
+import (
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/model/ploc"
+)
+
+// Kinds of suggestions, that complete the input of a user.
+const (
+	SuggestionSubject = "subject"
+	SuggestionExpert  = "expert"
+	SuggestionRecord  = "record"
+)
+
+// BuildSuggestionIndex repopulates the prefix index for completing a user's input with subject keywords, expert names
+// and record titles. The popularity of a suggestion is the number of related records (subjects) or the number of
+// publications (experts). Must be called after new records were added to the database.
+func (st *Storage) BuildSuggestionIndex() (err error) {
+
+	const subjectQuery = `
+		INSERT INTO vsuggestion (kind,ref_id,popularity,text)
+			SELECT '` + SuggestionSubject + `', s.id, COUNT(rsl.record_id), s.keyword
+			FROM subject AS s LEFT JOIN record_subject_link AS rsl ON s.id=rsl.subject_id
+			GROUP BY s.id`
+
+	const expertQuery = `
+		INSERT INTO vsuggestion (kind,ref_id,popularity,text)
+			SELECT '` + SuggestionExpert + `', id, IFNULL(total_publication_count,0), first_name || ' ' || last_name
+			FROM expert`
+
+	const recordQuery = `
+		INSERT INTO vsuggestion (kind,ref_id,popularity,text)
+			SELECT '` + SuggestionRecord + `', id, 0, title
+			FROM record`
+
+	tx, err := st.db.Begin()
+	if err != nil {
+		log.Printf("Database error. Could not initialize transaction for building suggestion index. %s", err)
+		return
+	}
+
+	for _, query := range []string{"DELETE FROM vsuggestion", subjectQuery, expertQuery, recordQuery} {
+		if _, err = tx.Exec(query); err != nil {
+			tx.Rollback()
+			log.Printf("Database error. Could not build suggestion index. %s", err)
+			return
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Printf("Database error. Could not commit transaction for building suggestion index. %s", err)
+	}
+
+	return
+}
+
+// ReadSuggestions returns the suggestions of the specified kind (e.g. SuggestionSubject), that complete a user's
+// input. Each word of the input must be a prefix of a word in the suggestion (e.g. "fin eco" is completed by
+// "Financial Economics"). Suggestions that start with the input are ranked first, followed by the more popular ones.
+// An empty list is returned if the input contains no words.
+func (st *Storage) ReadSuggestions(input string, kind string, limit int64) (suggestions ploc.Suggestions, err error) {
+
+	const query = `
+		SELECT ref_id, text
+		FROM vsuggestion
+		WHERE vsuggestion MATCH ? AND kind=?
+		ORDER BY INSTR(LOWER(text),LOWER(?))=1 DESC, popularity DESC, rank ASC, LENGTH(text) ASC
+		LIMIT ?`
+
+	suggestions = ploc.Suggestions{}
+
+	ftsQuery := prefixQuery(input)
+	if ftsQuery == "" {
+		return
+	}
+
+	rows, err := st.db.Query(query, ftsQuery, kind, strings.TrimSpace(input), limit)
+	if err != nil {
+		log.Printf("Database error. Querying suggestions failed. %s", err)
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+
+		var s ploc.Suggestion
+
+		if err = rows.Scan(&s.Id, &s.Text); err != nil {
+			log.Printf("Database error. Scanning suggestions failed. %s", err)
+			return
+		}
+
+		suggestions = append(suggestions, s)
+	}
+
+	return
+}
+
+// prefixQuery translates a user's input to an FTS5 query, that matches all words of the input as prefixes.
+// Returns an empty query if the input contains no words.
+func prefixQuery(input string) string {
+
+	words := strings.FieldsFunc(input, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	for i, w := range words {
+		words[i] = `"` + w + `" *`
+	}
+
+	return strings.Join(words, " AND ")
+}
@@ -0,0 +1,171 @@
+package storage
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"time"
+)
+
+import (
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/model"
+)
+
+// FeedRecord encapsulates a record of a user's record feed together with its ID, as it is rendered in syndication
+// feeds (Atom or RSS).
+type FeedRecord struct {
+	Id int64
+	model.Record
+}
+
+// CreateFeedToken creates a fresh random token, that grants read access to the syndication feeds of a user.
+// An already existing token of the user is revoked. Only a hash of the token is stored.
+func (st *Storage) CreateFeedToken(uid int64) (token string, err error) {
+
+	if token, err = randomToken(); err != nil {
+		log.Printf("Could not create random feed token. %s", err)
+		return "", err
+	}
+
+	_, err = st.db.Exec("INSERT OR REPLACE INTO feed_token (user_id,hashed_token) VALUES (?,?)", uid, hashToken(token))
+	if err != nil {
+		log.Printf("Database error. Could not store feed token. %s", err)
+		return "", err
+	}
+
+	return token, nil
+}
+
+// DeleteFeedToken revokes the feed token of a user, so that the user's syndication feeds are no longer accessible.
+func (st *Storage) DeleteFeedToken(uid int64) (err error) {
+
+	_, err = st.db.Exec("DELETE FROM feed_token WHERE user_id=?", uid)
+	if err != nil {
+		log.Printf("Database error. Could not delete feed token. %s", err)
+	}
+
+	return
+}
+
+// ReadRecordFeedEntries returns the first records of a user's record feed with their full bibliographic metadata.
+// Like the record feed of the ploc app, the entries exclude bookmarked records.
+func (st *Storage) ReadRecordFeedEntries(uid int64, limit int64) (records []FeedRecord, err error) {
+
+	const query = `
+		SELECT record_id
+		FROM record_feed
+		WHERE user_id=?
+			AND record_id NOT IN (SELECT record_id FROM record_bookmark WHERE user_id=?)
+		ORDER BY score DESC, rowid ASC LIMIT ?`
+
+	rows, err := st.db.Query(query, uid, uid, limit)
+	if err != nil {
+		log.Printf("Database error. Could not read record feed entries. %s", err)
+		return
+	}
+
+	var recordIds []int64
+
+	for rows.Next() {
+
+		var recordId int64
+
+		if err = rows.Scan(&recordId); err != nil {
+			rows.Close()
+			log.Printf("Database error. Scanning IDs of record feed entries failed. %s", err)
+			return
+		}
+
+		recordIds = append(recordIds, recordId)
+	}
+	rows.Close()
+
+	for _, recordId := range recordIds {
+
+		rec, err := readRecord(st.db, recordId)
+		if err != nil {
+			log.Printf("Database error. Could not read record feed entry with ID %d. %s", recordId, err)
+			return nil, err
+		}
+
+		records = append(records, FeedRecord{Id: recordId, Record: rec})
+	}
+
+	return records, nil
+}
+
+// UpdateFeedETag stores the entity tag of a user's syndication feed and returns the time when the feed has changed
+// last. If the entity tag differs from the stored one, the feed is considered as changed now.
+func (st *Storage) UpdateFeedETag(uid int64, etag string) (modified time.Time, err error) {
+
+	var storedETag, storedModified sql.NullString
+
+	err = st.db.QueryRow("SELECT etag,modified FROM feed_token WHERE user_id=?", uid).Scan(&storedETag, &storedModified)
+	if err != nil {
+		log.Printf("Database error. Could not read entity tag of feed. %s", err)
+		return
+	}
+
+	if storedETag.Valid && storedETag.String == etag && storedModified.Valid {
+		if modified, err = time.Parse(time.RFC3339, storedModified.String); err == nil {
+			return modified, nil
+		}
+	}
+
+	modified = time.Now().UTC().Truncate(time.Second)
+
+	_, err = st.db.Exec("UPDATE feed_token SET etag=?,modified=? WHERE user_id=?", etag, modified.Format(time.RFC3339), uid)
+	if err != nil {
+		log.Printf("Database error. Could not update entity tag of feed. %s", err)
+		return
+	}
+
+	return modified, nil
+}
+
+// UserByFeedToken returns the major user information like database ID, GUID and ORCiD identifier of the user that
+// owns a feed token. Returns nil if the token is unknown or was revoked.
+func (st *Storage) UserByFeedToken(token string) (user *model.User, err error) {
+
+	var u model.User
+	var orcId sql.NullString
+
+	const query = `
+		SELECT u.id,u.guid,u.hashed_secret,u.orcid,u.orcid_verified,u.role
+		FROM user AS u, feed_token AS t
+		WHERE t.hashed_token=? AND t.user_id=u.id`
+
+	err = st.db.QueryRow(query, hashToken(token)).Scan(&u.Id, &u.GUID, &u.HashedSecret, &orcId, &u.OrcIdVerified, &u.Role)
+
+	switch {
+	case err == sql.ErrNoRows:
+		return nil, nil
+	case err != nil:
+		return nil, err
+	}
+
+	u.OrcId = NullToString(orcId)
+
+	return &u, nil
+}
+
+// randomToken returns a fresh, hex-encoded random token (e.g. for feeds or refresh tokens).
+func randomToken() (string, error) {
+
+	b := make([]byte, 24)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// hashToken returns the hex-encoded SHA-256 hash of a feed or refresh token. Unlike user secrets, these tokens are
+// random and long enough, so that a fast, unsalted hash suffices and allows to look up tokens.
+func hashToken(token string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(token)))
+}
@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"database/sql"
+	"log"
+	"time"
+)
+
+// CreateOrcIdAuthorization registers a pending verification of a user's ORCiD via OAuth2, that lasts until the
+// specified expiry. The PKCE code verifier is kept until the provider's callback. Returns a fresh random state, that
+// relates the callback to the user. Only a hash of the state is stored. Expired authorizations are removed.
+func (st *Storage) CreateOrcIdAuthorization(uid int64, verifier string, expires time.Time) (state string, err error) {
+
+	if state, err = randomToken(); err != nil {
+		log.Printf("Could not create random state for ORCiD authorization. %s", err)
+		return "", err
+	}
+
+	if _, err = st.db.Exec("DELETE FROM orcid_authorization WHERE expires<=?", time.Now().Unix()); err != nil {
+		log.Printf("Database error. Could not delete expired ORCiD authorizations. %s", err)
+		return "", err
+	}
+
+	_, err = st.db.Exec("INSERT INTO orcid_authorization (hashed_state,user_id,code_verifier,expires) VALUES (?,?,?,?)",
+		hashToken(state), uid, verifier, expires.Unix())
+	if err != nil {
+		log.Printf("Database error. Could not store ORCiD authorization. %s", err)
+		return "", err
+	}
+
+	return state, nil
+}
+
+// DeleteOrcIdAuthorization removes the pending verification with the specified state and returns the user and the
+// PKCE code verifier of the verification. Each state can only be used once. Returns sql.ErrNoRows if the state is
+// unknown or has expired.
+func (st *Storage) DeleteOrcIdAuthorization(state string) (uid int64, verifier string, err error) {
+
+	tx, err := st.db.Begin()
+	if err != nil {
+		log.Printf("Database error. Could not initialize transaction for reading ORCiD authorization. %s", err)
+		return
+	}
+
+	err = tx.QueryRow("SELECT user_id,code_verifier FROM orcid_authorization WHERE hashed_state=? AND expires>?",
+		hashToken(state), time.Now().Unix()).Scan(&uid, &verifier)
+	if err != nil {
+		tx.Rollback()
+		if err != sql.ErrNoRows {
+			log.Printf("Database error. Could not read ORCiD authorization. %s", err)
+		}
+		return
+	}
+
+	if _, err = tx.Exec("DELETE FROM orcid_authorization WHERE hashed_state=?", hashToken(state)); err != nil {
+		tx.Rollback()
+		log.Printf("Database error. Could not delete ORCiD authorization. %s", err)
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Printf("Database error. Could not commit transaction for reading ORCiD authorization. %s", err)
+		return
+	}
+
+	return
+}
+
+// VerifyOrcId links an ORCiD, that the user has proven to own, to the user's profile and marks it as verified.
+// The name of the ORCiD's owner is kept for matching the user against experts of the catalogue (see CreateExpertClaim).
+// A verified ORCiD belongs to a single user. If another user has verified the same ORCiD before (e.g. on a former
+// device), the other user's ORCiD is no longer verified.
+func (st *Storage) VerifyOrcId(uid int64, orcId string, name string) (err error) {
+
+	tx, err := st.db.Begin()
+	if err != nil {
+		log.Printf("Database error. Could not initialize transaction for verifying ORCiD. %s", err)
+		return
+	}
+
+	if _, err = tx.Exec("UPDATE user SET orcid_verified=0 WHERE orcid=? AND id<>?", orcId, uid); err != nil {
+		tx.Rollback()
+		log.Printf("Database error. Could not revoke verification of ORCiD for other users. %s", err)
+		return
+	}
+
+	if _, err = tx.Exec("UPDATE user SET orcid=?, orcid_verified=1, orcid_name=? WHERE id=?", orcId, StringToNull(name), uid); err != nil {
+		tx.Rollback()
+		log.Printf("Database error. Could not verify ORCiD for user %d. %s", uid, err)
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Printf("Database error. Could not commit transaction for verifying ORCiD. %s", err)
+		return
+	}
+
+	return
+}
@@ -0,0 +1,5 @@
+/*
+Package syndication renders publication records as web feeds in the Atom and RSS 2.0 formats, so that a user's
+record feed can be followed with any desktop feed reader.
+*/
+package syndication
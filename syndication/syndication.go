@@ -0,0 +1,189 @@
+package syndication
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Media types of the supported feed formats.
+const (
+	MediaTypeAtom = "application/atom+xml"
+	MediaTypeRSS  = "application/rss+xml"
+)
+
+// Feed encapsulates the format-independent content of a web feed.
+type Feed struct {
+	Id      string    // globally unique and permanent identifier of the feed
+	Title   string    // human-readable title
+	Link    string    // URL of the feed itself
+	Updated time.Time // time when the feed content has changed last
+	Entries []Entry
+}
+
+// Entry encapsulates a publication record as entry of a web feed.
+type Entry struct {
+	Id       string   // globally unique and permanent identifier of the entry
+	Title    string   // title of the publication
+	Creators []string // full names of the authors
+	Abstract string   // abstract of the publication (optional)
+	Year     int64    // year of publication
+	Link     string   // link to the publication in its repository (optional)
+}
+
+// Atom 1.0 document structure (see RFC 4287)
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Id      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomEntry struct {
+	Id        string       `xml:"id"`
+	Title     string       `xml:"title"`
+	Updated   string       `xml:"updated"`
+	Published string       `xml:"published"`
+	Authors   []atomPerson `xml:"author"`
+	Links     []atomLink   `xml:"link"`
+	Summary   string       `xml:"summary,omitempty"`
+}
+
+// RSS 2.0 document structure (see https://www.rssboard.org/rss-specification). Creators are given by the Dublin Core
+// element dc:creator, since the RSS author element requires e-mail addresses.
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	DC      string     `xml:"xmlns:dc,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssGUID struct {
+	IsPermaLink string `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link,omitempty"`
+	Description string  `xml:"description,omitempty"`
+	Creator     string  `xml:"dc:creator,omitempty"`
+	PubDate     string  `xml:"pubDate"`
+	GUID        rssGUID `xml:"guid"`
+}
+
+// WriteAtom renders a feed as Atom 1.0 document. Since the exact publication date of records is unknown, entries are
+// dated to the beginning of their year of publication.
+func WriteAtom(w io.Writer, feed *Feed) error {
+
+	doc := atomFeed{
+		Id:      feed.Id,
+		Title:   feed.Title,
+		Updated: feed.Updated.UTC().Format(time.RFC3339),
+		Links:   []atomLink{{Rel: "self", Href: feed.Link}},
+	}
+
+	for _, e := range feed.Entries {
+
+		date := yearDate(e.Year).Format(time.RFC3339)
+
+		entry := atomEntry{
+			Id:        e.Id,
+			Title:     e.Title,
+			Updated:   date,
+			Published: date,
+			Summary:   e.Abstract,
+		}
+
+		for _, name := range e.Creators {
+			entry.Authors = append(entry.Authors, atomPerson{Name: name})
+		}
+
+		if e.Link != "" {
+			entry.Links = append(entry.Links, atomLink{Rel: "alternate", Href: e.Link})
+		}
+
+		doc.Entries = append(doc.Entries, entry)
+	}
+
+	return writeXML(w, doc)
+}
+
+// WriteRSS renders a feed as RSS 2.0 document. Like in Atom feeds, items are dated to the beginning of their year of
+// publication.
+func WriteRSS(w io.Writer, feed *Feed) error {
+
+	doc := rssFeed{
+		Version: "2.0",
+		DC:      "http://purl.org/dc/elements/1.1/",
+		Channel: rssChannel{
+			Title:         feed.Title,
+			Link:          feed.Link,
+			Description:   feed.Title,
+			LastBuildDate: feed.Updated.UTC().Format(time.RFC1123Z),
+		},
+	}
+
+	for _, e := range feed.Entries {
+
+		doc.Channel.Items = append(doc.Channel.Items, rssItem{
+			Title:       e.Title,
+			Link:        e.Link,
+			Description: e.Abstract,
+			Creator:     strings.Join(e.Creators, ", "),
+			PubDate:     yearDate(e.Year).Format(time.RFC1123Z),
+			GUID:        rssGUID{IsPermaLink: "false", Value: e.Id},
+		})
+	}
+
+	return writeXML(w, doc)
+}
+
+// writeXML writes an XML document with declaration and indentation.
+func writeXML(w io.Writer, doc interface{}) error {
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("Could not write feed. %s", err)
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("Could not encode feed. %s", err)
+	}
+
+	if _, err := io.WriteString(w, "\n"); err != nil {
+		return fmt.Errorf("Could not write feed. %s", err)
+	}
+
+	return nil
+}
+
+// yearDate returns the first day of a year.
+func yearDate(year int64) time.Time {
+	return time.Date(int(year), time.January, 1, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,104 @@
+package syndication
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+	"time"
+)
+
+var testFeed = Feed{
+	Id:      "urn:uuid:6ba7b811-9dad-11d1-80b4-00c04fd430c8",
+	Title:   "Ploc record feed",
+	Link:    "https://example.org/syndication/records.atom?token=abc&x=1",
+	Updated: time.Date(2019, time.May, 3, 12, 30, 0, 0, time.UTC),
+	Entries: []Entry{
+		{
+			Id:       "urn:uuid:6ba7b812-9dad-11d1-80b4-00c04fd430c8",
+			Title:    "Mortgage default in an estimated model of the U.S. housing market",
+			Creators: []string{"Luisa Lambertini", "Pinar Uysal"},
+			Abstract: "Prices & <quantities>",
+			Year:     2017,
+			Link:     "http://hdl.handle.net/10419/174459",
+		},
+		{
+			Id:    "urn:uuid:6ba7b813-9dad-11d1-80b4-00c04fd430c8",
+			Title: "Zipf zipped",
+			Year:  2004,
+		},
+	},
+}
+
+func TestWriteAtom(t *testing.T) {
+
+	var buf bytes.Buffer
+
+	if err := WriteAtom(&buf, &testFeed); err != nil {
+		t.Errorf("Unexpected error. %s", err)
+		return
+	}
+
+	var doc atomFeed
+
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Errorf("Could not parse Atom feed. %s", err)
+		return
+	}
+
+	if doc.Updated != "2019-05-03T12:30:00Z" || doc.Links[0].Href != testFeed.Link || len(doc.Entries) != 2 {
+		t.Errorf("Unexpected Atom feed %+v.", doc)
+		return
+	}
+
+	entry := doc.Entries[0]
+
+	if entry.Published != "2017-01-01T00:00:00Z" || len(entry.Authors) != 2 || entry.Authors[1].Name != "Pinar Uysal" ||
+		entry.Summary != "Prices & <quantities>" || entry.Links[0].Href != "http://hdl.handle.net/10419/174459" {
+		t.Errorf("Unexpected Atom entry %+v.", entry)
+		return
+	}
+
+	if len(doc.Entries[1].Links) != 0 || len(doc.Entries[1].Authors) != 0 {
+		t.Errorf("Expected no links and authors for second entry, but got %+v.", doc.Entries[1])
+		return
+	}
+}
+
+func TestWriteRSS(t *testing.T) {
+
+	var buf bytes.Buffer
+
+	if err := WriteRSS(&buf, &testFeed); err != nil {
+		t.Errorf("Unexpected error. %s", err)
+		return
+	}
+
+	var doc struct {
+		Version string `xml:"version,attr"`
+		Items   []struct {
+			Title   string `xml:"title"`
+			Link    string `xml:"link"`
+			Creator string `xml:"http://purl.org/dc/elements/1.1/ creator"`
+			PubDate string `xml:"pubDate"`
+			GUID    string `xml:"guid"`
+		} `xml:"channel>item"`
+	}
+
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Errorf("Could not parse RSS feed. %s", err)
+		return
+	}
+
+	if doc.Version != "2.0" || len(doc.Items) != 2 {
+		t.Errorf("Unexpected RSS feed %+v.", doc)
+		return
+	}
+
+	item := doc.Items[0]
+
+	if item.Creator != "Luisa Lambertini, Pinar Uysal" || item.PubDate != "Sun, 01 Jan 2017 00:00:00 +0000" ||
+		item.GUID != testFeed.Entries[0].Id || item.Link != testFeed.Entries[0].Link {
+		t.Errorf("Unexpected RSS item %+v.", item)
+		return
+	}
+}
@@ -0,0 +1,484 @@
+package webapi
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+import (
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/model"
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/model/admin"
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/storage"
+)
+
+// Default and maximum number of users, feedbacks or audit entries, that are returned by the admin API at once.
+const (
+	defaultAdminLimit = 50
+	maxAdminLimit     = 500
+)
+
+// adminHandler encapsulates a Web request handler of the admin API. Users authenticate like for the ploc API (see
+// authorizationHandler), but only users with the role of an administrator are permitted.
+func adminHandler(handler func(http.ResponseWriter, *http.Request, *model.User), c *Context) http.HandlerFunc {
+
+	return authorizationHandler(func(w http.ResponseWriter, r *http.Request, u *model.User) {
+
+		if !u.IsAdmin() {
+			handleForbiddenRequest(w, fmt.Sprintf("Admin request on '%s' denied. User with GUID '%s' is not an administrator.", r.URL.Path, u.GUID))
+			return
+		}
+
+		handler(w, r, u)
+	}, c)
+}
+
+// approveExpertClaim is a Web request handler that lets an administrator approve a pending claim of an expert, which
+// binds the expert to the ORCiD of the claiming user.
+func (c *Context) approveExpertClaim(w http.ResponseWriter, r *http.Request, u *model.User) {
+
+	// Declare request and response data structures
+
+	var request admin.UpdateExpertClaimRequest
+
+	// Unmarshal request
+
+	if err := readRequest(w, r, &request); err != nil {
+		handleInternalError(w, "Could not decode HTTP request. Body does not seem to contain the right JSON datastructure.", err)
+		return
+	}
+
+	// Update database
+
+	err := c.db.ApproveExpertClaim(request.ClaimId)
+	switch {
+	case err == sql.ErrNoRows:
+		handleBadRequest(w, fmt.Sprintf("Could not approve claim. Claim %d is not pending or its ORCiD is not verified.", request.ClaimId))
+		return
+	case err == storage.ErrExpertClaimed:
+		handleMalformedRequest(w, err)
+		return
+	case err != nil:
+		handleInternalError(w, "Database error. Could not approve expert claim.", err)
+		return
+	}
+
+	c.audit(u, "expert-claim/approve", strconv.FormatInt(request.ClaimId, 10))
+
+	// Write response (no payload)
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// buildSearchIndex is a Web request handler that lets an administrator rebuild the full text search indices, the
+// suggestion index and the index of related records.
+func (c *Context) buildSearchIndex(w http.ResponseWriter, r *http.Request, u *model.User) {
+
+	// Update database
+
+	if err := c.db.BuildSearchIndicies(); err != nil {
+		handleInternalError(w, "Database error. Could not build search indices.", err)
+		return
+	}
+
+	c.audit(u, "search-index/build", "")
+
+	// Write response (no payload)
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// deleteFeedback is a Web request handler that lets an administrator remove inappropriate feedback of a user.
+// The feedback is deleted locally but remains in the public ledger.
+func (c *Context) deleteFeedback(w http.ResponseWriter, r *http.Request, u *model.User) {
+
+	// Declare request and response data structures
+
+	var request admin.DeleteFeedbackRequest
+
+	// Unmarshal request
+
+	if err := readRequest(w, r, &request); err != nil {
+		handleInternalError(w, "Could not decode HTTP request. Body does not seem to contain the right JSON datastructure.", err)
+		return
+	}
+
+	// Update database
+
+	user, ok := c.userByGUID(w, request.GUID)
+	if !ok {
+		return
+	}
+
+	err := c.db.DeleteFeedback(user.Id, request.RecordId)
+	switch {
+	case err == sql.ErrNoRows:
+		handleBadRequest(w, fmt.Sprintf("Could not delete feedback. User with GUID '%s' has no feedback for record %d.", request.GUID, request.RecordId))
+		return
+	case err != nil:
+		handleInternalError(w, "Database error. Could not delete feedback.", err)
+		return
+	}
+
+	c.audit(u, "feedback/delete", fmt.Sprintf("%s/%d", request.GUID, request.RecordId))
+
+	// Write response (no payload)
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// deleteUser is a Web request handler that lets an administrator delete a user and all user-related information.
+// The feedback of the user is deleted locally but remains in the public ledger.
+func (c *Context) deleteUser(w http.ResponseWriter, r *http.Request, u *model.User) {
+
+	// Declare request and response data structures
+
+	var request admin.DeleteUserRequest
+
+	// Unmarshal request
+
+	if err := readRequest(w, r, &request); err != nil {
+		handleInternalError(w, "Could not decode HTTP request. Body does not seem to contain the right JSON datastructure.", err)
+		return
+	}
+
+	// Update database
+
+	user, ok := c.userByGUID(w, request.GUID)
+	if !ok {
+		return
+	}
+
+	if err := c.db.DeleteUserById(user.Id); err != nil {
+		handleInternalError(w, "Database error. Could not delete user.", err)
+		return
+	}
+
+	c.audit(u, "user/delete", request.GUID)
+
+	// Write response (no payload)
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// readAllFeedback is a Web request handler that returns a segment of the feedback of all users for moderation, most
+// recent first.
+func (c *Context) readAllFeedback(w http.ResponseWriter, r *http.Request, u *model.User) {
+
+	// Declare request and response data structures
+
+	var request admin.ReadFeedbackRequest
+	var response admin.ReadFeedbackResponse
+
+	// Unmarshal request
+
+	if err := readRequest(w, r, &request); err != nil {
+		handleInternalError(w, "Could not decode HTTP request. Body does not seem to contain the right JSON datastructure.", err)
+		return
+	}
+
+	// Read feedback from database
+
+	offset, limit := adminSegment(request.Offset, request.Limit)
+
+	feedbacks, err := c.db.ReadAllFeedback(offset, limit)
+	if err != nil {
+		handleInternalError(w, "Database error. Could not read feedback.", err)
+		return
+	}
+
+	// Build response
+
+	response.Offset = offset
+	response.Limit = limit
+	response.Feedbacks = feedbacks
+
+	// Respond
+
+	writeResponse(w, response)
+}
+
+// readAuditLog is a Web request handler that returns a segment of the audit log, most recent first.
+func (c *Context) readAuditLog(w http.ResponseWriter, r *http.Request, u *model.User) {
+
+	// Declare request and response data structures
+
+	var request admin.ReadAuditLogRequest
+	var response admin.ReadAuditLogResponse
+
+	// Unmarshal request
+
+	if err := readRequest(w, r, &request); err != nil {
+		handleInternalError(w, "Could not decode HTTP request. Body does not seem to contain the right JSON datastructure.", err)
+		return
+	}
+
+	// Read audit log from database
+
+	offset, limit := adminSegment(request.Offset, request.Limit)
+
+	entries, err := c.db.ReadAuditLog(offset, limit)
+	if err != nil {
+		handleInternalError(w, "Database error. Could not read audit log.", err)
+		return
+	}
+
+	// Build response
+
+	response.Offset = offset
+	response.Limit = limit
+	response.Entries = entries
+
+	// Respond
+
+	writeResponse(w, response)
+}
+
+// readIngestStatus is a Web request handler that returns the number of records and experts and the most recent runs
+// of the harvester.
+func (c *Context) readIngestStatus(w http.ResponseWriter, r *http.Request, u *model.User) {
+
+	// Declare request and response data structures
+
+	var response admin.ReadIngestStatusResponse
+
+	// Read ingest status from database
+
+	records, experts, runs, err := c.db.ReadIngestStatus()
+	if err != nil {
+		handleInternalError(w, "Database error. Could not read ingest status.", err)
+		return
+	}
+
+	// Build response
+
+	response.Records = records
+	response.Experts = experts
+	response.HarvestRuns = runs
+
+	// Respond
+
+	writeResponse(w, response)
+}
+
+// readPendingExpertClaims is a Web request handler that returns all claims of experts, that await the approval of an
+// administrator, oldest first.
+func (c *Context) readPendingExpertClaims(w http.ResponseWriter, r *http.Request, u *model.User) {
+
+	// Declare request and response data structures
+
+	var response admin.ReadExpertClaimsResponse
+
+	// Read claims from database
+
+	claims, err := c.db.ReadPendingExpertClaims()
+	if err != nil {
+		handleInternalError(w, "Database error. Could not read pending expert claims.", err)
+		return
+	}
+
+	// Build response
+
+	response.Claims = claims
+
+	// Respond
+
+	writeResponse(w, response)
+}
+
+// readStatistics is a Web request handler that returns aggregated numbers about the users and the database content.
+func (c *Context) readStatistics(w http.ResponseWriter, r *http.Request, u *model.User) {
+
+	// Declare request and response data structures
+
+	var response admin.ReadStatisticsResponse
+
+	// Read statistics from database
+
+	stats, err := c.db.ReadStatistics()
+	if err != nil {
+		handleInternalError(w, "Database error. Could not read statistics.", err)
+		return
+	}
+
+	// Build response
+
+	response.Statistics = stats
+
+	// Respond
+
+	writeResponse(w, response)
+}
+
+// readUsers is a Web request handler that returns a segment of all users, ordered by their registration.
+func (c *Context) readUsers(w http.ResponseWriter, r *http.Request, u *model.User) {
+
+	// Declare request and response data structures
+
+	var request admin.ReadUsersRequest
+	var response admin.ReadUsersResponse
+
+	// Unmarshal request
+
+	if err := readRequest(w, r, &request); err != nil {
+		handleInternalError(w, "Could not decode HTTP request. Body does not seem to contain the right JSON datastructure.", err)
+		return
+	}
+
+	// Read users from database
+
+	offset, limit := adminSegment(request.Offset, request.Limit)
+
+	users, err := c.db.ReadUsers(offset, limit)
+	if err != nil {
+		handleInternalError(w, "Database error. Could not read users.", err)
+		return
+	}
+
+	// Build response
+
+	response.Offset = offset
+	response.Limit = limit
+	response.Users = users
+
+	// Respond
+
+	writeResponse(w, response)
+}
+
+// rebuildFeeds is a Web request handler that lets an administrator precompute the record and expert feeds of all users.
+func (c *Context) rebuildFeeds(w http.ResponseWriter, r *http.Request, u *model.User) {
+
+	// Update database
+
+	if err := c.db.RebuildAllFeeds(); err != nil {
+		handleInternalError(w, "Database error. Could not rebuild feeds.", err)
+		return
+	}
+
+	c.audit(u, "feeds/rebuild", "")
+
+	// Write response (no payload)
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// rejectExpertClaim is a Web request handler that lets an administrator reject a pending claim of an expert.
+func (c *Context) rejectExpertClaim(w http.ResponseWriter, r *http.Request, u *model.User) {
+
+	// Declare request and response data structures
+
+	var request admin.UpdateExpertClaimRequest
+
+	// Unmarshal request
+
+	if err := readRequest(w, r, &request); err != nil {
+		handleInternalError(w, "Could not decode HTTP request. Body does not seem to contain the right JSON datastructure.", err)
+		return
+	}
+
+	// Update database
+
+	err := c.db.RejectExpertClaim(request.ClaimId)
+	switch {
+	case err == sql.ErrNoRows:
+		handleBadRequest(w, fmt.Sprintf("Could not reject claim. Claim %d is not pending.", request.ClaimId))
+		return
+	case err != nil:
+		handleInternalError(w, "Database error. Could not reject expert claim.", err)
+		return
+	}
+
+	c.audit(u, "expert-claim/reject", strconv.FormatInt(request.ClaimId, 10))
+
+	// Write response (no payload)
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// updateUserRole is a Web request handler that lets an administrator grant or revoke the role of an administrator.
+func (c *Context) updateUserRole(w http.ResponseWriter, r *http.Request, u *model.User) {
+
+	// Declare request and response data structures
+
+	var request admin.UpdateUserRoleRequest
+
+	// Unmarshal request
+
+	if err := readRequest(w, r, &request); err != nil {
+		handleInternalError(w, "Could not decode HTTP request. Body does not seem to contain the right JSON datastructure.", err)
+		return
+	}
+
+	// Check input types
+
+	if err := model.ValidateRole(request.Role); err != nil {
+		handleMalformedRequest(w, err)
+		return
+	}
+
+	// Update database
+
+	err := c.db.UpdateUserRole(request.GUID, request.Role)
+	switch {
+	case err == sql.ErrNoRows:
+		handleBadRequest(w, fmt.Sprintf("Could not update role. User with GUID '%s' does not exist.", request.GUID))
+		return
+	case err != nil:
+		handleInternalError(w, "Database error. Could not update role of user.", err)
+		return
+	}
+
+	c.audit(u, "user-role/update", request.GUID+"="+request.Role)
+
+	// Write response (no payload)
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// audit writes an action of an administrator to the audit log. The action has already been performed, so a failure
+// to write the log is only logged and does not fail the request.
+func (c *Context) audit(u *model.User, action string, target string) {
+
+	if err := c.db.CreateAuditEntry(u.GUID, action, target); err != nil {
+		log.Printf("Action '%s' on '%s' of administrator with GUID '%s' is missing in the audit log.", action, target, u.GUID)
+	}
+}
+
+// userByGUID reads the user with the GUID, that is the target of an administrator's request. If the user does not
+// exist, an error response is written and false is returned.
+func (c *Context) userByGUID(w http.ResponseWriter, guid string) (user *model.User, ok bool) {
+
+	user, err := c.db.UserByGUID(guid)
+	if err != nil {
+		handleInternalError(w, "Database error. Could not read user by GUID.", err)
+		return nil, false
+	}
+
+	if user == nil {
+		handleBadRequest(w, fmt.Sprintf("User with GUID '%s' does not exist.", guid))
+		return nil, false
+	}
+
+	return user, true
+}
+
+// adminSegment returns the offset and limit of a segment requested via the admin API. A missing limit is replaced by
+// the default, and limits above the maximum are reduced.
+func adminSegment(offset int64, limit int64) (int64, int64) {
+
+	if offset < 0 {
+		offset = 0
+	}
+
+	if limit <= 0 {
+		limit = defaultAdminLimit
+	}
+	if limit > maxAdminLimit {
+		limit = maxAdminLimit
+	}
+
+	return offset, limit
+}
@@ -2,25 +2,52 @@ package webapi
 
 import (
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"log"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 )
 
 import (
 	"github.com/fzi-forschungszentrum-informatik/dream-gozer/model"
-	"github.com/fzi-forschungszentrum-informatik/dream-gozer/storage"
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/model/ploc"
 	"github.com/google/uuid"
 )
 
-// authorizationHandler encapsulates a Web request handler that requires user authentication.
-func authorizationHandler(handler func(http.ResponseWriter, *http.Request, *model.User), st *storage.Storage) http.HandlerFunc {
+// authorizationHandler encapsulates a Web request handler that requires user authentication. Users authenticate
+// either with a signed access token of a session (HTTP Bearer authentication) or with their GUID and secret (HTTP
+// BasicAuth). Access tokens are preferred, since checking the hashed secret is expensive. Requests are limited per
+// client IP address and per authenticated user. Failed authorizations temporarily lock the client IP address and its
+// attempts for the GUID, but never the GUID itself (see RateLimitConfiguration).
+func authorizationHandler(handler func(http.ResponseWriter, *http.Request, *model.User), c *Context) http.HandlerFunc {
 
 	return func(w http.ResponseWriter, r *http.Request) {
 
 		startTime := time.Now()
 
+		if !c.allowIP(w, r) {
+			return
+		}
+
+		if token := bearerToken(r); token != "" {
+
+			user, ok := c.userByAccessToken(w, token)
+			if !ok || !c.allowGUID(w, user.GUID) {
+				return
+			}
+
+			log.Printf("Processing authorized %s-request on '%s' with GUID '%s' (session).", r.Method, r.URL, user.GUID)
+
+			handler(w, r, user)
+
+			log.Printf("Total response time: %v", time.Since(startTime))
+			return
+		}
+
 		guid, secret, ok := r.BasicAuth()
 
 		if !ok {
@@ -33,11 +60,17 @@ func authorizationHandler(handler func(http.ResponseWriter, *http.Request, *mode
 
 		if err != nil {
 			log.Printf("Autorization failue. GUID '%s' seems to be malformed. Could not be parsed.", guid)
+			c.authorizationFailed(r, "")
 			http.Error(w, "Authorization Error", http.StatusUnauthorized)
 			return
 		}
 
-		user, err := st.UserByGUID(guid)
+		// Locked attempts are rejected before checking the secret, which is expensive.
+		if !c.allowAttempt(w, r, guid) {
+			return
+		}
+
+		user, err := c.db.UserByGUID(guid)
 
 		if err != nil {
 			handleInternalError(w, "Internal database error while reading user credentials.", err)
@@ -46,6 +79,7 @@ func authorizationHandler(handler func(http.ResponseWriter, *http.Request, *mode
 
 		if user == nil {
 			log.Printf("Autorization failue. User with GUID '%s' does not exist.", guid)
+			c.authorizationFailed(r, guid)
 			http.Error(w, "Authorization Error", http.StatusUnauthorized)
 			return
 		}
@@ -54,10 +88,17 @@ func authorizationHandler(handler func(http.ResponseWriter, *http.Request, *mode
 
 		if err != nil {
 			log.Printf("Authorization failure. %s", err)
+			c.authorizationFailed(r, guid)
 			http.Error(w, "Authorization Error", http.StatusUnauthorized)
 			return
 		}
 
+		c.authorizationSucceeded(r, guid)
+
+		if !c.allowGUID(w, guid) {
+			return
+		}
+
 		log.Printf("Processing authorized %s-request on '%s' with GUID '%s'.", r.Method, r.URL, user.GUID)
 
 		handler(w, r, user)
@@ -66,6 +107,85 @@ func authorizationHandler(handler func(http.ResponseWriter, *http.Request, *mode
 	}
 }
 
+// bearerToken returns the token of a request's HTTP Bearer authentication, or an empty string.
+func bearerToken(r *http.Request) string {
+
+	const prefix = "Bearer "
+
+	auth := r.Header.Get("Authorization")
+	if len(auth) <= len(prefix) || !strings.EqualFold(auth[:len(prefix)], prefix) {
+		return ""
+	}
+
+	return strings.TrimSpace(auth[len(prefix):])
+}
+
+// userByAccessToken verifies an access token and returns the user of its session. If the token is invalid, has
+// expired or its session was revoked, an error response is written and false is returned.
+func (c *Context) userByAccessToken(w http.ResponseWriter, token string) (user *model.User, ok bool) {
+
+	sessionId, err := verifyAccessToken(c.tokenKey, token)
+	if err != nil {
+		log.Printf("Autorization failue. %s", err)
+		http.Error(w, "Authorization Error", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	user, err = c.db.UserBySession(sessionId)
+	if err != nil {
+		handleInternalError(w, "Internal database error while reading user by session.", err)
+		return nil, false
+	}
+
+	if user == nil {
+		log.Printf("Autorization failue. Session %d has expired or was revoked.", sessionId)
+		http.Error(w, "Authorization Error", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	return user, true
+}
+
+// feedTokenHandler encapsulates a Web request handler for feed readers, that authenticates users by the feed token
+// given as URL parameter "token". Feed readers commonly do not support other kinds of authentication. Unknown feed tokens
+// count as failed authorizations of the client's IP address.
+func feedTokenHandler(handler func(http.ResponseWriter, *http.Request, *model.User), c *Context) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		startTime := time.Now()
+
+		token := r.URL.Query().Get("token")
+
+		if token == "" {
+			log.Printf("Autorization failue. Request on '%s' seems to miss a feed token.", r.URL.Path)
+			http.Error(w, "Authorization Error", http.StatusUnauthorized)
+			return
+		}
+
+		user, err := c.db.UserByFeedToken(token)
+
+		if err != nil {
+			handleInternalError(w, "Internal database error while reading user by feed token.", err)
+			return
+		}
+
+		if user == nil {
+			c.authorizationFailed(r, "")
+			log.Printf("Autorization failue. Feed token for '%s' is unknown or was revoked.", r.URL.Path)
+			http.Error(w, "Authorization Error", http.StatusUnauthorized)
+			return
+		}
+
+		// The URL is logged without its query, so that feed tokens do not show up in log files.
+		log.Printf("Processing feed %s-request on '%s' with GUID '%s'.", r.Method, r.URL.Path, user.GUID)
+
+		handler(w, r, user)
+
+		log.Printf("Total response time: %v", time.Since(startTime))
+	}
+}
+
 // authorizationHandler encapsulates a Web request handler that requires no user authentication.
 func defaultHandler(handler func(http.ResponseWriter, *http.Request)) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -82,6 +202,28 @@ func handleBadRequest(w http.ResponseWriter, msg string) {
 	http.Error(w, "Not Implemented Error", http.StatusBadRequest)
 }
 
+// handleForbiddenRequest writes a standard response to the client in the case an authorized user is not permitted to
+// perform the request (e.g. giving feedback without a verified ORCiD).
+func handleForbiddenRequest(w http.ResponseWriter, msg string) {
+	log.Print(msg)
+	http.Error(w, "Forbidden Error", http.StatusForbidden)
+}
+
+// handleTooManyRequests writes a standard response to the client in the case the client has exceeded a rate limit or
+// is locked due to failed authorizations. The response tells the client how many seconds to wait before retrying.
+func handleTooManyRequests(w http.ResponseWriter, retryAfter time.Duration, msg string) {
+	log.Print(msg)
+	w.Header().Set("Retry-After", strconv.FormatInt(int64(math.Ceil(retryAfter.Seconds())), 10))
+	http.Error(w, "Too Many Requests Error", http.StatusTooManyRequests)
+}
+
+// handleMalformedRequest writes a response to the client in the case the request has malformed parameters, that the
+// user can correct (e.g. a search query). Unlike handleBadRequest, the response contains the error message.
+func handleMalformedRequest(w http.ResponseWriter, err error) {
+	log.Printf("Malformed request. %s", err)
+	http.Error(w, err.Error(), http.StatusBadRequest)
+}
+
 // handleBadRequest writes a standard response to the client in the case a handler fails to complete.
 func handleInternalError(w http.ResponseWriter, msg string, err error) {
 	log.Printf("%s %s", msg, err)
@@ -125,3 +267,69 @@ func writeResponse(w http.ResponseWriter, response interface{}) error {
 
 	return nil
 }
+
+// requestBaseURL returns the scheme and host of the URL that a client has used for a request (e.g.
+// "https://example.org"), considering the X-Forwarded-Proto header of reverse proxies.
+func requestBaseURL(r *http.Request) string {
+
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+
+	return scheme + "://" + r.Host
+}
+
+// validateRecordFeedFilter checks a record feed filter of a client for contradicting or unknown values.
+func validateRecordFeedFilter(filter *ploc.RecordFeedFilter) error {
+
+	if filter.YearFrom != 0 && filter.YearTo != 0 && filter.YearFrom > filter.YearTo {
+		return fmt.Errorf("Year range of filter is empty. Year %d is after year %d.", filter.YearFrom, filter.YearTo)
+	}
+
+	for _, t := range filter.Types {
+		if _, ok := recordTypeKeyword(t); !ok {
+			return fmt.Errorf("Unknown record type %d in filter.", t)
+		}
+	}
+
+	return nil
+}
+
+// labelRecordFeedFacets sets human-readable labels for the years, types and open access states of record feed facets.
+// Subjects are already labeled by the database.
+func labelRecordFeedFacets(facets ploc.RecordFeedFacets) ploc.RecordFeedFacets {
+
+	for i, fc := range facets.Years {
+		facets.Years[i].Label = fmt.Sprintf("%d", fc.Value)
+	}
+
+	for i, fc := range facets.Types {
+		facets.Types[i].Label, _ = recordTypeKeyword(fc.Value)
+	}
+
+	for i, fc := range facets.OpenAccess {
+		switch {
+		case fc.Value > 0:
+			facets.OpenAccess[i].Label = "Open access"
+		case fc.Value < 0:
+			facets.OpenAccess[i].Label = "Closed access"
+		default:
+			facets.OpenAccess[i].Label = "Unknown"
+		}
+	}
+
+	return facets
+}
+
+// recordTypeKeyword returns the keyword of a record type (e.g. "Article").
+func recordTypeKeyword(id int64) (keyword string, ok bool) {
+
+	for _, t := range recordTypes {
+		if t.Id == id {
+			return t.Keyword, true
+		}
+	}
+
+	return "", false
+}
@@ -1,7 +1,14 @@
 package webapi
 
+import (
+	"crypto/rand"
+	"log"
+)
+
 import (
 	"github.com/fzi-forschungszentrum-informatik/dream-gozer/config"
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/orcid"
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/ratelimit"
 	"github.com/fzi-forschungszentrum-informatik/dream-gozer/storage"
 	"github.com/fzi-forschungszentrum-informatik/dream-gozer/storage/ledger"
 )
@@ -9,12 +16,38 @@ import (
 // Context defines a state of information, in which a HTTP request is interpreted.
 // In GoZer this state is composed by the state of the database and the configuration file.
 type Context struct {
-	conf   *config.WebAPIConfiguration
-	db     *storage.Storage
-	ledger *ledger.Ledger
+	conf     *config.WebAPIConfiguration
+	db       *storage.Storage
+	ledger   *ledger.Ledger
+	tokenKey []byte // key for signing access tokens
+	orcid    *orcid.Client
+	limiter  *ratelimit.Limiter
 }
 
 // newContext defines a new context object, consisting of global configuration information, a data storage and an Ethereum ledger.
+// If no token secret is configured, a random key for signing access tokens is created. The ORCiD client verifies the
+// ORCiDs of experts. The counters of the rate limiter are kept in memory.
 func newContext(conf *config.WebAPIConfiguration, db *storage.Storage, ledger *ledger.Ledger) *Context {
-	return &Context{conf: conf, db: db, ledger: ledger}
+
+	key := []byte(conf.TokenSecret)
+
+	if len(key) == 0 {
+
+		key = make([]byte, 32)
+
+		if _, err := rand.Read(key); err != nil {
+			log.Fatalf("Could not create random key for signing access tokens. %s", err)
+		}
+
+		log.Print("No token secret configured. Access tokens are signed with a random key and expire on restart.")
+	}
+
+	return &Context{
+		conf:     conf,
+		db:       db,
+		ledger:   ledger,
+		tokenKey: key,
+		orcid:    orcid.NewClient(&conf.OrcId),
+		limiter:  ratelimit.NewLimiter(&conf.RateLimit, nil),
+	}
 }
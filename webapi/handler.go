@@ -1,17 +1,101 @@
 package webapi
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
-	"unicode/utf8"
+	"strings"
+	"time"
 )
 
 import (
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/bibformat"
 	"github.com/fzi-forschungszentrum-informatik/dream-gozer/model"
 	"github.com/fzi-forschungszentrum-informatik/dream-gozer/model/ploc"
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/orcid"
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/storage"
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/syndication"
+	"github.com/google/uuid"
 )
 
+// Duration within which a user has to authorize the verification of the user's ORCiD at the provider.
+const orcIdAuthorizationExpiry = 10 * time.Minute
+
+// Number of records that are included in syndication feeds.
+const syndicationFeedLength = 50
+
+// Default and maximum number of co-authors, that are returned for an expert's network.
+const (
+	defaultCoauthorLimit = 20
+	maxCoauthorLimit     = 100
+)
+
+// Default and maximum number of records, that are returned as related to a record.
+const (
+	defaultRelatedRecordLimit = 10
+	maxRelatedRecordLimit     = 50
+)
+
+// Default and maximum number of suggestions of each kind, that complete a user's input.
+const (
+	defaultSuggestionLimit = 5
+	maxSuggestionLimit     = 20
+)
+
+// All supported types of publications and their keywords.
+var recordTypes = ploc.RecordTypes{
+	ploc.RecordType{Id: model.RecordTypeArticle, Keyword: "Article"},
+	ploc.RecordType{Id: model.RecordTypeBook, Keyword: "Book"},
+	ploc.RecordType{Id: model.RecordTypeOther, Keyword: "Other"},
+	ploc.RecordType{Id: model.RecordTypePaper, Keyword: "Paper"},
+	ploc.RecordType{Id: model.RecordTypeReport, Keyword: "Report"},
+	ploc.RecordType{Id: model.RecordTypeThesis, Keyword: "Thesis"},
+}
+
+// authorizeExpertProfile is a Web request handler that starts the verification of a user's ORCiD via OAuth2. The
+// response contains the URL of the ORCiD provider, which the client opens in a browser. After the user has signed in
+// and authorized GoZer, the provider redirects the browser to GoZer's callback (see verifyExpertProfile).
+func (c *Context) authorizeExpertProfile(w http.ResponseWriter, r *http.Request, u *model.User) {
+
+	// Declare request and response data structures
+
+	var response ploc.CreateOrcIdAuthorizationResponse
+
+	if !c.orcid.Enabled() {
+		log.Printf("Verification of ORCiDs is not configured. Client ID of ORCiD provider is missing.")
+		http.Error(w, "Not Implemented Error", http.StatusNotImplemented)
+		return
+	}
+
+	// Update database
+
+	verifier, err := orcid.NewVerifier()
+	if err != nil {
+		handleInternalError(w, "Could not create PKCE code verifier.", err)
+		return
+	}
+
+	state, err := c.db.CreateOrcIdAuthorization(u.Id, verifier, time.Now().Add(orcIdAuthorizationExpiry))
+	if err != nil {
+		handleInternalError(w, "Database error. Could not create ORCiD authorization.", err)
+		return
+	}
+
+	// Build response
+
+	response.AuthorizationURL = c.orcid.AuthorizationURL(state, verifier, c.conf.OrcId.RedirectURL)
+
+	// Respond
+
+	writeResponse(w, response)
+}
+
 // createCollection is a Web request handler that creates a new collection.
 // The user specifies the authorized user profile to which this operation is related.
 func (c *Context) createCollection(w http.ResponseWriter, r *http.Request, u *model.User) {
@@ -73,6 +157,54 @@ func (c *Context) createExpertBookmark(w http.ResponseWriter, r *http.Request, u
 	w.WriteHeader(http.StatusOK)
 }
 
+// createExpertClaim is a Web request handler that lets a user with verified ORCiD claim, that an expert of the catalogue
+// is the user. The claim binds the expert to the user's ORCiD and allows the user to edit the expert's details. Claims
+// of experts, whose name does not match the name of the user's ORCiD, have to be approved by an administrator.
+func (c *Context) createExpertClaim(w http.ResponseWriter, r *http.Request, u *model.User) {
+
+	// Declare request and response data structures
+
+	var request ploc.CreateExpertClaimRequest
+	var response ploc.CreateExpertClaimResponse
+
+	// Unmarshal request
+
+	if err := readRequest(w, r, &request); err != nil {
+		handleInternalError(w, "Could not decode HTTP request. Body does not seem to contain the right JSON datastructure.", err)
+		return
+	}
+
+	// Check permission
+
+	if !u.OrcIdVerified {
+		handleForbiddenRequest(w, fmt.Sprintf("Could not claim expert. ORCiD of user with GUID '%s' is not verified.", u.GUID))
+		return
+	}
+
+	// Update database
+
+	claim, err := c.db.CreateExpertClaim(u.Id, request.ExpertId)
+	switch {
+	case err == sql.ErrNoRows:
+		handleBadRequest(w, fmt.Sprintf("Could not claim expert. Expert with ID %d does not exist.", request.ExpertId))
+		return
+	case err == storage.ErrExpertClaimed:
+		handleMalformedRequest(w, err)
+		return
+	case err != nil:
+		handleInternalError(w, "Database error. Could not create expert claim.", err)
+		return
+	}
+
+	// Build response
+
+	response.ExpertClaim = claim
+
+	// Respond
+
+	writeResponse(w, response)
+}
+
 // createExpertProfile is a Web request handler that registers a user as an expert.
 func (c *Context) createExpertProfile(w http.ResponseWriter, r *http.Request, u *model.User) {
 
@@ -87,11 +219,17 @@ func (c *Context) createExpertProfile(w http.ResponseWriter, r *http.Request, u
 		return
 	}
 
-	// TODO: Check ORCiD identity via OAuth2.
+	// Check input types
 
-	// Update database
+	orcId, err := model.ParseOrcID(request.OrcId)
+	if err != nil {
+		handleMalformedRequest(w, err)
+		return
+	}
 
-	err := c.db.CreateExpertProfile(u.Id, request.OrcId)
+	// Update database (the ORCiD remains unverified until the user authorizes it, see authorizeExpertProfile)
+
+	err = c.db.CreateExpertProfile(u.Id, orcId.String())
 	if err != nil {
 		handleInternalError(w, "Database error. Could create expert profile.", err)
 		return
@@ -102,6 +240,35 @@ func (c *Context) createExpertProfile(w http.ResponseWriter, r *http.Request, u
 	w.WriteHeader(http.StatusOK)
 }
 
+// createFeedToken is a Web request handler that creates a fresh token for reading the user's record feed with a feed
+// reader. Previously created tokens are revoked. The response includes the feed URLs, that contain the token.
+func (c *Context) createFeedToken(w http.ResponseWriter, r *http.Request, u *model.User) {
+
+	// Declare request and response data structures
+
+	var response ploc.CreateFeedTokenResponse
+
+	// Update database
+
+	token, err := c.db.CreateFeedToken(u.Id)
+	if err != nil {
+		handleInternalError(w, "Could not create feed token.", err)
+		return
+	}
+
+	// Build response
+
+	query := "?token=" + url.QueryEscape(token)
+
+	response.Token = token
+	response.AtomURL = requestBaseURL(r) + "/syndication/records.atom" + query
+	response.RSSURL = requestBaseURL(r) + "/syndication/records.rss" + query
+
+	// Respond
+
+	writeResponse(w, response)
+}
+
 // createFeedback is a Web request handler that adds a user's feedback about a record.
 // The feedback is also made public by writing it to a public distributed ledger.
 // A unique bibliographic hash is used to address a record in the ledger.
@@ -118,6 +285,18 @@ func (c *Context) createFeedback(w http.ResponseWriter, r *http.Request, u *mode
 		return
 	}
 
+	// Check permission, since feedback is published under the user's ORCiD
+
+	if !u.OrcIdVerified {
+		handleForbiddenRequest(w, fmt.Sprintf("Could not create feedback. ORCiD of user with GUID '%s' is not verified.", u.GUID))
+		return
+	}
+
+	if _, err := model.ParseOrcID(u.OrcId); err != nil {
+		handleMalformedRequest(w, err)
+		return
+	}
+
 	// Update database
 
 	err := c.db.CreateFeedback(u.Id, request.RecordId, request.Relevance, request.Presentation, request.Methodology)
@@ -243,6 +422,99 @@ func (c *Context) createRecordDislike(w http.ResponseWriter, r *http.Request, u
 	w.WriteHeader(http.StatusOK)
 }
 
+// createRecoveryCodes is a Web request handler that creates fresh one-time codes for recovering the user's profile.
+// Recovery codes, that were created before, can no longer be used.
+func (c *Context) createRecoveryCodes(w http.ResponseWriter, r *http.Request, u *model.User) {
+
+	// Declare request and response data structures
+
+	var response ploc.CreateRecoveryCodesResponse
+
+	// Update database
+
+	codes, err := c.db.CreateRecoveryCodes(u.Id)
+	if err != nil {
+		handleInternalError(w, "Database error. Could not create recovery codes.", err)
+		return
+	}
+
+	// Build response
+
+	response.RecoveryCodes = codes
+
+	// Respond
+
+	writeResponse(w, response)
+}
+
+// createSession is a Web request handler that logs a user in. The user's GUID and secret are checked once and
+// exchanged for a short-lived access token and a refresh token of a new session.
+func (c *Context) createSession(w http.ResponseWriter, r *http.Request) {
+
+	// Declare request and response data structures
+
+	var request ploc.CreateSessionRequest
+	var response ploc.CreateSessionResponse
+
+	// Unmarshal request
+
+	if err := readRequest(w, r, &request); err != nil {
+		handleInternalError(w, "Could not decode HTTP request. Body does not seem to contain the right JSON datastructure.", err)
+		return
+	}
+
+	// Check credentials
+
+	if _, err := uuid.Parse(request.GUID); err != nil {
+		log.Printf("Autorization failue. GUID '%s' seems to be malformed. Could not be parsed.", request.GUID)
+		c.authorizationFailed(r, "")
+		http.Error(w, "Authorization Error", http.StatusUnauthorized)
+		return
+	}
+
+	if !c.allowAttempt(w, r, request.GUID) {
+		return
+	}
+
+	user, err := c.db.UserByGUID(request.GUID)
+	if err != nil {
+		handleInternalError(w, "Internal database error while reading user credentials.", err)
+		return
+	}
+
+	if user == nil {
+		log.Printf("Autorization failue. User with GUID '%s' does not exist.", request.GUID)
+		c.authorizationFailed(r, request.GUID)
+		http.Error(w, "Authorization Error", http.StatusUnauthorized)
+		return
+	}
+
+	if err = user.Authorize(request.Secret); err != nil {
+		log.Printf("Authorization failure. %s", err)
+		c.authorizationFailed(r, request.GUID)
+		http.Error(w, "Authorization Error", http.StatusUnauthorized)
+		return
+	}
+
+	c.authorizationSucceeded(r, request.GUID)
+
+	// Update database
+
+	sessionId, refreshToken, err := c.db.CreateSession(user.Id, c.refreshTokenExpiry())
+	if err != nil {
+		handleInternalError(w, "Database error. Could not create session.", err)
+		return
+	}
+
+	// Build response
+
+	response.SessionTokens = c.sessionTokens(sessionId, refreshToken)
+
+	// Respond
+
+	writeResponse(w, response)
+}
+
 // createUserProfile is a Web request handler that registers a new user by creating a new user profile.
 // Access to profile is controlled by a user-specified, secret passphrase, or its hash in specific.
 func (c *Context) createUserProfile(w http.ResponseWriter, r *http.Request) {
@@ -259,12 +531,17 @@ func (c *Context) createUserProfile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Check rate limit
+
+	if !c.allowProfile(w, r) {
+		return
+	}
+
 	// Check input types
 
 	// TODO: Check request for malicious or malformed data values.
-	// TODO: Better check entropy instead of password length.
-	if utf8.RuneCountInString(request.Secret) < 16 {
-		handleBadRequest(w, "Secret phrase is too short. Must contain at least 16 characters.")
+	if err := model.ValidateSecret(request.Secret); err != nil {
+		handleMalformedRequest(w, err)
 		return
 	}
 
@@ -276,15 +553,16 @@ func (c *Context) createUserProfile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = c.db.CreateUser(&user)
+	codes, err := c.db.CreateUserWithRecoveryCodes(&user)
 	if err != nil {
-		handleInternalError(w, "Could not add new user to database.", err)
+		handleInternalError(w, "Could not add new user with recovery codes to database.", err)
 		return
 	}
 
 	// Build response
 
 	response.GUID = user.GUID
+	response.RecoveryCodes = codes
 
 	// Respond
 
@@ -360,6 +638,22 @@ func (c *Context) deleteExpertProfile(w http.ResponseWriter, r *http.Request, u
 	w.WriteHeader(http.StatusOK)
 }
 
+// deleteFeedToken is a Web request handler that revokes the user's feed token, so that the record feed can no longer
+// be read by feed readers.
+func (c *Context) deleteFeedToken(w http.ResponseWriter, r *http.Request, u *model.User) {
+
+	// Update database
+
+	if err := c.db.DeleteFeedToken(u.Id); err != nil {
+		handleInternalError(w, "Could not delete feed token.", err)
+		return
+	}
+
+	// Write response (no payload)
+
+	w.WriteHeader(http.StatusOK)
+}
+
 // deleteInterest is a Web request handler that removes a single subject of interest from a user's profile.
 func (c *Context) deleteInterest(w http.ResponseWriter, r *http.Request, u *model.User) {
 
@@ -425,6 +719,45 @@ func (c *Context) deleteRecordBookmark(w http.ResponseWriter, r *http.Request, u
 	w.WriteHeader(http.StatusOK)
 }
 
+// deleteSession is a Web request handler that logs a user out by revoking the session of a refresh token, or all
+// sessions of the user. Access tokens of revoked sessions are rejected immediately.
+func (c *Context) deleteSession(w http.ResponseWriter, r *http.Request, u *model.User) {
+
+	// Declare request and response data structures
+
+	var request ploc.DeleteSessionRequest
+
+	// Unmarshal request
+
+	if err := readRequest(w, r, &request); err != nil {
+		handleInternalError(w, "Could not decode HTTP request. Body does not seem to contain the right JSON datastructure.", err)
+		return
+	}
+
+	// Update database
+
+	if request.All {
+		if err := c.db.DeleteAllSessions(u.Id); err != nil {
+			handleInternalError(w, "Database error. Could not delete sessions.", err)
+			return
+		}
+	} else {
+		err := c.db.DeleteSession(u.Id, request.RefreshToken)
+		if err == sql.ErrNoRows {
+			handleBadRequest(w, "Could not delete session. Refresh token does not belong to a session of the user.")
+			return
+		}
+		if err != nil {
+			handleInternalError(w, "Database error. Could not delete session.", err)
+			return
+		}
+	}
+
+	// Write response (no payload)
+
+	w.WriteHeader(http.StatusOK)
+}
+
 // deleteUserProfile is a Web request handler that removes a user's profile and all user-related information.
 // Feedback of that user is deleted locally but not in the public ledger.
 func (c *Context) deleteUserProfile(w http.ResponseWriter, r *http.Request, u *model.User) {
@@ -463,6 +796,76 @@ func (c *Context) downloadPloc(w http.ResponseWriter, r *http.Request) {
 	http.ServeFile(w, r, c.conf.PlocAPK)
 }
 
+// exportRecordBookmarks is a Web request handler that exports all records bookmarked by a user, or the records of one
+// of the user's collections, as a file that can be imported into reference managers. The file format (BibTeX, RIS or
+// CSL-JSON) is either specified by the request or negotiated via the HTTP Accept header.
+func (c *Context) exportRecordBookmarks(w http.ResponseWriter, r *http.Request, u *model.User) {
+
+	// Declare request and response data structures
+
+	var request ploc.ExportRecordBookmarksRequest
+
+	// Unmarshal request
+
+	if err := readRequest(w, r, &request); err != nil {
+		handleInternalError(w, "Could not decode HTTP request. Body does not seem to contain the right JSON datastructure.", err)
+		return
+	}
+
+	format := request.Format
+	if format == "" {
+		format = bibformat.FormatByMediaType(r.Header.Get("Accept"))
+		if format == "" {
+			log.Printf("Could not export bookmarks. None of the accepted media types '%s' is supported.", r.Header.Get("Accept"))
+			http.Error(w, "Not Acceptable", http.StatusNotAcceptable)
+			return
+		}
+	}
+
+	if bibformat.MediaType(format) == "" {
+		handleBadRequest(w, fmt.Sprintf("Could not export bookmarks. Format '%s' is not supported.", format))
+		return
+	}
+
+	if request.CollectionId != 0 {
+
+		collections, err := c.db.ReadCollections(u.Id)
+		if err != nil {
+			handleInternalError(w, "Could not read collections from database.", err)
+			return
+		}
+
+		if collections.SelectById(request.CollectionId).Id == 0 {
+			handleBadRequest(w, fmt.Sprintf("Could not export bookmarks. Collection with ID %d does not exist.", request.CollectionId))
+			return
+		}
+	}
+
+	// Build response
+
+	records, err := c.db.ReadBookmarkedRecords(u.Id, request.CollectionId)
+	if err != nil {
+		handleInternalError(w, "Could not read bookmarked records from database.", err)
+		return
+	}
+
+	var buf bytes.Buffer
+
+	if err = bibformat.Write(&buf, records, format); err != nil {
+		handleInternalError(w, "Could not export bookmarked records.", err)
+		return
+	}
+
+	// Respond
+
+	w.Header().Set("Content-Type", bibformat.MediaType(format)+"; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="bookmarks`+bibformat.FileExtension(format)+`"`)
+
+	if _, err = w.Write(buf.Bytes()); err != nil {
+		log.Printf("Could not write response to HTTP ResponseWriter. %s", err)
+	}
+}
+
 // readCollections is a Web request handler that returns all the bookmark collections that are stored in an user's profile.
 func (c *Context) readCollections(w http.ResponseWriter, r *http.Request, u *model.User) {
 
@@ -507,6 +910,28 @@ func (c *Context) readExpertBookmarks(w http.ResponseWriter, r *http.Request, u
 	writeResponse(w, response)
 }
 
+// readExpertClaims is a Web request handler that returns all claims of experts, that the user has made.
+func (c *Context) readExpertClaims(w http.ResponseWriter, r *http.Request, u *model.User) {
+
+	// Declare request and response data structures
+
+	var response ploc.ReadExpertClaimsResponse
+
+	// Build response
+
+	claims, err := c.db.ReadExpertClaims(u.Id)
+	if err != nil {
+		handleInternalError(w, "Database error. Could not read expert claims.", err)
+		return
+	}
+
+	response.Claims = claims
+
+	// Respond
+
+	writeResponse(w, response)
+}
+
 // readExpertDetails is a Web request handler that returns a detailed profile about a specific expert.
 // The profile includes information like name, ORCiD and publications.
 func (c *Context) readExpertDetails(w http.ResponseWriter, r *http.Request, u *model.User) {
@@ -576,7 +1001,93 @@ func (c *Context) readExpertFeed(w http.ResponseWriter, r *http.Request, u *mode
 	writeResponse(w, response)
 }
 
-// readExpertProfile is a Web request handler that returns a short summary about a specific expert.
+// readExpertNetwork is a Web request handler that returns the co-authors of an expert, together with the number of
+// publications they have co-authored with the expert.
+func (c *Context) readExpertNetwork(w http.ResponseWriter, r *http.Request, u *model.User) {
+
+	// Declare request and response data structures
+
+	var request ploc.ReadExpertNetworkRequest
+	var response ploc.ReadExpertNetworkResponse
+
+	// Unmarshal request
+
+	if err := readRequest(w, r, &request); err != nil {
+		handleInternalError(w, "Could not decode HTTP request. Body does not seem to contain the right JSON datastructure.", err)
+		return
+	}
+
+	limit := request.Limit
+	if limit <= 0 {
+		limit = defaultCoauthorLimit
+	}
+	if limit > maxCoauthorLimit {
+		limit = maxCoauthorLimit
+	}
+
+	// Read co-authors from database
+
+	rawCoauthors, err := c.db.ReadExpertNetwork(request.ExpertId, limit)
+	if err == sql.ErrNoRows {
+		handleBadRequest(w, fmt.Sprintf("Could not read expert network. Expert with ID %d does not exist.", request.ExpertId))
+		return
+	}
+
+	if err != nil {
+		handleInternalError(w, "Database error. Could not read expert network.", err)
+		return
+	}
+
+	// Build response
+
+	response.ExpertId = request.ExpertId
+	response.RawCoauthors = rawCoauthors
+
+	// Respond
+
+	writeResponse(w, response)
+}
+
+// readExpertPath is a Web request handler that returns the shortest chain of co-authors, that connects two experts.
+func (c *Context) readExpertPath(w http.ResponseWriter, r *http.Request, u *model.User) {
+
+	// Declare request and response data structures
+
+	var request ploc.ReadExpertPathRequest
+	var response ploc.ReadExpertPathResponse
+
+	// Unmarshal request
+
+	if err := readRequest(w, r, &request); err != nil {
+		handleInternalError(w, "Could not decode HTTP request. Body does not seem to contain the right JSON datastructure.", err)
+		return
+	}
+
+	// Read path from database
+
+	rawExperts, err := c.db.ReadExpertPath(request.FromId, request.ToId)
+	if err == sql.ErrNoRows {
+		handleBadRequest(w, fmt.Sprintf("Could not read path between experts %d and %d. Expert does not exist.", request.FromId, request.ToId))
+		return
+	}
+
+	if err != nil {
+		handleInternalError(w, "Database error. Could not read path between experts.", err)
+		return
+	}
+
+	// Build response
+
+	response.FromId = request.FromId
+	response.ToId = request.ToId
+	response.RawExperts = rawExperts
+
+	// Respond
+
+	writeResponse(w, response)
+}
+
+// readExpertProfile is a Web request handler that returns a short summary about a specific expert.
 // The summary includes information like name, last year of publication, and subjects the expert has published about.
 func (c *Context) readExpertProfile(w http.ResponseWriter, r *http.Request, u *model.User) {
 
@@ -586,13 +1097,14 @@ func (c *Context) readExpertProfile(w http.ResponseWriter, r *http.Request, u *m
 
 	// Build response
 
-	orcId, err := c.db.ReadOrcId(u.Id)
+	orcId, verified, err := c.db.ReadOrcId(u.Id)
 	if err != nil {
 		handleInternalError(w, "Could not read the user's ORCiD from database.", err)
 		return
 	}
 
 	response.OrcId = orcId
+	response.Verified = verified
 
 	// Respond
 
@@ -698,6 +1210,11 @@ func (c *Context) readInterests(w http.ResponseWriter, r *http.Request, u *model
 	writeResponse(w, response)
 }
 
+// readRecordAtomFeed is a Web request handler that returns the first records of a user's record feed as Atom feed.
+func (c *Context) readRecordAtomFeed(w http.ResponseWriter, r *http.Request, u *model.User) {
+	c.serveRecordSyndicationFeed(w, r, u, syndication.MediaTypeAtom)
+}
+
 // readRecordBookmarks is a Web request handler that returns all records bookmarked by a user.
 func (c *Context) readRecordBookmarks(w http.ResponseWriter, r *http.Request, u *model.User) {
 
@@ -754,8 +1271,10 @@ func (c *Context) readRecordDetails(w http.ResponseWriter, r *http.Request, u *m
 }
 
 // readRecordFeed is a Web request handler that returns a list of publications that match the user's subjects of interest.
-// The list is descendingly ordered by year of publication and within that year by relevance.
+// The list is descendingly ordered by the records' scores, which combine matching interests, recency and dislikes.
 // The list is accessed segment-wise, so that a client can read only the segments that are shown to the user, but not the whole list.
+// An optional filter restricts the list, while the facets in the response count the filtered records by year, type,
+// open access state and subject.
 func (c *Context) readRecordFeed(w http.ResponseWriter, r *http.Request, u *model.User) {
 
 	// Declare request and response data structures
@@ -770,25 +1289,77 @@ func (c *Context) readRecordFeed(w http.ResponseWriter, r *http.Request, u *mode
 		return
 	}
 
+	if err := validateRecordFeedFilter(&request.Filter); err != nil {
+		handleMalformedRequest(w, err)
+		return
+	}
+
 	// Read records from database
 
-	rawRecords, err := c.db.ReadRecordFeed(u.Id, request.Offset, request.Limit)
+	rawRecords, err := c.db.ReadRecordFeed(u.Id, &request.Filter, request.Offset, request.Limit)
 	if err != nil {
 		handleInternalError(w, "Database error. Could not read record feed.", err)
 		return
 	}
 
+	facets, err := c.db.ReadRecordFeedFacets(u.Id, "", &request.Filter)
+	if err != nil {
+		handleInternalError(w, "Database error. Could not count record feed facets.", err)
+		return
+	}
+
 	// Build response
 
 	response.Offset = request.Offset
 	response.Limit = request.Limit
 	response.RawRecords = rawRecords
+	response.Facets = labelRecordFeedFacets(facets)
 
 	// Respond
 
 	writeResponse(w, response)
 }
 
+// readRecordRecommendations is a Web request handler that returns a segment of the publications, that users with
+// similar bookmarks, visits and feedback have found useful ("recommended for you").
+func (c *Context) readRecordRecommendations(w http.ResponseWriter, r *http.Request, u *model.User) {
+
+	// Declare request and response data structures
+
+	var request ploc.ReadRecordRecommendationsRequest
+	var response ploc.ReadRecordRecommendationsResponse
+
+	// Unmarshal request
+
+	if err := readRequest(w, r, &request); err != nil {
+		handleInternalError(w, "Could not decode HTTP request. Body does not seem to contain the right JSON datastructure.", err)
+		return
+	}
+
+	// Read records from database
+
+	rawRecords, err := c.db.ReadRecordRecommendations(u.Id, request.Offset, request.Limit)
+	if err != nil {
+		handleInternalError(w, "Database error. Could not read record recommendations.", err)
+		return
+	}
+
+	// Build response
+
+	response.Offset = request.Offset
+	response.Limit = request.Limit
+	response.RawRecords = rawRecords
+
+	// Respond
+
+	writeResponse(w, response)
+}
+
+// readRecordRSSFeed is a Web request handler that returns the first records of a user's record feed as RSS 2.0 feed.
+func (c *Context) readRecordRSSFeed(w http.ResponseWriter, r *http.Request, u *model.User) {
+	c.serveRecordSyndicationFeed(w, r, u, syndication.MediaTypeRSS)
+}
+
 // readRecordTypes is a Web request handler that returns all supported types of publications and their numerical keys.
 func (c *Context) readRecordTypes(w http.ResponseWriter, r *http.Request, u *model.User) {
 
@@ -796,15 +1367,55 @@ func (c *Context) readRecordTypes(w http.ResponseWriter, r *http.Request, u *mod
 
 	// Build response
 
-	response.Types = []ploc.RecordType{
-		ploc.RecordType{Id: int64(0), Keyword: "Article"},
-		ploc.RecordType{Id: int64(1), Keyword: "Book"},
-		ploc.RecordType{Id: int64(2), Keyword: "Other"},
-		ploc.RecordType{Id: int64(3), Keyword: "Paper"},
-		ploc.RecordType{Id: int64(4), Keyword: "Report"},
-		ploc.RecordType{Id: int64(5), Keyword: "Thesis"},
+	response.Types = recordTypes
+
+	// Respond
+
+	writeResponse(w, response)
+}
+
+// readRelatedRecords is a Web request handler that returns the publications, that are most similar to a specific record
+// (e.g. to continue from the record's detail view). Similarities are computed on first request and cached afterwards.
+func (c *Context) readRelatedRecords(w http.ResponseWriter, r *http.Request, u *model.User) {
+
+	// Declare request and response data structures
+
+	var request ploc.ReadRelatedRecordsRequest
+	var response ploc.ReadRelatedRecordsResponse
+
+	// Unmarshal request
+
+	if err := readRequest(w, r, &request); err != nil {
+		handleInternalError(w, "Could not decode HTTP request. Body does not seem to contain the right JSON datastructure.", err)
+		return
+	}
+
+	limit := request.Limit
+	if limit <= 0 {
+		limit = defaultRelatedRecordLimit
+	}
+	if limit > maxRelatedRecordLimit {
+		limit = maxRelatedRecordLimit
 	}
 
+	// Read related records from database
+
+	rawRecords, err := c.db.ReadRelatedRecords(u.Id, request.RecordId, limit)
+	if err == sql.ErrNoRows {
+		handleBadRequest(w, fmt.Sprintf("Could not read related records. Record with ID %d does not exist.", request.RecordId))
+		return
+	}
+
+	if err != nil {
+		handleInternalError(w, "Database error. Could not read related records.", err)
+		return
+	}
+
+	// Build response
+
+	response.RecordId = request.RecordId
+	response.RawRecords = rawRecords
+
 	// Respond
 
 	writeResponse(w, response)
@@ -833,9 +1444,195 @@ func (c *Context) readSubjects(w http.ResponseWriter, r *http.Request, u *model.
 	writeResponse(w, response)
 }
 
+// readSuggestions is a Web request handler that completes a user's input with subject keywords, expert names and
+// record titles. It is called for each keystroke and therefore relies on a prefix index.
+func (c *Context) readSuggestions(w http.ResponseWriter, r *http.Request, u *model.User) {
+
+	// Declare request and response data structures
+
+	var request ploc.ReadSuggestionsRequest
+	var response ploc.ReadSuggestionsResponse
+
+	// Unmarshal request
+
+	if err := readRequest(w, r, &request); err != nil {
+		handleInternalError(w, "Could not decode HTTP request. Body does not seem to contain the right JSON datastructure.", err)
+		return
+	}
+
+	limit := request.Limit
+	if limit <= 0 {
+		limit = defaultSuggestionLimit
+	}
+	if limit > maxSuggestionLimit {
+		limit = maxSuggestionLimit
+	}
+
+	// Read suggestions from database
+
+	kinds := []struct {
+		kind        string
+		suggestions *ploc.Suggestions
+	}{
+		{storage.SuggestionSubject, &response.Subjects},
+		{storage.SuggestionExpert, &response.Experts},
+		{storage.SuggestionRecord, &response.Records},
+	}
+
+	for _, k := range kinds {
+
+		suggestions, err := c.db.ReadSuggestions(request.Input, k.kind, limit)
+		if err != nil {
+			handleInternalError(w, "Database error. Could not read suggestions.", err)
+			return
+		}
+
+		*k.suggestions = suggestions
+	}
+
+	// Respond
+
+	writeResponse(w, response)
+}
+
+// recoverUserProfile is a Web request handler that replaces the secret of a user's profile, whose secret was lost,
+// by using up one of the user's recovery codes. All sessions of the user are revoked.
+func (c *Context) recoverUserProfile(w http.ResponseWriter, r *http.Request) {
+
+	// Declare request and response data structures
+
+	var request ploc.RecoverUserProfileRequest
+
+	// Unmarshal request
+
+	if err := readRequest(w, r, &request); err != nil {
+		handleInternalError(w, "Could not decode HTTP request. Body does not seem to contain the right JSON datastructure.", err)
+		return
+	}
+
+	// Check input types
+
+	if err := model.ValidateSecret(request.Secret); err != nil {
+		handleMalformedRequest(w, err)
+		return
+	}
+
+	// Check rate limit (recovery codes are credentials, that must not be guessed)
+
+	if !c.allowAttempt(w, r, request.GUID) {
+		return
+	}
+
+	var user model.User
+
+	if err := user.SetSecret(request.Secret); err != nil {
+		handleInternalError(w, "Could not hash new secret.", err)
+		return
+	}
+
+	// Update database
+
+	err := c.db.RecoverUser(request.GUID, request.RecoveryCode, user.HashedSecret)
+	if err == sql.ErrNoRows {
+		log.Printf("Autorization failue. Recovery code for GUID '%s' is unknown or was used up.", request.GUID)
+		c.authorizationFailed(r, request.GUID)
+		http.Error(w, "Authorization Error", http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		handleInternalError(w, "Database error. Could not recover user profile.", err)
+		return
+	}
+
+	// Write response (no payload)
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// refreshSession is a Web request handler that exchanges a refresh token for a fresh access token and a fresh
+// refresh token of the same session. The old refresh token can not be used again.
+func (c *Context) refreshSession(w http.ResponseWriter, r *http.Request) {
+
+	// Declare request and response data structures
+
+	var request ploc.RefreshSessionRequest
+	var response ploc.RefreshSessionResponse
+
+	// Unmarshal request
+
+	if err := readRequest(w, r, &request); err != nil {
+		handleInternalError(w, "Could not decode HTTP request. Body does not seem to contain the right JSON datastructure.", err)
+		return
+	}
+
+	// Update database
+
+	sessionId, user, refreshToken, err := c.db.RefreshSession(request.RefreshToken, c.refreshTokenExpiry())
+	if err != nil {
+		handleInternalError(w, "Database error. Could not refresh session.", err)
+		return
+	}
+
+	if user == nil {
+		log.Printf("Autorization failue. Refresh token is unknown, has expired or its session was revoked.")
+		http.Error(w, "Authorization Error", http.StatusUnauthorized)
+		return
+	}
+
+	// Build response
+
+	response.SessionTokens = c.sessionTokens(sessionId, refreshToken)
+
+	// Respond
+
+	writeResponse(w, response)
+}
+
+// searchExpertCatalogue is a Web request handler that makes a full text search among all experts, including those that
+// are not part of the user's expert feed. Each result signifies if the expert is bookmarked and part of the user's feed.
+func (c *Context) searchExpertCatalogue(w http.ResponseWriter, r *http.Request, u *model.User) {
+
+	// Declare request and response data structures
+
+	var request ploc.SearchExpertCatalogueRequest
+	var response ploc.SearchExpertCatalogueResponse
+
+	// Unmarshal request
+
+	if err := readRequest(w, r, &request); err != nil {
+		handleInternalError(w, "Could not decode HTTP request. Body does not seem to contain the right JSON datastructure.", err)
+		return
+	}
+
+	// Search database (this may take a while)
+
+	rawExperts, highlights, err := c.db.SearchExpertCatalogue(u.Id, request.SearchTerm, request.Offset, request.Limit)
+	if queryErr, ok := err.(*storage.SearchQueryError); ok {
+		handleMalformedRequest(w, queryErr)
+		return
+	}
+
+	if err != nil {
+		handleInternalError(w, "Database error. Could not search expert catalogue.", err)
+		return
+	}
+
+	// Build response
+
+	response.Offset = request.Offset
+	response.Limit = request.Limit
+	response.RawExperts = rawExperts
+	response.Highlights = highlights
+
+	// Respond
+
+	writeResponse(w, response)
+}
+
 // searchExpertFeed is a Web request handler that makes a full text search within a user's expert feed and
 // returns a summary for all the experts with matching textual content. The searched fields include name,
 // publication titles and subjects.
+// The results are ordered by relevance and accompanied by highlighted text fragments that match the search term.
 func (c *Context) searchExpertFeed(w http.ResponseWriter, r *http.Request, u *model.User) {
 
 	// Declare request and response data structures
@@ -851,9 +1648,13 @@ func (c *Context) searchExpertFeed(w http.ResponseWriter, r *http.Request, u *mo
 	}
 
 	// Search database (this may take a while)
-	// TODO: Filter search term and remove malicious character sequences
 
-	rawExperts, err := c.db.SearchExpertFeed(u.Id, request.SearchTerm, request.Offset, request.Limit)
+	rawExperts, highlights, err := c.db.SearchExpertFeed(u.Id, request.SearchTerm, request.Offset, request.Limit)
+	if queryErr, ok := err.(*storage.SearchQueryError); ok {
+		handleMalformedRequest(w, queryErr)
+		return
+	}
+
 	if err != nil {
 		handleInternalError(w, "Database error. Could not search expert feed.", err)
 		return
@@ -864,6 +1665,49 @@ func (c *Context) searchExpertFeed(w http.ResponseWriter, r *http.Request, u *mo
 	response.Offset = request.Offset
 	response.Limit = request.Limit
 	response.RawExperts = rawExperts
+	response.Highlights = highlights
+
+	// Respond
+
+	writeResponse(w, response)
+}
+
+// searchRecordCatalogue is a Web request handler that makes a full text search in the whole catalogue of publications,
+// including those that are not part of the user's record feed. Each result signifies if the record was visited or
+// bookmarked and if it is part of the user's feed.
+func (c *Context) searchRecordCatalogue(w http.ResponseWriter, r *http.Request, u *model.User) {
+
+	// Declare request and response data structures
+
+	var request ploc.SearchRecordCatalogueRequest
+	var response ploc.SearchRecordCatalogueResponse
+
+	// Unmarshal request
+
+	if err := readRequest(w, r, &request); err != nil {
+		handleInternalError(w, "Could not decode HTTP request. Body does not seem to contain the right JSON datastructure.", err)
+		return
+	}
+
+	// Search database (this may take a while)
+
+	rawRecords, highlights, err := c.db.SearchRecordCatalogue(u.Id, request.SearchTerm, request.Offset, request.Limit)
+	if queryErr, ok := err.(*storage.SearchQueryError); ok {
+		handleMalformedRequest(w, queryErr)
+		return
+	}
+
+	if err != nil {
+		handleInternalError(w, "Database error. Could not search record catalogue.", err)
+		return
+	}
+
+	// Build response
+
+	response.Offset = request.Offset
+	response.Limit = request.Limit
+	response.RawRecords = rawRecords
+	response.Highlights = highlights
 
 	// Respond
 
@@ -873,6 +1717,7 @@ func (c *Context) searchExpertFeed(w http.ResponseWriter, r *http.Request, u *mo
 // searchRecordFeed is a Web request handler that makes a full text search within a user's publication feed and
 // returns a summary for all the publication records with matching textual content. The searched fields include title,
 // abstract, subjects and author names.
+// The results are ordered by relevance and accompanied by highlighted text fragments that match the search term.
 func (c *Context) searchRecordFeed(w http.ResponseWriter, r *http.Request, u *model.User) {
 
 	// Declare request and response data structures
@@ -887,26 +1732,124 @@ func (c *Context) searchRecordFeed(w http.ResponseWriter, r *http.Request, u *mo
 		return
 	}
 
+	if err := validateRecordFeedFilter(&request.Filter); err != nil {
+		handleMalformedRequest(w, err)
+		return
+	}
+
 	// Search database (this may take a while)
-	// TODO: Filter search term and remove malicious character sequences
 
-	rawRecords, err := c.db.SearchRecordFeed(u.Id, request.SearchTerm, request.Offset, request.Limit)
+	rawRecords, highlights, err := c.db.SearchRecordFeed(u.Id, request.SearchTerm, &request.Filter, request.Offset, request.Limit)
+	if queryErr, ok := err.(*storage.SearchQueryError); ok {
+		handleMalformedRequest(w, queryErr)
+		return
+	}
+
 	if err != nil {
 		handleInternalError(w, "Database error. Could not search record feed.", err)
 		return
 	}
 
+	facets, err := c.db.ReadRecordFeedFacets(u.Id, request.SearchTerm, &request.Filter)
+	if err != nil {
+		handleInternalError(w, "Database error. Could not count facets of search results.", err)
+		return
+	}
+
 	// Build response
 
 	response.Offset = request.Offset
 	response.Limit = request.Limit
 	response.RawRecords = rawRecords
+	response.Facets = labelRecordFeedFacets(facets)
+	response.Highlights = highlights
 
 	// Respond
 
 	writeResponse(w, response)
 }
 
+// serveRecordSyndicationFeed renders the first records of a user's record feed as Atom or RSS feed (specified by its
+// media type). Feed readers can poll the feed cheaply by conditional GET requests, since the response carries an entity
+// tag and the time of the last change of the feed content.
+func (c *Context) serveRecordSyndicationFeed(w http.ResponseWriter, r *http.Request, u *model.User, mediaType string) {
+
+	// Read feed entries
+
+	records, err := c.db.ReadRecordFeedEntries(u.Id, syndicationFeedLength)
+	if err != nil {
+		handleInternalError(w, "Database error. Could not read record feed entries.", err)
+		return
+	}
+
+	// Determine entity tag and time of last change. The tag is computed from the feed content, so that both formats
+	// share the time of last change, but get distinct entity tags.
+
+	jRecords, err := json.Marshal(records)
+	if err != nil {
+		handleInternalError(w, "Could not compute entity tag of feed.", err)
+		return
+	}
+
+	contentHash := fmt.Sprintf("%x", sha256.Sum256(jRecords))[:32]
+
+	modified, err := c.db.UpdateFeedETag(u.Id, contentHash)
+	if err != nil {
+		handleInternalError(w, "Database error. Could not update entity tag of feed.", err)
+		return
+	}
+
+	// Build response
+
+	feed := syndication.Feed{
+		Id:      "urn:uuid:" + uuid.NewSHA1(uuid.NameSpaceURL, []byte("gozer:record-feed:"+u.GUID)).String(),
+		Title:   "Ploc record feed",
+		Link:    requestBaseURL(r) + r.URL.RequestURI(),
+		Updated: modified,
+	}
+
+	for _, rec := range records {
+
+		entry := syndication.Entry{
+			Id:       fmt.Sprintf("urn:uuid:%s", uuid.NewSHA1(uuid.NameSpaceURL, []byte(fmt.Sprintf("gozer:record:%d", rec.Id)))),
+			Title:    rec.Title,
+			Abstract: rec.Abstract,
+			Year:     rec.Year,
+			Link:     rec.RepositoryLink,
+		}
+
+		for _, creator := range rec.Creators {
+			entry.Creators = append(entry.Creators, strings.TrimSpace(creator.FirstName+" "+creator.LastName))
+		}
+
+		feed.Entries = append(feed.Entries, entry)
+	}
+
+	var buf bytes.Buffer
+	var etag string
+
+	if mediaType == syndication.MediaTypeAtom {
+		err = syndication.WriteAtom(&buf, &feed)
+		etag = `"` + contentHash + `-atom"`
+	} else {
+		err = syndication.WriteRSS(&buf, &feed)
+		etag = `"` + contentHash + `-rss"`
+	}
+
+	if err != nil {
+		handleInternalError(w, "Could not render syndication feed.", err)
+		return
+	}
+
+	// Respond (ServeContent answers conditional requests with 304 Not Modified)
+
+	w.Header().Set("Content-Type", mediaType+"; charset=utf-8")
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "private, no-cache")
+
+	http.ServeContent(w, r, "", modified, bytes.NewReader(buf.Bytes()))
+}
+
 // updateCollection is a Web request handler that allows a user to change the title for one of its existing collections.
 func (c *Context) updateCollection(w http.ResponseWriter, r *http.Request, u *model.User) {
 
@@ -933,6 +1876,38 @@ func (c *Context) updateCollection(w http.ResponseWriter, r *http.Request, u *mo
 	w.WriteHeader(http.StatusOK)
 }
 
+// updateExpertDetails is a Web request handler that changes the affiliation of an expert. Only the owner of the
+// expert's profile, i.e. the user whose claim of the expert was approved, is permitted to change it.
+func (c *Context) updateExpertDetails(w http.ResponseWriter, r *http.Request, u *model.User) {
+
+	// Declare request and response data structures
+
+	var request ploc.UpdateExpertDetailsRequest
+
+	// Unmarshal request
+
+	if err := readRequest(w, r, &request); err != nil {
+		handleInternalError(w, "Could not decode HTTP request. Body does not seem to contain the right JSON datastructure.", err)
+		return
+	}
+
+	// Update database
+
+	err := c.db.UpdateExpertAffiliation(u.Id, request.ExpertId, request.Affiliation)
+	if err == sql.ErrNoRows {
+		handleForbiddenRequest(w, fmt.Sprintf("Could not update expert details. User with GUID '%s' does not own expert %d.", u.GUID, request.ExpertId))
+		return
+	}
+	if err != nil {
+		handleInternalError(w, "Database error. Could not update expert details.", err)
+		return
+	}
+
+	// Write response (no payload)
+
+	w.WriteHeader(http.StatusOK)
+}
+
 // updateRecordBookmarkCollections is a Web request handler that allows a user to specify to which of its bookmark collections
 // a publication corresponds. With help of this function a publication can be added or removed from any of the user's
 // collections.
@@ -960,3 +1935,111 @@ func (c *Context) updateRecordBookmarkCollections(w http.ResponseWriter, r *http
 
 	w.WriteHeader(http.StatusOK)
 }
+
+// updateUserSecret is a Web request handler that replaces the secret of the user's profile. All sessions of the user
+// are revoked, so that clients have to log in with the new secret.
+func (c *Context) updateUserSecret(w http.ResponseWriter, r *http.Request, u *model.User) {
+
+	// Declare request and response data structures
+
+	var request ploc.UpdateUserSecretRequest
+
+	// Unmarshal request
+
+	if err := readRequest(w, r, &request); err != nil {
+		handleInternalError(w, "Could not decode HTTP request. Body does not seem to contain the right JSON datastructure.", err)
+		return
+	}
+
+	// Check input types
+
+	if err := model.ValidateSecret(request.Secret); err != nil {
+		handleMalformedRequest(w, err)
+		return
+	}
+
+	// Check permission (access tokens alone must not suffice to take over the profile)
+
+	if !c.allowAttempt(w, r, u.GUID) {
+		return
+	}
+
+	if err := u.Authorize(request.CurrentSecret); err != nil {
+		c.authorizationFailed(r, u.GUID)
+		handleForbiddenRequest(w, fmt.Sprintf("Could not update secret. %s", err))
+		return
+	}
+
+	if err := u.SetSecret(request.Secret); err != nil {
+		handleInternalError(w, "Could not hash new secret.", err)
+		return
+	}
+
+	// Update database
+
+	if err := c.db.UpdateUserSecret(u.Id, u.HashedSecret); err != nil {
+		handleInternalError(w, "Database error. Could not update secret.", err)
+		return
+	}
+
+	// Write response (no payload)
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// verifyExpertProfile is a Web request handler for the callback of the ORCiD provider, to which the provider
+// redirects the user's browser after authorization. The authorization code is exchanged for the user's ORCiD, which is
+// then linked to the user's profile as verified. The response is a short message for the user.
+func (c *Context) verifyExpertProfile(w http.ResponseWriter, r *http.Request) {
+
+	// Read callback parameters
+
+	params := r.URL.Query()
+
+	if reason := params.Get("error"); reason != "" {
+		log.Printf("ORCiD provider has denied authorization. %s", reason)
+		http.Error(w, "The verification of your ORCiD was cancelled.", http.StatusBadRequest)
+		return
+	}
+
+	uid, verifier, err := c.db.DeleteOrcIdAuthorization(params.Get("state"))
+	if err == sql.ErrNoRows {
+		log.Printf("Unknown or expired state in callback of ORCiD provider.")
+		http.Error(w, "The verification of your ORCiD has expired. Please try again.", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		handleInternalError(w, "Database error. Could not read ORCiD authorization.", err)
+		return
+	}
+
+	// Exchange authorization code
+
+	id, err := c.orcid.Exchange(params.Get("code"), verifier, c.conf.OrcId.RedirectURL)
+	if err != nil {
+		log.Printf("Could not verify ORCiD of user %d. %s", uid, err)
+		http.Error(w, "The ORCiD provider did not confirm your ORCiD. Please try again.", http.StatusBadGateway)
+		return
+	}
+
+	orcId, err := model.ParseOrcID(id.OrcId)
+	if err != nil {
+		log.Printf("ORCiD provider has returned a malformed ORCiD for user %d. %s", uid, err)
+		http.Error(w, "The ORCiD provider did not confirm your ORCiD. Please try again.", http.StatusBadGateway)
+		return
+	}
+
+	// Update database
+
+	if err = c.db.VerifyOrcId(uid, orcId.String(), id.Name); err != nil {
+		handleInternalError(w, "Database error. Could not verify ORCiD.", err)
+		return
+	}
+
+	// Respond
+
+	log.Printf("ORCiD '%s' of user %d was verified.", orcId, uid)
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprintf(w, "Your ORCiD %s was verified. You can return to the ploc app now.\n", orcId)
+}
@@ -1,9 +1,229 @@
 package webapi
 
 import (
+	"encoding/json"
+	"net/http"
+	"strings"
 	"testing"
+	"time"
 )
 
+import (
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/config"
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/model"
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/model/admin"
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/model/ploc"
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/storage"
+)
+
+func TestAdminAPI(t *testing.T) {
+
+	// Setup database and service
+
+	ts := NewTestService(t)
+	defer ts.Close()
+
+	// Setup some example data: a verified expert with feedback and an administrator.
+
+	_ = ts.CreateUserProfileWithData()
+	expertGUID := ts.guid
+
+	record := ts.ReadFeedbackFeed(0, 1).Records[0]
+	ts.CreateFeedback(record.Id, 1, 0, 1)
+
+	ts.CreateUserProfile()
+
+	// Perform test #1: regular users are not permitted to use the admin API
+
+	if statusCode, _ := ts.PostAdminRequest("/statistics/read", nil, nil); statusCode != http.StatusForbidden {
+		t.Errorf("Expected HTTP status %d for a regular user but got %d.", http.StatusForbidden, statusCode)
+		return
+	}
+
+	if err := ts.storage.UpdateUserRole(ts.guid, model.RoleAdmin); err != nil {
+		t.Errorf("Could not grant role of administrator. %s", err)
+		return
+	}
+
+	// Perform test #2: read statistics and users
+
+	var stats admin.ReadStatisticsResponse
+	ts.PostAdminRequestOK("/statistics/read", nil, &stats)
+
+	if stats.Users != 2 || stats.Administrators != 1 || stats.VerifiedExperts != 1 || stats.Feedback != 1 {
+		t.Errorf("Expected 2 users, 1 administrator, 1 verified expert and 1 feedback but got %+v.", stats.Statistics)
+		return
+	}
+
+	var users admin.ReadUsersResponse
+	ts.PostAdminRequestOK("/users/read", &admin.ReadUsersRequest{}, &users)
+
+	if len(users.Users) != 2 || users.Users[0].GUID != expertGUID || users.Users[0].FeedbackCount != 1 || users.Users[1].Role != model.RoleAdmin {
+		t.Errorf("Expected expert with feedback and administrator but got %+v.", users.Users)
+		return
+	}
+
+	// Perform test #3: moderate feedback
+
+	var feedback admin.ReadFeedbackResponse
+	ts.PostAdminRequestOK("/feedback/read", &admin.ReadFeedbackRequest{}, &feedback)
+
+	if len(feedback.Feedbacks) != 1 || feedback.Feedbacks[0].GUID != expertGUID || feedback.Feedbacks[0].RecordId != record.Id {
+		t.Errorf("Expected feedback of '%s' for record %d but got %+v.", expertGUID, record.Id, feedback.Feedbacks)
+		return
+	}
+
+	deleteFeedback := admin.DeleteFeedbackRequest{GUID: expertGUID, RecordId: record.Id}
+	ts.PostAdminRequestOK("/feedback/delete", &deleteFeedback, nil)
+
+	if statusCode, _ := ts.PostAdminRequest("/feedback/delete", &deleteFeedback, nil); statusCode != http.StatusBadRequest {
+		t.Errorf("Expected HTTP status %d for deleting missing feedback but got %d.", http.StatusBadRequest, statusCode)
+		return
+	}
+
+	if feedbacks := ts.ReadFeedback(record.Id).Feedbacks; len(feedbacks) != 0 {
+		t.Errorf("Expected no feedback after deletion but got %v.", feedbacks)
+		return
+	}
+
+	// Perform test #4: read ingest status and rebuild search indices and feeds
+
+	var status admin.ReadIngestStatusResponse
+	ts.PostAdminRequestOK("/ingest-status/read", nil, &status)
+
+	if status.Records == 0 || status.Experts == 0 || len(status.HarvestRuns) != 0 {
+		t.Errorf("Expected records and experts without harvest runs but got %+v.", status)
+		return
+	}
+
+	ts.PostAdminRequestOK("/search-index/build", nil, nil)
+	ts.PostAdminRequestOK("/feeds/rebuild", nil, nil)
+
+	// Perform test #5: manage users
+
+	if statusCode, _ := ts.PostAdminRequest("/user-role/update", &admin.UpdateUserRoleRequest{GUID: expertGUID, Role: "root"}, nil); statusCode != http.StatusBadRequest {
+		t.Errorf("Expected HTTP status %d for an unknown role but got %d.", http.StatusBadRequest, statusCode)
+		return
+	}
+
+	ts.PostAdminRequestOK("/user/delete", &admin.DeleteUserRequest{GUID: expertGUID}, nil)
+
+	if user, _ := ts.storage.UserByGUID(expertGUID); user != nil {
+		t.Errorf("Expected user with GUID '%s' to be deleted.", expertGUID)
+		return
+	}
+
+	// Perform test #6: actions are written to the audit log, most recent first
+
+	var auditLog admin.ReadAuditLogResponse
+	ts.PostAdminRequestOK("/audit-log/read", &admin.ReadAuditLogRequest{}, &auditLog)
+
+	expActions := []string{"user/delete", "feeds/rebuild", "search-index/build", "feedback/delete"}
+
+	if len(auditLog.Entries) != len(expActions) {
+		t.Errorf("Expected %d audit entries but got %+v.", len(expActions), auditLog.Entries)
+		return
+	}
+
+	for i, e := range auditLog.Entries {
+		if e.Action != expActions[i] || e.Actor != ts.guid {
+			t.Errorf("Expected action '%s' of '%s' but got %+v.", expActions[i], ts.guid, e)
+			return
+		}
+	}
+
+	if auditLog.Entries[0].Target != expertGUID {
+		t.Errorf("Expected target '%s' but got '%s'.", expertGUID, auditLog.Entries[0].Target)
+		return
+	}
+}
+
+func TestCatalogue(t *testing.T) {
+
+	// Setup database and service
+
+	ts := NewTestService(t)
+	defer ts.Close()
+
+	// Setup some example data.
+
+	user := ts.CreateUserProfileWithData()
+
+	// Perform test #1: the catalogue contains more records than the feed
+
+	feedCount := len(ts.SearchRecordFeed("bank", 0, 1000).Records)
+	respSearch := ts.SearchRecordCatalogue("bank", 0, 1000)
+
+	var inFeedCount, outsideFeedCount int
+
+	for _, rec := range respSearch.Records {
+		switch {
+		case !rec.InFeed:
+			outsideFeedCount++
+		case !rec.Bookmarked:
+			inFeedCount++
+		}
+	}
+
+	if inFeedCount != feedCount || outsideFeedCount == 0 {
+		t.Errorf("Expected %d search results in feed and more outside, but got %d and %d.", feedCount, inFeedCount, outsideFeedCount)
+		return
+	}
+
+	if len(respSearch.Highlights) != len(respSearch.Records) {
+		t.Errorf("Expected %d highlights but got %d.", len(respSearch.Records), len(respSearch.Highlights))
+		return
+	}
+
+	// Perform test #2: pagination
+
+	page := ts.SearchRecordCatalogue("bank", 10, 5)
+
+	if len(page.Records) != 5 || page.Records[0].Id != respSearch.Records[10].Id {
+		t.Errorf("Expected %d records starting with ID %d, but got %d.", 5, respSearch.Records[10].Id, len(page.Records))
+		return
+	}
+
+	// Perform test #3: bookmarked records are decorated
+
+	bookmark := user.RecordBookmarks[0]
+	respSearch = ts.SearchRecordCatalogue("\""+bookmark.Title+"\"", 0, 10)
+
+	var found bool
+
+	for _, rec := range respSearch.Records {
+		if rec.Id == bookmark.Id {
+			found = rec.Bookmarked && rec.InFeed
+		} else if rec.Bookmarked {
+			t.Errorf("Expected record %d not to be marked as bookmarked.", rec.Id)
+			return
+		}
+	}
+
+	if !found {
+		t.Errorf("Expected bookmarked record '%s' among search results.", bookmark.Title)
+		return
+	}
+
+	// Perform test #4: bookmarked experts are decorated
+
+	respExperts := ts.SearchExpertCatalogue("McAleer", 0, 10)
+
+	if len(respExperts.Experts) != 1 || !respExperts.Experts[0].Bookmarked || respExperts.Experts[0].Name != "M. McAleer" {
+		t.Errorf("Expected bookmarked expert '%s' as search result, but got %v.", "M. McAleer", respExperts.Experts)
+		return
+	}
+
+	// Perform test #5: malformed search queries are rejected
+
+	request := ploc.SearchExpertCatalogueRequest{SearchTerm: "authors:McAleer", Offset: 0, Limit: 10}
+
+	if statusCode, _ := ts.PostRequest("/expert-catalogue/search", &request, nil); statusCode != http.StatusBadRequest {
+		t.Errorf("Expected HTTP status %d for malformed search query but got %d.", http.StatusBadRequest, statusCode)
+		return
+	}
+}
+
 func TestCollections(t *testing.T) {
 
 	// Setup database and service
@@ -124,6 +344,71 @@ func TestExpertBookmarks(t *testing.T) {
 	}
 }
 
+func TestExpertClaims(t *testing.T) {
+
+	// Setup database and service
+
+	ts := NewTestService(t)
+	defer ts.Close()
+
+	// Setup some example data (the user's verified ORCiD is the one of all experts in the test database).
+
+	_ = ts.CreateUserProfileWithData()
+	expertA := ts.SearchExpertCatalogue("McAleer", 0, 1).Experts[0]
+	expertB := ts.SearchExpertCatalogue("Koetter", 0, 1).Experts[0]
+	someOrcId := "0000-0001-5393-1421"
+
+	// Perform test #1: claims of experts with the user's ORCiD are approved immediately
+
+	claim := ts.CreateExpertClaim(expertA.Id)
+
+	if claim.Status != "approved" || claim.OrcId != someOrcId || claim.OrcIdName != "Test Expert" {
+		t.Errorf("Expected approved claim for ORCiD '%s' of '%s' but got %v.", someOrcId, "Test Expert", claim)
+		return
+	}
+
+	if claims := ts.ReadExpertClaims().Claims; len(claims) != 1 || claims[0].Id != claim.Id {
+		t.Errorf("Expected claim %d but got %v.", claim.Id, claims)
+		return
+	}
+
+	// Perform test #2: owners edit the affiliation of their claimed experts, but not of other experts
+
+	ts.UpdateExpertDetails(expertA.Id, "FZI")
+
+	details := ts.ReadExpertDetails(expertA.Id)
+
+	if !details.Claimed || details.Affiliation != "FZI" {
+		t.Errorf("Expected claimed expert with affiliation '%s' but got '%s' (claimed: %t).", "FZI", details.Affiliation, details.Claimed)
+		return
+	}
+
+	request := ploc.UpdateExpertDetailsRequest{ExpertId: expertB.Id, Affiliation: "FZI"}
+
+	if statusCode, _ := ts.PostRequest("/expert-details/update", &request, nil); statusCode != http.StatusForbidden {
+		t.Errorf("Expected HTTP status %d for updating an unclaimed expert but got %d.", http.StatusForbidden, statusCode)
+		return
+	}
+
+	// Perform test #3: users without verified ORCiD can not claim experts
+
+	ts.CreateUserProfile()
+
+	if statusCode, _ := ts.PostRequest("/expert-claim/create", &ploc.CreateExpertClaimRequest{ExpertId: expertB.Id}, nil); statusCode != http.StatusForbidden {
+		t.Errorf("Expected HTTP status %d for a claim without verified ORCiD but got %d.", http.StatusForbidden, statusCode)
+		return
+	}
+
+	// Perform test #4: experts, that are bound to another ORCiD, can not be claimed
+
+	ts.VerifyExpertProfile("0000-0002-1825-0097", "Michael Koetter")
+
+	if statusCode, _ := ts.PostRequest("/expert-claim/create", &ploc.CreateExpertClaimRequest{ExpertId: expertB.Id}, nil); statusCode != http.StatusBadRequest {
+		t.Errorf("Expected HTTP status %d for claiming an expert of another ORCiD but got %d.", http.StatusBadRequest, statusCode)
+		return
+	}
+}
+
 func TestExpertProfile(t *testing.T) {
 
 	// Setup database and service
@@ -134,7 +419,7 @@ func TestExpertProfile(t *testing.T) {
 	// Setup some example data.
 
 	_ = ts.CreateUserProfileWithData()
-	someOrcId := "0000-0001-5393-1422"
+	someOrcId := "0000-0002-1825-0097"
 
 	// Perform test #1: Read ORCiD from expert profile
 
@@ -145,14 +430,82 @@ func TestExpertProfile(t *testing.T) {
 		return
 	}
 
+	if !expertProfile.Verified {
+		t.Errorf("Expected verified ORCiD.")
+		return
+	}
+
 	// Perform test #2: Delete expert profile
 
 	ts.DeleteExpertProfile()
 
 	expertProfile = ts.ReadExpertProfile()
 
-	if len(expertProfile.OrcId) != 0 {
-		t.Errorf("Expected an OrcId of length %d but length is %d.", 0, len(expertProfile.OrcId))
+	if len(expertProfile.OrcId) != 0 || expertProfile.Verified {
+		t.Errorf("Expected an unverified OrcId of length %d but length is %d.", 0, len(expertProfile.OrcId))
+		return
+	}
+
+	// Perform test #3: ORCiDs sent by the client are not verified and do not permit feedback
+
+	ts.CreateExpertProfile(someOrcId)
+
+	if expertProfile = ts.ReadExpertProfile(); expertProfile.OrcId != someOrcId || expertProfile.Verified {
+		t.Errorf("Expected unverified ORCiD '%s' but got '%s' (verified: %t).", someOrcId, expertProfile.OrcId, expertProfile.Verified)
+		return
+	}
+
+	recordId := ts.ReadFeedbackFeed(0, 1).Records[0].Id
+
+	request := ploc.CreateFeedbackRequest{RecordId: recordId, Relevance: 1}
+
+	if statusCode, _ := ts.PostRequest("/feedback/create", &request, nil); statusCode != http.StatusForbidden {
+		t.Errorf("Expected HTTP status %d for feedback with unverified ORCiD but got %d.", http.StatusForbidden, statusCode)
+		return
+	}
+
+	// Perform test #4: verifying the ORCiD via OAuth2 permits feedback
+
+	if statusCode := ts.VerifyExpertProfile(someOrcId, "Test Expert"); statusCode != http.StatusOK {
+		t.Errorf("Expected HTTP status %d for ORCiD callback but got %d.", http.StatusOK, statusCode)
+		return
+	}
+
+	if expertProfile = ts.ReadExpertProfile(); expertProfile.OrcId != someOrcId || !expertProfile.Verified {
+		t.Errorf("Expected verified ORCiD '%s' but got '%s' (verified: %t).", someOrcId, expertProfile.OrcId, expertProfile.Verified)
+		return
+	}
+
+	if statusCode, _ := ts.PostRequest("/feedback/create", &request, nil); statusCode != http.StatusOK {
+		t.Errorf("Expected HTTP status %d for feedback with verified ORCiD but got %d.", http.StatusOK, statusCode)
+		return
+	}
+
+	// Perform test #5: states of the callback can only be used once
+
+	resp, err := http.Get(ts.server.URL + "/orcid/callback?code=code-x&state=unknown")
+	if err != nil || resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected HTTP status %d for unknown state.", http.StatusBadRequest)
+		return
+	}
+	resp.Body.Close()
+
+	// Perform test #6: malformed ORCiDs are rejected, while URLs are canonicalized
+
+	for _, malformed := range []string{"abcd-efgh-ijkl-mnop", "0000-0002-1825-0098"} {
+
+		statusCode, _ := ts.PostRequest("/expert-profile/create", &ploc.CreateExpertProfileRequest{OrcId: malformed}, nil)
+
+		if statusCode != http.StatusBadRequest {
+			t.Errorf("Expected HTTP status %d for malformed ORCiD '%s' but got %d.", http.StatusBadRequest, malformed, statusCode)
+			return
+		}
+	}
+
+	ts.CreateExpertProfile("https://orcid.org/0000-0002-1694-233x")
+
+	if expertProfile = ts.ReadExpertProfile(); expertProfile.OrcId != "0000-0002-1694-233X" {
+		t.Errorf("Expected canonical ORCiD '%s' but got '%s'.", "0000-0002-1694-233X", expertProfile.OrcId)
 		return
 	}
 }
@@ -177,6 +530,13 @@ func TestExpertFeed(t *testing.T) {
 		return
 	}
 
+	for i, e := range respFeed.Experts {
+		if e.Score <= 0 || (i > 0 && e.Score > respFeed.Experts[i-1].Score) {
+			t.Errorf("Expected experts in descending order of positive scores, but got %f at position %d.", e.Score, i)
+			return
+		}
+	}
+
 	respFeed = ts.ReadExpertFeed(10, 10)
 
 	if len(respFeed.Experts) != 10 {
@@ -200,7 +560,7 @@ func TestExpertFeed(t *testing.T) {
 
 	previewNameA := "P. Abbassi"
 	fullNameA := "Puriya Abbassi"
-	expertA := respFeed.Experts.SelectByName(previewNameA)
+	expertA := respFeed.Experts.SelectByName(previewNameA).ExpertPreview
 	respDetails := ts.ReadExpertDetails(expertA.Id)
 
 	if respDetails.Name != fullNameA {
@@ -235,108 +595,277 @@ func TestExpertFeed(t *testing.T) {
 		t.Errorf("Expected name '%s', but got '%s'.", "N. Podlich", expertA.Name)
 		return
 	}
+
+	if len(respSearch.Highlights) != 1 || !strings.Contains(respSearch.Highlights[0].Heading, "<b>Podlich</b>") {
+		t.Errorf("Expected highlighted name '%s', but got %v.", "<b>Podlich</b>", respSearch.Highlights)
+		return
+	}
 }
 
-func TestFeedbackFeed(t *testing.T) {
+func TestExpertNetwork(t *testing.T) {
 
 	// Setup database and service
 
 	ts := NewTestService(t)
 	defer ts.Close()
 
-	// Setup some example data.
-
 	_ = ts.CreateUserProfileWithData()
 
-	// Perform test #1: Read feedback feed.
+	if err := ts.storage.BuildCoauthorshipGraph(); err != nil {
+		t.Errorf("Unexpected error. %s", err)
+		return
+	}
 
-	records := ts.ReadFeedbackFeed(0, 200).Records
+	// Find an expert with co-authors and an expert without.
 
-	expRecordCount := 92
+	var expert, loner ploc.ExpertPreview
+	var network ploc.ReadExpertNetworkResponse
 
-	if len(records) != expRecordCount {
-		t.Errorf("Expected %d number of records in feedback feed but got %d.", expRecordCount, len(records))
+	for _, e := range ts.ReadExpertFeed(0, 100).Experts {
+		resp := ts.ReadExpertNetwork(e.Id, 0)
+		if len(resp.Coauthors) > 0 && expert.Id == 0 {
+			expert, network = e.ExpertPreview, resp
+		}
+		if len(resp.Coauthors) == 0 && loner.Id == 0 {
+			loner = e.ExpertPreview
+		}
+	}
+
+	if expert.Id == 0 || loner.Id == 0 {
+		t.Errorf("Expected experts with and without co-authors.")
 		return
 	}
 
-	// Perform test #2: Provide and then read feedback for two records.
+	// Perform test #1: co-authors are ordered by the number of shared records
 
-	recordA := records[0]
-	recordB := records[1]
+	if network.ExpertId != expert.Id {
+		t.Errorf("Expected expert ID %d but got %d.", expert.Id, network.ExpertId)
+		return
+	}
 
-	ts.CreateFeedback(recordA.Id, 1, 0, 0) // Rate relevance, presentation, and methodology
-	ts.CreateFeedback(recordB.Id, 1, 1, 1)
+	for i, c := range network.Coauthors {
+		if c.RecordCount < 1 || c.Id == expert.Id || (i > 0 && c.RecordCount > network.Coauthors[i-1].RecordCount) {
+			t.Errorf("Unexpected co-author %v at position %d.", c, i)
+			return
+		}
+	}
 
-	records = ts.ReadFeedbackFeed(0, 200).Records // Rated records should not occur in record-feedback feed
+	if resp := ts.ReadExpertNetwork(expert.Id, 1); len(resp.Coauthors) != 1 {
+		t.Errorf("Expected %d co-author but got %d.", 1, len(resp.Coauthors))
+		return
+	}
 
-	expRecordCount = expRecordCount - 2
+	// Perform test #2: paths to the expert itself and to a co-author
 
-	if len(records) != expRecordCount {
-		t.Errorf("Expected %d number of records in feedback feed but got %d.", expRecordCount, len(records))
+	if resp := ts.ReadExpertPath(expert.Id, expert.Id); len(resp.Experts) != 1 || resp.Experts[0].Id != expert.Id {
+		t.Errorf("Expected path with expert %d only, but got %v.", expert.Id, resp.Experts)
 		return
 	}
 
-	// Perform test #3: Read feedback provided by user.
+	coauthor := network.Coauthors[0]
+	resp := ts.ReadExpertPath(expert.Id, coauthor.Id)
 
-	feedbacks := ts.ReadFeedback(recordA.Id).Feedbacks
+	if len(resp.Experts) != 2 || resp.Experts[0].Id != expert.Id || resp.Experts[1].Id != coauthor.Id {
+		t.Errorf("Expected path from %d to %d, but got %v.", expert.Id, coauthor.Id, resp.Experts)
+		return
+	}
 
-	expFeedbackCount := 1
+	if resp.FromId != expert.Id || resp.ToId != coauthor.Id {
+		t.Errorf("Expected IDs %d and %d but got %d and %d.", expert.Id, coauthor.Id, resp.FromId, resp.ToId)
+		return
+	}
 
-	if len(feedbacks) != expFeedbackCount {
-		t.Errorf("Expected %d number of feedbacks for record %d but got %d.", expFeedbackCount, recordA.Id, len(feedbacks))
+	// Perform test #3: unconnected experts have no path
+
+	if resp = ts.ReadExpertPath(expert.Id, loner.Id); len(resp.Experts) != 0 {
+		t.Errorf("Expected no path to expert without co-authors, but got %v.", resp.Experts)
 		return
 	}
 
-	feedbackA := feedbacks[0]
+	// Perform test #4: unknown experts are rejected
 
-	if feedbackA.RecordId != recordA.Id || feedbackA.Relevance != 1 {
-		t.Errorf("Unexpected feeback field values.")
+	request := ploc.ReadExpertPathRequest{FromId: expert.Id, ToId: 999999}
+
+	if statusCode, _ := ts.PostRequest("/expert-details/path", &request, nil); statusCode != http.StatusBadRequest {
+		t.Errorf("Expected HTTP status %d but got %d.", http.StatusBadRequest, statusCode)
 		return
 	}
 }
 
-func TestInterests(t *testing.T) {
+func TestExportRecordBookmarks(t *testing.T) {
 
 	// Setup database and service
 
 	ts := NewTestService(t)
 	defer ts.Close()
 
-	// Setup some example data
+	// Setup some example data.
 
 	profile := ts.CreateUserProfileWithData()
 
-	// Perform test #1: test assumptions about user's test profile
+	// Perform test #1: export all bookmarks as BibTeX by default
 
-	if len(profile.Interests) != 2 {
-		t.Errorf("Expected 2 interests but got %d.", len(profile.Interests))
+	statusCode, contentType, body := ts.ExportRecordBookmarks(0, "", "")
+
+	if statusCode != http.StatusOK || !strings.HasPrefix(contentType, "application/x-bibtex") {
+		t.Errorf("Expected BibTeX export but got status %d and content type '%s'.", statusCode, contentType)
 		return
 	}
 
-	// Perform test #2: test reading call and returned number of matching records
+	if strings.Count("\n"+body, "\n@") != 2 || !strings.Contains(body, "title = {Contagion dynamics in EMU government bond spreads}") {
+		t.Errorf("Expected %d BibTeX entries but got '%s'.", 2, body)
+		return
+	}
 
-	readResp := ts.ReadInterests()
+	// Perform test #2: negotiate RIS via the Accept header
 
-	if len(readResp.Subjects) != 2 {
-		t.Errorf("Expected 2 interests but got %d.", len(readResp.Subjects))
+	statusCode, contentType, body = ts.ExportRecordBookmarks(0, "", "text/html, application/x-research-info-systems;q=0.9")
+
+	if statusCode != http.StatusOK || !strings.HasPrefix(contentType, "application/x-research-info-systems") {
+		t.Errorf("Expected RIS export but got status %d and content type '%s'.", statusCode, contentType)
 		return
 	}
 
-	if readResp.RecordCount != 90 {
-		t.Errorf("Expected 90 matching records but got %d.", readResp.RecordCount)
+	if strings.Count(body, "ER  - ") != 2 {
+		t.Errorf("Expected %d RIS references but got '%s'.", 2, body)
 		return
 	}
 
-	// Perform test #3: delete an interest
+	// Perform test #3: export a single collection as CSL-JSON
 
-	interestA := profile.Interests.SelectByKeyword("Policy")
-	deleteResp := ts.DeleteInterest(interestA.Id)
+	collection := profile.Collections.SelectByTitle("Work")
+	statusCode, _, body = ts.ExportRecordBookmarks(collection.Id, "csljson", "")
 
-	if deleteResp.RecordCount != 90 {
-		t.Errorf("Expected 90 matching records but got %d.", deleteResp.RecordCount)
+	var items []map[string]interface{}
+
+	if err := json.Unmarshal([]byte(body), &items); statusCode != http.StatusOK || err != nil {
+		t.Errorf("Expected CSL-JSON export but got status %d. %v", statusCode, err)
 		return
 	}
-}
+
+	if len(items) != 1 || items[0]["title"] != "Contagion dynamics in EMU government bond spreads" {
+		t.Errorf("Expected %d CSL-JSON item but got '%s'.", 1, body)
+		return
+	}
+
+	// Perform test #4: reject unknown formats and collections
+
+	if statusCode, _, _ = ts.ExportRecordBookmarks(0, "endnote", ""); statusCode != http.StatusBadRequest {
+		t.Errorf("Expected HTTP status %d for unknown format but got %d.", http.StatusBadRequest, statusCode)
+		return
+	}
+
+	if statusCode, _, _ = ts.ExportRecordBookmarks(0, "", "text/html"); statusCode != http.StatusNotAcceptable {
+		t.Errorf("Expected HTTP status %d for unsupported media type but got %d.", http.StatusNotAcceptable, statusCode)
+		return
+	}
+
+	if statusCode, _, _ = ts.ExportRecordBookmarks(123456, "ris", ""); statusCode != http.StatusBadRequest {
+		t.Errorf("Expected HTTP status %d for unknown collection but got %d.", http.StatusBadRequest, statusCode)
+		return
+	}
+}
+
+func TestFeedbackFeed(t *testing.T) {
+
+	// Setup database and service
+
+	ts := NewTestService(t)
+	defer ts.Close()
+
+	// Setup some example data.
+
+	_ = ts.CreateUserProfileWithData()
+
+	// Perform test #1: Read feedback feed.
+
+	records := ts.ReadFeedbackFeed(0, 200).Records
+
+	expRecordCount := 92
+
+	if len(records) != expRecordCount {
+		t.Errorf("Expected %d number of records in feedback feed but got %d.", expRecordCount, len(records))
+		return
+	}
+
+	// Perform test #2: Provide and then read feedback for two records.
+
+	recordA := records[0]
+	recordB := records[1]
+
+	ts.CreateFeedback(recordA.Id, 1, 0, 0) // Rate relevance, presentation, and methodology
+	ts.CreateFeedback(recordB.Id, 1, 1, 1)
+
+	records = ts.ReadFeedbackFeed(0, 200).Records // Rated records should not occur in record-feedback feed
+
+	expRecordCount = expRecordCount - 2
+
+	if len(records) != expRecordCount {
+		t.Errorf("Expected %d number of records in feedback feed but got %d.", expRecordCount, len(records))
+		return
+	}
+
+	// Perform test #3: Read feedback provided by user.
+
+	feedbacks := ts.ReadFeedback(recordA.Id).Feedbacks
+
+	expFeedbackCount := 1
+
+	if len(feedbacks) != expFeedbackCount {
+		t.Errorf("Expected %d number of feedbacks for record %d but got %d.", expFeedbackCount, recordA.Id, len(feedbacks))
+		return
+	}
+
+	feedbackA := feedbacks[0]
+
+	if feedbackA.RecordId != recordA.Id || feedbackA.Relevance != 1 {
+		t.Errorf("Unexpected feeback field values.")
+		return
+	}
+}
+
+func TestInterests(t *testing.T) {
+
+	// Setup database and service
+
+	ts := NewTestService(t)
+	defer ts.Close()
+
+	// Setup some example data
+
+	profile := ts.CreateUserProfileWithData()
+
+	// Perform test #1: test assumptions about user's test profile
+
+	if len(profile.Interests) != 2 {
+		t.Errorf("Expected 2 interests but got %d.", len(profile.Interests))
+		return
+	}
+
+	// Perform test #2: test reading call and returned number of matching records
+
+	readResp := ts.ReadInterests()
+
+	if len(readResp.Subjects) != 2 {
+		t.Errorf("Expected 2 interests but got %d.", len(readResp.Subjects))
+		return
+	}
+
+	if readResp.RecordCount != 90 {
+		t.Errorf("Expected 90 matching records but got %d.", readResp.RecordCount)
+		return
+	}
+
+	// Perform test #3: delete an interest
+
+	interestA := profile.Interests.SelectByKeyword("Policy")
+	deleteResp := ts.DeleteInterest(interestA.Id)
+
+	if deleteResp.RecordCount != 90 {
+		t.Errorf("Expected 90 matching records but got %d.", deleteResp.RecordCount)
+		return
+	}
+}
 
 func TestRecordBookmarks(t *testing.T) {
 
@@ -451,6 +980,333 @@ func TestRecordFeed(t *testing.T) {
 		t.Errorf("Expected %d records to match search term, but got %d.", 77, len(respSearch.Records))
 		return
 	}
+
+	if len(respSearch.Highlights) != len(respSearch.Records) {
+		t.Errorf("Expected %d highlights but got %d.", len(respSearch.Records), len(respSearch.Highlights))
+		return
+	}
+
+	for i, hl := range respSearch.Highlights {
+		if hl.Id != respSearch.Records[i].Id || !strings.Contains(hl.Heading+hl.Snippet, "<b>") {
+			t.Errorf("Expected highlighted search term for record %d, but got heading '%s' and snippet '%s'.", respSearch.Records[i].Id, hl.Heading, hl.Snippet)
+			return
+		}
+	}
+
+	// Records with the search term in their title are ranked first
+
+	firstTitle := strings.ToLower(respSearch.Records[0].Title)
+
+	if !strings.Contains(firstTitle, "bank") {
+		t.Errorf("Expected search term in title of the most relevant record, but got '%s'.", firstTitle)
+		return
+	}
+
+	// Perform test #4: search feed with column filters and operators
+
+	inTitle := ts.SearchRecordFeed("title:bank", 0, 100).Records
+	notInTitle := ts.SearchRecordFeed("bank NOT title:bank", 0, 100).Records
+
+	if len(inTitle) == 0 || len(inTitle)+len(notInTitle) != 77 {
+		t.Errorf("Expected %d records to match search terms in total, but got %d and %d.", 77, len(inTitle), len(notInTitle))
+		return
+	}
+
+	// Perform test #5: malformed search queries are rejected
+
+	request := ploc.SearchRecordFeedRequest{SearchTerm: "title:(bank", Offset: 0, Limit: 100}
+
+	if statusCode, _ := ts.PostRequest("/record-feed/search", &request, nil); statusCode != http.StatusBadRequest {
+		t.Errorf("Expected HTTP status %d for malformed search query but got %d.", http.StatusBadRequest, statusCode)
+		return
+	}
+
+	// Perform test #6: facet counts of the unfiltered feed
+
+	facets := ts.ReadRecordFeed(0, 10).Facets
+
+	if facets.RecordCount != 90 {
+		t.Errorf("Expected %d records to be counted but got %d.", 90, facets.RecordCount)
+		return
+	}
+
+	for name, counts := range map[string]ploc.FacetCounts{"years": facets.Years, "types": facets.Types, "open access": facets.OpenAccess} {
+
+		var sum int64
+		for _, fc := range counts {
+			if fc.Label == "" {
+				t.Errorf("Expected label for value %d of %s facet.", fc.Value, name)
+				return
+			}
+			sum += fc.Count
+		}
+
+		if sum != facets.RecordCount {
+			t.Errorf("Expected counts of %s facet to sum up to %d but got %d.", name, facets.RecordCount, sum)
+			return
+		}
+	}
+
+	if len(facets.Subjects) == 0 || facets.Subjects[0].Label == "" {
+		t.Errorf("Expected labeled subject facets.")
+		return
+	}
+
+	// Perform test #7: filter feed by the facet values
+
+	year := facets.Years[0]
+	filtered := ts.ReadFilteredRecordFeed(ploc.RecordFeedFilter{YearFrom: year.Value, YearTo: year.Value}, 0, 100)
+
+	if int64(len(filtered.Records)) != year.Count || filtered.Facets.RecordCount != year.Count {
+		t.Errorf("Expected %d records of year %d but got %d (counted %d).", year.Count, year.Value, len(filtered.Records), filtered.Facets.RecordCount)
+		return
+	}
+
+	for _, rec := range filtered.Records {
+		if rec.Year != year.Value {
+			t.Errorf("Expected only records of year %d but got %d.", year.Value, rec.Year)
+			return
+		}
+	}
+
+	recordType := facets.Types[0]
+	filtered = ts.ReadFilteredRecordFeed(ploc.RecordFeedFilter{Types: []int64{recordType.Value}}, 0, 100)
+
+	if int64(len(filtered.Records)) != recordType.Count {
+		t.Errorf("Expected %d records of type %d but got %d.", recordType.Count, recordType.Value, len(filtered.Records))
+		return
+	}
+
+	subject := facets.Subjects[0]
+	included := ts.ReadFilteredRecordFeed(ploc.RecordFeedFilter{IncludeSubjectIds: []int64{subject.Value}}, 0, 100)
+	excluded := ts.ReadFilteredRecordFeed(ploc.RecordFeedFilter{ExcludeSubjectIds: []int64{subject.Value}}, 0, 100)
+
+	if int64(len(included.Records)) != subject.Count || len(included.Records)+len(excluded.Records) != 90 {
+		t.Errorf("Expected %d records with subject '%s' and %d without, but got %d and %d.", subject.Count, subject.Label, 90-subject.Count, len(included.Records), len(excluded.Records))
+		return
+	}
+
+	// Perform test #8: filter search results
+
+	searched := ts.SearchFilteredRecordFeed("bank", ploc.RecordFeedFilter{YearFrom: year.Value, YearTo: year.Value}, 0, 100)
+
+	if searched.Facets.RecordCount != int64(len(searched.Records)) || len(searched.Records) > int(year.Count) {
+		t.Errorf("Expected at most %d search results of year %d but got %d (counted %d).", year.Count, year.Value, len(searched.Records), searched.Facets.RecordCount)
+		return
+	}
+
+	// Perform test #9: contradicting filters are rejected
+
+	feedRequest := ploc.ReadRecordFeedRequest{Offset: 0, Limit: 100, Filter: ploc.RecordFeedFilter{YearFrom: 2015, YearTo: 2010}}
+
+	if statusCode, _ := ts.PostRequest("/record-feed/read", &feedRequest, nil); statusCode != http.StatusBadRequest {
+		t.Errorf("Expected HTTP status %d for empty year range but got %d.", http.StatusBadRequest, statusCode)
+		return
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+
+	// Setup database and service
+
+	ts := NewTestService(t)
+	defer ts.Close()
+
+	ts.CreateUserProfile()
+
+	limit := config.DefaultConfiguration().WebAPI.RateLimit
+	limit.IPBurst = 5
+	limit.IPRate = 1
+	ts.SetRateLimit(limit)
+
+	// Perform test #1: requests of an IP address are limited to its burst
+
+	for i := 0; i < limit.IPBurst; i++ {
+		ts.ReadRecordTypes()
+	}
+
+	if statusCode, _ := ts.PostRequest("/record-types/read", nil, nil); statusCode != http.StatusTooManyRequests {
+		t.Errorf("Expected HTTP status %d after %d requests but got %d.", http.StatusTooManyRequests, limit.IPBurst, statusCode)
+		return
+	}
+
+	// Perform test #2: new user profiles are limited per IP address
+
+	limit = config.DefaultConfiguration().WebAPI.RateLimit
+	limit.MaxFailures = 3
+	ts.SetRateLimit(limit)
+
+	for i := 0; i < limit.ProfileBurst; i++ {
+		ts.CreateUserProfile()
+	}
+
+	request := ploc.CreateUserProfileRequest{Secret: "dsj738hFs3d:Kl67jdk"}
+
+	if statusCode, _ := ts.PostRequest("/user-profile/create", &request, nil); statusCode != http.StatusTooManyRequests {
+		t.Errorf("Expected HTTP status %d after %d new profiles but got %d.", http.StatusTooManyRequests, limit.ProfileBurst, statusCode)
+		return
+	}
+
+	// Perform test #3: repeated failed authorizations lock the client, even with the right secret, but not the user
+
+	limit.TrustProxy = true
+	ts.SetRateLimit(limit)
+
+	session := ts.CreateSession()
+
+	post := func(ip string, secret string, token string) *http.Response {
+
+		req, _ := http.NewRequest("POST", ts.server.URL+"/plocapi/v1/record-types/read", nil)
+		req.Header.Set("X-Forwarded-For", ip)
+
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		} else {
+			req.SetBasicAuth(ts.guid, secret)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Unexpected error. %s", err)
+		}
+		resp.Body.Close()
+
+		return resp
+	}
+
+	for i := 0; i < limit.MaxFailures; i++ {
+		if resp := post("10.0.0.1", "wrong secret", ""); resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("Expected HTTP status %d for wrong secret but got %d.", http.StatusUnauthorized, resp.StatusCode)
+			return
+		}
+	}
+
+	if resp := post("10.0.0.1", ts.secret, ""); resp.StatusCode != http.StatusTooManyRequests || resp.Header.Get("Retry-After") != "900" {
+		t.Errorf("Expected HTTP status %d with 'Retry-After: 900' but got %d with '%s'.", http.StatusTooManyRequests, resp.StatusCode, resp.Header.Get("Retry-After"))
+		return
+	}
+
+	if resp := post("10.0.0.2", ts.secret, ""); resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected HTTP status %d for the right secret of another client but got %d.", http.StatusOK, resp.StatusCode)
+		return
+	}
+
+	if resp := post("10.0.0.2", "", session.AccessToken); resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected HTTP status %d for a valid session of another client but got %d.", http.StatusOK, resp.StatusCode)
+		return
+	}
+
+	// Perform test #4: guessing feed tokens locks the client IP address
+
+	feedURL := ts.server.URL + "/syndication/records.atom?token="
+
+	for i := 0; i < limit.MaxFailures; i++ {
+		if resp, _ := ts.GetFeed(feedURL+"guessed", map[string]string{"X-Forwarded-For": "10.0.0.3"}); resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("Expected HTTP status %d for unknown feed token but got %d.", http.StatusUnauthorized, resp.StatusCode)
+			return
+		}
+	}
+
+	if resp, _ := ts.GetFeed(feedURL+"guessed", map[string]string{"X-Forwarded-For": "10.0.0.3"}); resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("Expected HTTP status %d after %d unknown feed tokens but got %d.", http.StatusTooManyRequests, limit.MaxFailures, resp.StatusCode)
+		return
+	}
+}
+
+func TestRecordRecommendations(t *testing.T) {
+
+	// Setup database and service
+
+	ts := NewTestService(t)
+	defer ts.Close()
+
+	ts.CreateUserProfile()
+
+	records := ts.SearchRecordCatalogue("market", 0, 4).Records
+
+	if len(records) != 4 {
+		t.Errorf("Expected %d records but got %d.", 4, len(records))
+		return
+	}
+
+	// Three users bookmark the first two records, two of them also the third record.
+
+	for i := 0; i < 3; i++ {
+		if i > 0 {
+			ts.CreateUserProfile()
+		}
+		ts.CreateRecordBookmark(records[0].Id)
+		ts.CreateRecordBookmark(records[1].Id)
+		if i > 0 {
+			ts.CreateRecordBookmark(records[2].Id)
+		}
+	}
+
+	// Perform test #1: no recommendations before the similarities are built
+
+	ts.CreateUserProfile()
+	ts.CreateRecordBookmark(records[0].Id)
+
+	if resp := ts.ReadRecordRecommendations(0, 10); len(resp.Records) != 0 {
+		t.Errorf("Expected no recommendations but got %d.", len(resp.Records))
+		return
+	}
+
+	// Perform test #2: only records with enough support are recommended
+
+	if pairCount, err := ts.storage.BuildRecordSimilarities(3); err != nil || pairCount != 2 {
+		t.Errorf("Expected %d similar record pairs but got %d. %v", 2, pairCount, err)
+		return
+	}
+
+	resp := ts.ReadRecordRecommendations(0, 10)
+
+	if len(resp.Records) != 1 || resp.Records[0].Id != records[1].Id {
+		t.Errorf("Expected record %d as only recommendation, but got %v.", records[1].Id, resp.Records)
+		return
+	}
+
+	if resp.Offset != 0 || resp.Limit != 10 {
+		t.Errorf("Expected offset %d and limit %d but got %d and %d.", 0, 10, resp.Offset, resp.Limit)
+		return
+	}
+
+	// Perform test #3: disliked records are not recommended
+
+	ts.CreateRecordDislike(records[1].Id)
+
+	if resp = ts.ReadRecordRecommendations(0, 10); len(resp.Records) != 0 {
+		t.Errorf("Expected no recommendations after dislike but got %d.", len(resp.Records))
+		return
+	}
+
+	// Perform test #4: a higher minimum support hides all pairs
+
+	if pairCount, _ := ts.storage.BuildRecordSimilarities(4); pairCount != 0 {
+		t.Errorf("Expected no similar record pairs but got %d.", pairCount)
+		return
+	}
+
+	// Perform test #5: pairs of records, that a single user engaged with, are never recommended
+
+	ts.CreateRecordBookmark(records[3].Id)
+
+	if _, err := ts.storage.BuildRecordSimilarities(1); err == nil {
+		t.Errorf("Expected error for a minimum support lower than %d.", storage.MinRecordSimilaritySupport)
+		return
+	}
+
+	ts.CreateUserProfile()
+	ts.CreateRecordBookmark(records[0].Id)
+
+	ts.storage.BuildRecordSimilarities(storage.MinRecordSimilaritySupport)
+
+	for _, r := range ts.ReadRecordRecommendations(0, 10).Records {
+		if r.Id == records[3].Id {
+			t.Errorf("Expected record %d, that only a single user engaged with, not to be recommended.", records[3].Id)
+			return
+		}
+	}
 }
 
 func TestRecordTypes(t *testing.T) {
@@ -474,6 +1330,250 @@ func TestRecordTypes(t *testing.T) {
 	}
 }
 
+func TestRelatedRecords(t *testing.T) {
+
+	// Setup database and service
+
+	ts := NewTestService(t)
+	defer ts.Close()
+
+	_ = ts.CreateUserProfileWithData()
+
+	recordId := ts.ReadRecordFeed(0, 1).Records[0].Id
+
+	// Perform test #1: related records exclude the record itself and respect the limit
+
+	resp := ts.ReadRelatedRecords(recordId, 5)
+
+	if resp.RecordId != recordId {
+		t.Errorf("Expected record ID %d but got %d.", recordId, resp.RecordId)
+		return
+	}
+
+	if len(resp.Records) == 0 || len(resp.Records) > 5 {
+		t.Errorf("Expected between %d and %d related records but got %d.", 1, 5, len(resp.Records))
+		return
+	}
+
+	for _, r := range resp.Records {
+		if r.Id == recordId {
+			t.Errorf("Expected record %d not to be related to itself.", recordId)
+			return
+		}
+	}
+
+	// Perform test #2: cached similarities return the same records
+
+	cached := ts.ReadRelatedRecords(recordId, 5)
+
+	if len(cached.Records) != len(resp.Records) {
+		t.Errorf("Expected %d cached related records but got %d.", len(resp.Records), len(cached.Records))
+		return
+	}
+
+	for i := range cached.Records {
+		if cached.Records[i].Id != resp.Records[i].Id {
+			t.Errorf("Expected cached record %d at position %d but got %d.", resp.Records[i].Id, i, cached.Records[i].Id)
+			return
+		}
+	}
+
+	// Perform test #3: the number of related records is capped
+
+	if resp = ts.ReadRelatedRecords(recordId, 1000); len(resp.Records) > maxRelatedRecordLimit {
+		t.Errorf("Expected at most %d related records but got %d.", maxRelatedRecordLimit, len(resp.Records))
+		return
+	}
+
+	// Perform test #4: unknown records are rejected
+
+	request := ploc.ReadRelatedRecordsRequest{RecordId: 999999}
+
+	if statusCode, _ := ts.PostRequest("/record-details/related", &request, nil); statusCode != http.StatusBadRequest {
+		t.Errorf("Expected HTTP status %d but got %d.", http.StatusBadRequest, statusCode)
+		return
+	}
+}
+
+func TestSessions(t *testing.T) {
+
+	// Setup database and service
+
+	ts := NewTestService(t)
+	defer ts.Close()
+
+	ts.CreateUserProfile()
+
+	// Perform test #1: log in with GUID and secret
+
+	session := ts.CreateSession()
+
+	if session.AccessToken == "" || session.RefreshToken == "" || session.TokenType != "Bearer" || session.ExpiresIn != 15*60 {
+		t.Errorf("Expected Bearer tokens that expire in %d seconds but got %+v.", 15*60, session.SessionTokens)
+		return
+	}
+
+	statusCode, _ := ts.PostRequest("/session/create", &ploc.CreateSessionRequest{GUID: ts.guid, Secret: "wrong secret phrase"}, nil)
+
+	if statusCode != http.StatusUnauthorized {
+		t.Errorf("Expected HTTP status %d for wrong secret but got %d.", http.StatusUnauthorized, statusCode)
+		return
+	}
+
+	// Perform test #2: requests are authorized by the access token
+
+	ts.token = session.AccessToken
+
+	if statusCode, _ = ts.PostRequest("/interests/read", nil, nil); statusCode != http.StatusOK {
+		t.Errorf("Expected HTTP status %d for access token but got %d.", http.StatusOK, statusCode)
+		return
+	}
+
+	ts.token = session.AccessToken + "x"
+
+	if statusCode, _ = ts.PostRequest("/interests/read", nil, nil); statusCode != http.StatusUnauthorized {
+		t.Errorf("Expected HTTP status %d for tampered access token but got %d.", http.StatusUnauthorized, statusCode)
+		return
+	}
+
+	// Perform test #3: refresh tokens can be used only once
+
+	refreshed := ts.RefreshSession(session.RefreshToken)
+
+	if refreshed.AccessToken == "" || refreshed.RefreshToken == session.RefreshToken {
+		t.Errorf("Expected fresh tokens after refreshing session.")
+		return
+	}
+
+	statusCode, _ = ts.PostRequest("/session/refresh", &ploc.RefreshSessionRequest{RefreshToken: session.RefreshToken}, nil)
+
+	if statusCode != http.StatusUnauthorized {
+		t.Errorf("Expected HTTP status %d for used refresh token but got %d.", http.StatusUnauthorized, statusCode)
+		return
+	}
+
+	// Perform test #4: revoked sessions are rejected, while BasicAuth still works
+
+	ts.token = refreshed.AccessToken
+	ts.DeleteSession(refreshed.RefreshToken, false)
+
+	if statusCode, _ = ts.PostRequest("/interests/read", nil, nil); statusCode != http.StatusUnauthorized {
+		t.Errorf("Expected HTTP status %d for revoked session but got %d.", http.StatusUnauthorized, statusCode)
+		return
+	}
+
+	ts.token = ""
+
+	if statusCode, _ = ts.PostRequest("/interests/read", nil, nil); statusCode != http.StatusOK {
+		t.Errorf("Expected HTTP status %d for BasicAuth but got %d.", http.StatusOK, statusCode)
+		return
+	}
+
+	// Perform test #5: all sessions of a user can be revoked at once
+
+	sessionA := ts.CreateSession()
+	sessionB := ts.CreateSession()
+
+	ts.DeleteSession("", true)
+
+	for _, s := range []ploc.CreateSessionResponse{sessionA, sessionB} {
+		ts.token = s.AccessToken
+		if statusCode, _ = ts.PostRequest("/interests/read", nil, nil); statusCode != http.StatusUnauthorized {
+			t.Errorf("Expected HTTP status %d for revoked session but got %d.", http.StatusUnauthorized, statusCode)
+			return
+		}
+	}
+
+	// Perform test #6: expired access tokens are rejected
+
+	key := []byte("secret")
+
+	if sessionId, err := verifyAccessToken(key, signAccessToken(key, 42, time.Now().Add(time.Minute))); err != nil || sessionId != 42 {
+		t.Errorf("Expected session %d for valid access token but got %d. %s", 42, sessionId, err)
+		return
+	}
+
+	if _, err := verifyAccessToken(key, signAccessToken(key, 42, time.Now().Add(-time.Minute))); err == nil {
+		t.Errorf("Expected error for expired access token.")
+		return
+	}
+}
+
+func TestSyndicationFeed(t *testing.T) {
+
+	// Setup database and service
+
+	ts := NewTestService(t)
+	defer ts.Close()
+
+	// Setup some example data.
+
+	profile := ts.CreateUserProfileWithData()
+	feedToken := ts.CreateFeedToken()
+
+	// Perform test #1: read the record feed as Atom feed
+
+	resp, body := ts.GetFeed(feedToken.AtomURL, nil)
+
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "application/atom+xml") {
+		t.Errorf("Expected Atom feed but got status %d and content type '%s'.", resp.StatusCode, resp.Header.Get("Content-Type"))
+		return
+	}
+
+	if strings.Count(body, "<entry>") != 50 || !strings.Contains(body, "<published>") {
+		t.Errorf("Expected %d entries in the Atom feed but got %d.", 50, strings.Count(body, "<entry>"))
+		return
+	}
+
+	etag := resp.Header.Get("ETag")
+	lastModified := resp.Header.Get("Last-Modified")
+
+	// Perform test #2: conditional requests for an unchanged feed are answered without content
+
+	resp, _ = ts.GetFeed(feedToken.AtomURL, map[string]string{"If-None-Match": etag})
+
+	if resp.StatusCode != http.StatusNotModified {
+		t.Errorf("Expected HTTP status %d for matching entity tag but got %d.", http.StatusNotModified, resp.StatusCode)
+		return
+	}
+
+	resp, _ = ts.GetFeed(feedToken.AtomURL, map[string]string{"If-Modified-Since": lastModified})
+
+	if resp.StatusCode != http.StatusNotModified {
+		t.Errorf("Expected HTTP status %d for unmodified feed but got %d.", http.StatusNotModified, resp.StatusCode)
+		return
+	}
+
+	// Perform test #3: the RSS feed has its own entity tag
+
+	resp, body = ts.GetFeed(feedToken.RSSURL, map[string]string{"If-None-Match": etag})
+
+	if resp.StatusCode != http.StatusOK || strings.Count(body, "<item>") != 50 || !strings.Contains(body, "<dc:creator>") {
+		t.Errorf("Expected RSS feed with %d items but got status %d.", 50, resp.StatusCode)
+		return
+	}
+
+	// Perform test #4: changing the user's interests changes the feed
+
+	ts.DeleteInterest(profile.Interests.SelectByKeyword("Financial Economics").Id)
+
+	resp, _ = ts.GetFeed(feedToken.AtomURL, map[string]string{"If-None-Match": etag})
+
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("ETag") == etag {
+		t.Errorf("Expected changed feed but got status %d and entity tag '%s'.", resp.StatusCode, resp.Header.Get("ETag"))
+		return
+	}
+
+	// Perform test #5: revoked tokens are rejected
+
+	ts.DeleteFeedToken()
+
+	if resp, _ = ts.GetFeed(feedToken.AtomURL, nil); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected HTTP status %d for revoked token but got %d.", http.StatusUnauthorized, resp.StatusCode)
+		return
+	}
+}
+
 func TestSubjects(t *testing.T) {
 
 	// Setup database and service
@@ -494,3 +1594,167 @@ func TestSubjects(t *testing.T) {
 		return
 	}
 }
+
+func TestSuggestions(t *testing.T) {
+
+	// Setup database and service
+
+	ts := NewTestService(t)
+	defer ts.Close()
+
+	ts.CreateUserProfile()
+
+	// Perform test #1: complete subjects by prefixes of their words
+
+	resp := ts.ReadSuggestions("fin eco", 5)
+
+	if len(resp.Subjects) == 0 || resp.Subjects[0].Text != "Financial Economics" {
+		t.Errorf("Expected '%s' as first subject suggestion, but got %v.", "Financial Economics", resp.Subjects)
+		return
+	}
+
+	for _, s := range resp.Records {
+		if !strings.Contains(strings.ToLower(s.Text), "fin") {
+			t.Errorf("Expected record suggestions to contain the input, but got '%s'.", s.Text)
+			return
+		}
+	}
+
+	// Perform test #2: suggestions that start with the input are ranked first
+
+	resp = ts.ReadSuggestions("Podl", 5)
+
+	if len(resp.Experts) == 0 || !strings.Contains(resp.Experts[0].Text, "Podlich") {
+		t.Errorf("Expected expert '%s' as suggestion, but got %v.", "Podlich", resp.Experts)
+		return
+	}
+
+	resp = ts.ReadSuggestions("bank", 20)
+
+	if len(resp.Subjects) < 2 || !strings.HasPrefix(strings.ToLower(resp.Subjects[0].Text), "bank") {
+		t.Errorf("Expected subject starting with '%s' as first suggestion, but got %v.", "bank", resp.Subjects)
+		return
+	}
+
+	// Perform test #3: limits and empty input
+
+	if resp = ts.ReadSuggestions("e", 100); len(resp.Records) != maxSuggestionLimit {
+		t.Errorf("Expected %d record suggestions but got %d.", maxSuggestionLimit, len(resp.Records))
+		return
+	}
+
+	if resp = ts.ReadSuggestions(" \"*( ", 5); len(resp.Subjects)+len(resp.Experts)+len(resp.Records) != 0 {
+		t.Errorf("Expected no suggestions for input without words, but got %v.", resp)
+		return
+	}
+}
+
+func TestUserSecret(t *testing.T) {
+
+	// Setup database and service
+
+	ts := NewTestService(t)
+	defer ts.Close()
+
+	ts.CreateUserProfile()
+
+	// Perform test #1: recovery codes are created at signup
+
+	if len(ts.codes) != 10 {
+		t.Errorf("Expected %d recovery codes but got %d.", 10, len(ts.codes))
+		return
+	}
+
+	// Perform test #2: updating the secret rejects the old secret and revokes sessions
+
+	oldSecret := ts.secret
+	session := ts.CreateSession()
+
+	ts.UpdateUserSecret("jd7Gs9:kL2mxP4qRt")
+
+	if statusCode, _ := ts.PostRequest("/interests/read", nil, nil); statusCode != http.StatusOK {
+		t.Errorf("Expected HTTP status %d for new secret but got %d.", http.StatusOK, statusCode)
+		return
+	}
+
+	ts.secret = oldSecret
+
+	if statusCode, _ := ts.PostRequest("/interests/read", nil, nil); statusCode != http.StatusUnauthorized {
+		t.Errorf("Expected HTTP status %d for old secret but got %d.", http.StatusUnauthorized, statusCode)
+		return
+	}
+
+	ts.token = session.AccessToken
+
+	if statusCode, _ := ts.PostRequest("/interests/read", nil, nil); statusCode != http.StatusUnauthorized {
+		t.Errorf("Expected HTTP status %d for revoked session but got %d.", http.StatusUnauthorized, statusCode)
+		return
+	}
+
+	ts.token = ""
+	ts.secret = "jd7Gs9:kL2mxP4qRt"
+
+	// Perform test #3: secrets that are too short are rejected
+
+	if statusCode, _ := ts.PostRequest("/user-profile/update-secret", &ploc.UpdateUserSecretRequest{CurrentSecret: ts.secret, Secret: "short"}, nil); statusCode != http.StatusBadRequest {
+		t.Errorf("Expected HTTP status %d for short secret but got %d.", http.StatusBadRequest, statusCode)
+		return
+	}
+
+	// Perform test #4: an access token without the current secret can not update the secret
+
+	ts.token = ts.CreateSession().AccessToken
+
+	wrongSecret := ploc.UpdateUserSecretRequest{CurrentSecret: oldSecret, Secret: "Hx72kd:Pq93mzL1wt"}
+
+	if statusCode, _ := ts.PostRequest("/user-profile/update-secret", &wrongSecret, nil); statusCode != http.StatusForbidden {
+		t.Errorf("Expected HTTP status %d for wrong current secret but got %d.", http.StatusForbidden, statusCode)
+		return
+	}
+
+	if statusCode, _ := ts.PostRequest("/interests/read", nil, nil); statusCode != http.StatusOK {
+		t.Errorf("Expected HTTP status %d for unchanged session but got %d.", http.StatusOK, statusCode)
+		return
+	}
+
+	ts.token = ""
+
+	// Perform test #5: a recovery code resets the secret once
+
+	request := ploc.RecoverUserProfileRequest{GUID: ts.guid, RecoveryCode: strings.ToUpper(ts.codes[3]), Secret: "Lk83hd:Jw72mnB0xz"}
+
+	if statusCode, _ := ts.PostRequest("/user-profile/recover", &request, nil); statusCode != http.StatusOK {
+		t.Errorf("Expected HTTP status %d for recovery but got %d.", http.StatusOK, statusCode)
+		return
+	}
+
+	ts.secret = request.Secret
+
+	if statusCode, _ := ts.PostRequest("/interests/read", nil, nil); statusCode != http.StatusOK {
+		t.Errorf("Expected HTTP status %d for recovered secret but got %d.", http.StatusOK, statusCode)
+		return
+	}
+
+	if statusCode, _ := ts.PostRequest("/user-profile/recover", &request, nil); statusCode != http.StatusUnauthorized {
+		t.Errorf("Expected HTTP status %d for used recovery code but got %d.", http.StatusUnauthorized, statusCode)
+		return
+	}
+
+	// Perform test #6: fresh recovery codes replace the former ones
+
+	codes := ts.CreateRecoveryCodes().RecoveryCodes
+
+	request.RecoveryCode = ts.codes[4]
+
+	if statusCode, _ := ts.PostRequest("/user-profile/recover", &request, nil); len(codes) != 10 || statusCode != http.StatusUnauthorized {
+		t.Errorf("Expected HTTP status %d for replaced recovery code but got %d.", http.StatusUnauthorized, statusCode)
+		return
+	}
+
+	request.RecoveryCode = codes[0]
+
+	if statusCode, _ := ts.PostRequest("/user-profile/recover", &request, nil); statusCode != http.StatusOK {
+		t.Errorf("Expected HTTP status %d for fresh recovery code but got %d.", http.StatusOK, statusCode)
+		return
+	}
+}
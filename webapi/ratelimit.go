@@ -0,0 +1,107 @@
+package webapi
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// rateLimitHandler encapsulates a Web request handler without user authentication, whose requests are limited per
+// client IP address (e.g. logging in or creating a user profile).
+func rateLimitHandler(handler func(http.ResponseWriter, *http.Request), c *Context) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		if !c.allowIP(w, r) {
+			return
+		}
+
+		handler(w, r)
+	}
+}
+
+// allowIP takes a token of the rate limit of the client's IP address. If the IP address has exceeded its rate limit
+// or is locked due to failed authorizations, the request is answered with HTTP status 429 and false is returned.
+func (c *Context) allowIP(w http.ResponseWriter, r *http.Request) bool {
+
+	ip := c.clientIP(r)
+
+	if retryAfter := c.limiter.AllowIP(ip); retryAfter > 0 {
+		handleTooManyRequests(w, retryAfter, fmt.Sprintf("Rate limit of IP address '%s' exceeded on '%s'.", ip, r.URL.Path))
+		return false
+	}
+
+	return true
+}
+
+// allowGUID takes a token of the rate limit of an authenticated user. If the user has exceeded the rate limit, the
+// request is answered with HTTP status 429 and false is returned.
+func (c *Context) allowGUID(w http.ResponseWriter, guid string) bool {
+
+	if retryAfter := c.limiter.AllowGUID(guid); retryAfter > 0 {
+		handleTooManyRequests(w, retryAfter, fmt.Sprintf("Rate limit of GUID '%s' exceeded.", guid))
+		return false
+	}
+
+	return true
+}
+
+// allowAttempt checks whether the client may try to authorize as the user with the GUID. If the client's IP address
+// is locked for the GUID due to failed authorizations, the request is answered with HTTP status 429 and false is
+// returned. Failures of other clients do not affect the client.
+func (c *Context) allowAttempt(w http.ResponseWriter, r *http.Request, guid string) bool {
+
+	ip := c.clientIP(r)
+
+	if retryAfter := c.limiter.AllowAttempt(ip, guid); retryAfter > 0 {
+		handleTooManyRequests(w, retryAfter, fmt.Sprintf("IP address '%s' is locked for GUID '%s' due to failed authorizations.", ip, guid))
+		return false
+	}
+
+	return true
+}
+
+// allowProfile takes a token of the rate limit for creating user profiles from the client's IP address. If the limit
+// is exceeded, the request is answered with HTTP status 429 and false is returned.
+func (c *Context) allowProfile(w http.ResponseWriter, r *http.Request) bool {
+
+	ip := c.clientIP(r)
+
+	if retryAfter := c.limiter.AllowProfile(ip); retryAfter > 0 {
+		handleTooManyRequests(w, retryAfter, fmt.Sprintf("Rate limit for creating user profiles exceeded by IP address '%s'.", ip))
+		return false
+	}
+
+	return true
+}
+
+// authorizationFailed counts a failed authorization of the client's IP address and, if it is known, of the client's
+// IP address for the GUID.
+func (c *Context) authorizationFailed(r *http.Request, guid string) {
+	c.limiter.Fail(c.clientIP(r), guid)
+}
+
+// authorizationSucceeded forgets the failed authorizations of the client's IP address for the GUID.
+func (c *Context) authorizationSucceeded(r *http.Request, guid string) {
+	c.limiter.Succeed(c.clientIP(r), guid)
+}
+
+// clientIP returns the IP address of the client, that has sent a request. Behind a trusted reverse proxy, it is the
+// address that the proxy has appended to the header "X-Forwarded-For", since preceding addresses may be forged.
+func (c *Context) clientIP(r *http.Request) string {
+
+	if c.conf.RateLimit.TrustProxy {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			addresses := strings.Split(forwarded, ",")
+			return strings.TrimSpace(addresses[len(addresses)-1])
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
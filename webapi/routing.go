@@ -22,61 +22,122 @@ func newRouter(conf *config.WebAPIConfiguration, st *storage.Storage, ledger *le
 	plocRouter := router.PathPrefix("/plocapi/v1/").Subrouter()
 
 	// User profile
-	plocRouter.HandleFunc("/user-profile/create", defaultHandler(context.createUserProfile)).Methods("POST")
-	plocRouter.HandleFunc("/user-profile/delete", authorizationHandler(context.deleteUserProfile, st)).Methods("POST")
+	plocRouter.HandleFunc("/user-profile/create", defaultHandler(rateLimitHandler(context.createUserProfile, context))).Methods("POST")
+	plocRouter.HandleFunc("/user-profile/delete", authorizationHandler(context.deleteUserProfile, context)).Methods("POST")
+	plocRouter.HandleFunc("/user-profile/update-secret", authorizationHandler(context.updateUserSecret, context)).Methods("POST")
+	plocRouter.HandleFunc("/user-profile/recover", defaultHandler(rateLimitHandler(context.recoverUserProfile, context))).Methods("POST")
+	plocRouter.HandleFunc("/recovery-codes/create", authorizationHandler(context.createRecoveryCodes, context)).Methods("POST")
+
+	// Sessions
+	plocRouter.HandleFunc("/session/create", defaultHandler(rateLimitHandler(context.createSession, context))).Methods("POST")
+	plocRouter.HandleFunc("/session/refresh", defaultHandler(rateLimitHandler(context.refreshSession, context))).Methods("POST")
+	plocRouter.HandleFunc("/session/delete", authorizationHandler(context.deleteSession, context)).Methods("POST")
 
 	// Expert profile
-	plocRouter.HandleFunc("/expert-profile/create", authorizationHandler(context.createExpertProfile, st)).Methods("POST")
-	plocRouter.HandleFunc("/expert-profile/delete", authorizationHandler(context.deleteExpertProfile, st)).Methods("POST")
-	plocRouter.HandleFunc("/expert-profile/read", authorizationHandler(context.readExpertProfile, st)).Methods("POST")
+	plocRouter.HandleFunc("/expert-profile/create", authorizationHandler(context.createExpertProfile, context)).Methods("POST")
+	plocRouter.HandleFunc("/expert-profile/delete", authorizationHandler(context.deleteExpertProfile, context)).Methods("POST")
+	plocRouter.HandleFunc("/expert-profile/read", authorizationHandler(context.readExpertProfile, context)).Methods("POST")
+	plocRouter.HandleFunc("/expert-profile/authorize", authorizationHandler(context.authorizeExpertProfile, context)).Methods("POST")
+	plocRouter.HandleFunc("/expert-claim/create", authorizationHandler(context.createExpertClaim, context)).Methods("POST")
+	plocRouter.HandleFunc("/expert-claims/read", authorizationHandler(context.readExpertClaims, context)).Methods("POST")
 
 	// Subjects
-	plocRouter.HandleFunc("/subjects/read", authorizationHandler(context.readSubjects, st)).Methods("POST")
+	plocRouter.HandleFunc("/subjects/read", authorizationHandler(context.readSubjects, context)).Methods("POST")
 
 	// Interests
-	plocRouter.HandleFunc("/interest/create", authorizationHandler(context.createInterest, st)).Methods("POST")
-	plocRouter.HandleFunc("/interest/delete", authorizationHandler(context.deleteInterest, st)).Methods("POST")
-	plocRouter.HandleFunc("/interests/read", authorizationHandler(context.readInterests, st)).Methods("POST")
+	plocRouter.HandleFunc("/interest/create", authorizationHandler(context.createInterest, context)).Methods("POST")
+	plocRouter.HandleFunc("/interest/delete", authorizationHandler(context.deleteInterest, context)).Methods("POST")
+	plocRouter.HandleFunc("/interests/read", authorizationHandler(context.readInterests, context)).Methods("POST")
 
 	// Record-Types
-	plocRouter.HandleFunc("/record-types/read", authorizationHandler(context.readRecordTypes, st)).Methods("POST")
+	plocRouter.HandleFunc("/record-types/read", authorizationHandler(context.readRecordTypes, context)).Methods("POST")
 
 	// Record-Feed
-	plocRouter.HandleFunc("/record-feed/read", authorizationHandler(context.readRecordFeed, st)).Methods("POST")
-	plocRouter.HandleFunc("/record-feed/search", authorizationHandler(context.searchRecordFeed, st)).Methods("POST")
-	plocRouter.HandleFunc("/record-details/read", authorizationHandler(context.readRecordDetails, st)).Methods("POST")
+	plocRouter.HandleFunc("/record-feed/read", authorizationHandler(context.readRecordFeed, context)).Methods("POST")
+	plocRouter.HandleFunc("/record-feed/search", authorizationHandler(context.searchRecordFeed, context)).Methods("POST")
+	plocRouter.HandleFunc("/record-details/read", authorizationHandler(context.readRecordDetails, context)).Methods("POST")
+	plocRouter.HandleFunc("/record-details/related", authorizationHandler(context.readRelatedRecords, context)).Methods("POST")
 
 	// Expert-Feed
-	plocRouter.HandleFunc("/expert-feed/read", authorizationHandler(context.readExpertFeed, st)).Methods("POST")
-	plocRouter.HandleFunc("/expert-feed/search", authorizationHandler(context.searchExpertFeed, st)).Methods("POST")
-	plocRouter.HandleFunc("/expert-details/read", authorizationHandler(context.readExpertDetails, st)).Methods("POST")
+	plocRouter.HandleFunc("/expert-feed/read", authorizationHandler(context.readExpertFeed, context)).Methods("POST")
+	plocRouter.HandleFunc("/expert-feed/search", authorizationHandler(context.searchExpertFeed, context)).Methods("POST")
+	plocRouter.HandleFunc("/expert-details/read", authorizationHandler(context.readExpertDetails, context)).Methods("POST")
+	plocRouter.HandleFunc("/expert-details/network", authorizationHandler(context.readExpertNetwork, context)).Methods("POST")
+	plocRouter.HandleFunc("/expert-details/path", authorizationHandler(context.readExpertPath, context)).Methods("POST")
+	plocRouter.HandleFunc("/expert-details/update", authorizationHandler(context.updateExpertDetails, context)).Methods("POST")
+
+	// Catalogue
+	plocRouter.HandleFunc("/record-catalogue/search", authorizationHandler(context.searchRecordCatalogue, context)).Methods("POST")
+	plocRouter.HandleFunc("/expert-catalogue/search", authorizationHandler(context.searchExpertCatalogue, context)).Methods("POST")
+	plocRouter.HandleFunc("/suggestions/read", authorizationHandler(context.readSuggestions, context)).Methods("POST")
 
 	// Feedback-Feed
-	plocRouter.HandleFunc("/feedback-feed/read", authorizationHandler(context.readFeedbackFeed, st)).Methods("POST")
+	plocRouter.HandleFunc("/feedback-feed/read", authorizationHandler(context.readFeedbackFeed, context)).Methods("POST")
 
 	// Record-Bookmarks
-	plocRouter.HandleFunc("/record-bookmark/collections/update", authorizationHandler(context.updateRecordBookmarkCollections, st)).Methods("POST")
-	plocRouter.HandleFunc("/record-bookmark/create", authorizationHandler(context.createRecordBookmark, st)).Methods("POST")
-	plocRouter.HandleFunc("/record-bookmark/delete", authorizationHandler(context.deleteRecordBookmark, st)).Methods("POST")
-	plocRouter.HandleFunc("/record-bookmarks/read", authorizationHandler(context.readRecordBookmarks, st)).Methods("POST")
+	plocRouter.HandleFunc("/record-bookmark/collections/update", authorizationHandler(context.updateRecordBookmarkCollections, context)).Methods("POST")
+	plocRouter.HandleFunc("/record-bookmark/create", authorizationHandler(context.createRecordBookmark, context)).Methods("POST")
+	plocRouter.HandleFunc("/record-bookmark/delete", authorizationHandler(context.deleteRecordBookmark, context)).Methods("POST")
+	plocRouter.HandleFunc("/record-bookmarks/export", authorizationHandler(context.exportRecordBookmarks, context)).Methods("POST")
+	plocRouter.HandleFunc("/record-bookmarks/read", authorizationHandler(context.readRecordBookmarks, context)).Methods("POST")
 
 	// Expert-Bookmarks
-	plocRouter.HandleFunc("/expert-bookmark/create", authorizationHandler(context.createExpertBookmark, st)).Methods("POST")
-	plocRouter.HandleFunc("/expert-bookmark/delete", authorizationHandler(context.deleteExpertBookmark, st)).Methods("POST")
-	plocRouter.HandleFunc("/expert-bookmarks/read", authorizationHandler(context.readExpertBookmarks, st)).Methods("POST")
+	plocRouter.HandleFunc("/expert-bookmark/create", authorizationHandler(context.createExpertBookmark, context)).Methods("POST")
+	plocRouter.HandleFunc("/expert-bookmark/delete", authorizationHandler(context.deleteExpertBookmark, context)).Methods("POST")
+	plocRouter.HandleFunc("/expert-bookmarks/read", authorizationHandler(context.readExpertBookmarks, context)).Methods("POST")
 
 	// Collections
-	plocRouter.HandleFunc("/collection/create", authorizationHandler(context.createCollection, st)).Methods("POST")
-	plocRouter.HandleFunc("/collection/delete", authorizationHandler(context.deleteCollection, st)).Methods("POST")
-	plocRouter.HandleFunc("/collection/update", authorizationHandler(context.updateCollection, st)).Methods("POST")
-	plocRouter.HandleFunc("/collections/read", authorizationHandler(context.readCollections, st)).Methods("POST")
+	plocRouter.HandleFunc("/collection/create", authorizationHandler(context.createCollection, context)).Methods("POST")
+	plocRouter.HandleFunc("/collection/delete", authorizationHandler(context.deleteCollection, context)).Methods("POST")
+	plocRouter.HandleFunc("/collection/update", authorizationHandler(context.updateCollection, context)).Methods("POST")
+	plocRouter.HandleFunc("/collections/read", authorizationHandler(context.readCollections, context)).Methods("POST")
 
 	// Feedback
-	plocRouter.HandleFunc("/feedback/create", authorizationHandler(context.createFeedback, st)).Methods("POST")
-	plocRouter.HandleFunc("/feedback/read", authorizationHandler(context.readFeedback, st)).Methods("POST")
+	plocRouter.HandleFunc("/feedback/create", authorizationHandler(context.createFeedback, context)).Methods("POST")
+	plocRouter.HandleFunc("/feedback/read", authorizationHandler(context.readFeedback, context)).Methods("POST")
 
 	// Personalization
-	plocRouter.HandleFunc("/record-dislike/create", authorizationHandler(context.createRecordDislike, st)).Methods("POST")
+	plocRouter.HandleFunc("/record-dislike/create", authorizationHandler(context.createRecordDislike, context)).Methods("POST")
+	plocRouter.HandleFunc("/record-recommendations/read", authorizationHandler(context.readRecordRecommendations, context)).Methods("POST")
+
+	// Syndication feeds
+	plocRouter.HandleFunc("/feed-token/create", authorizationHandler(context.createFeedToken, context)).Methods("POST")
+	plocRouter.HandleFunc("/feed-token/delete", authorizationHandler(context.deleteFeedToken, context)).Methods("POST")
+
+	// Admin request handler
+	adminRouter := router.PathPrefix("/adminapi/v1/").Subrouter()
+
+	// Users
+	adminRouter.HandleFunc("/users/read", adminHandler(context.readUsers, context)).Methods("POST")
+	adminRouter.HandleFunc("/user/delete", adminHandler(context.deleteUser, context)).Methods("POST")
+	adminRouter.HandleFunc("/user-role/update", adminHandler(context.updateUserRole, context)).Methods("POST")
+
+	// Moderation
+	adminRouter.HandleFunc("/feedback/read", adminHandler(context.readAllFeedback, context)).Methods("POST")
+	adminRouter.HandleFunc("/feedback/delete", adminHandler(context.deleteFeedback, context)).Methods("POST")
+	adminRouter.HandleFunc("/expert-claims/read", adminHandler(context.readPendingExpertClaims, context)).Methods("POST")
+	adminRouter.HandleFunc("/expert-claim/approve", adminHandler(context.approveExpertClaim, context)).Methods("POST")
+	adminRouter.HandleFunc("/expert-claim/reject", adminHandler(context.rejectExpertClaim, context)).Methods("POST")
+
+	// Maintenance
+	adminRouter.HandleFunc("/ingest-status/read", adminHandler(context.readIngestStatus, context)).Methods("POST")
+	adminRouter.HandleFunc("/search-index/build", adminHandler(context.buildSearchIndex, context)).Methods("POST")
+	adminRouter.HandleFunc("/feeds/rebuild", adminHandler(context.rebuildFeeds, context)).Methods("POST")
+	adminRouter.HandleFunc("/statistics/read", adminHandler(context.readStatistics, context)).Methods("POST")
+	adminRouter.HandleFunc("/audit-log/read", adminHandler(context.readAuditLog, context)).Methods("POST")
+
+	// Feed reader request handler
+	syndicationRouter := router.PathPrefix("/syndication").Subrouter()
+
+	// Record-Feed
+	syndicationRouter.HandleFunc("/records.atom", rateLimitHandler(feedTokenHandler(context.readRecordAtomFeed, context), context)).Methods("GET", "HEAD")
+	syndicationRouter.HandleFunc("/records.rss", rateLimitHandler(feedTokenHandler(context.readRecordRSSFeed, context), context)).Methods("GET", "HEAD")
+
+	// ORCiD request handler
+	orcIdRouter := router.PathPrefix("/orcid").Subrouter()
+
+	// Verification callback
+	orcIdRouter.HandleFunc("/callback", defaultHandler(rateLimitHandler(context.verifyExpertProfile, context))).Methods("GET")
 
 	// Download request handler
 	downloadRouter := router.PathPrefix("/download").Subrouter()
@@ -7,12 +7,15 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"sync"
 	"testing"
 )
 
 import (
 	"github.com/fzi-forschungszentrum-informatik/dream-gozer/config"
 	"github.com/fzi-forschungszentrum-informatik/dream-gozer/model/ploc"
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/orcid"
 	"github.com/fzi-forschungszentrum-informatik/dream-gozer/storage"
 	"github.com/fzi-forschungszentrum-informatik/dream-gozer/storage/ledger"
 )
@@ -20,9 +23,12 @@ import (
 type TestService struct {
 	storage *storage.Storage
 	server  *httptest.Server
+	orcid   *MockOrcIdProvider
 	t       *testing.T
 	guid    string
 	secret  string
+	token   string // access token, that is preferred over the GUID and secret
+	codes   []string
 }
 
 type TestUserProfile struct {
@@ -32,18 +38,71 @@ type TestUserProfile struct {
 	Interests       ploc.Subjects
 }
 
+// MockOrcIdProvider simulates the token endpoint of an ORCiD provider. Authorization codes are granted by the test
+// (see Grant) instead of a user signing in, and are only redeemed with the matching PKCE code verifier.
+type MockOrcIdProvider struct {
+	server *httptest.Server
+	mutex  sync.Mutex
+	grants map[string]mockOrcIdGrant // authorization codes
+}
+
+type mockOrcIdGrant struct {
+	orcId     string
+	name      string
+	challenge string
+}
+
+func NewMockOrcIdProvider() *MockOrcIdProvider {
+
+	p := &MockOrcIdProvider{grants: make(map[string]mockOrcIdGrant)}
+
+	p.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		r.ParseForm()
+
+		p.mutex.Lock()
+		grant, ok := p.grants[r.PostForm.Get("code")]
+		delete(p.grants, r.PostForm.Get("code"))
+		p.mutex.Unlock()
+
+		if r.URL.Path != "/oauth/token" || r.PostForm.Get("client_id") != "gozer-test" || !ok ||
+			orcid.Challenge(r.PostForm.Get("code_verifier")) != grant.challenge {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+
+		fmt.Fprintf(w, `{"access_token":"x","token_type":"bearer","orcid":"%s","name":"%s"}`, grant.orcId, grant.name)
+	}))
+
+	return p
+}
+
+// Grant issues an authorization code for the ORCiD of the named owner, as if the user had signed in at the provider.
+func (p *MockOrcIdProvider) Grant(code string, orcId string, name string, challenge string) {
+	p.mutex.Lock()
+	p.grants[code] = mockOrcIdGrant{orcId: orcId, name: name, challenge: challenge}
+	p.mutex.Unlock()
+}
+
 func NewTestService(t *testing.T) *TestService {
 
+	provider := NewMockOrcIdProvider()
+
 	conf := config.DefaultConfiguration()
 	conf.Storage.DBFilename = ":memory:"
 	conf.Ledger.Enable = false // Enable to test with local Ethereum testbed (e.g. Ganache)
 	conf.Ledger.RPCClient = "http://127.0.0.1:8545"
 	conf.Ledger.ContractAddress = "17e91224c30c5b0b13ba2ef1e84fe880cb902352"                    // Adress for the open feedback storage contract in the Ganache testbed.
 	conf.Ledger.PrivateKey = "6370fd033278c143179d81c5526140625662b8daa446c22ee2d73db3707e620c" // Private wallet key that is used to pay transaction fees in the Ganache testbed.
+	conf.WebAPI.OrcId.ClientId = "gozer-test"
+	conf.WebAPI.OrcId.AuthorizeURL = provider.server.URL + "/oauth/authorize"
+	conf.WebAPI.OrcId.TokenURL = provider.server.URL + "/oauth/token"
+	conf.WebAPI.RateLimit.Enable = false // Enabled by tests of the rate limits (see SetRateLimit)
 
 	storage := storage.Open(&conf.Storage)
 	ledger := ledger.Open(&conf.Ledger)
 	server := httptest.NewServer(newRouter(&conf.WebAPI, storage, ledger))
+	conf.WebAPI.OrcId.RedirectURL = server.URL + "/orcid/callback"
 
 	storage.CreateTestPublications()
 	storage.BuildSearchIndicies()
@@ -51,21 +110,42 @@ func NewTestService(t *testing.T) *TestService {
 	return &TestService{
 		storage: storage,
 		server:  server,
+		orcid:   provider,
 		t:       t,
 		guid:    "",
 		secret:  "",
 	}
 }
 
+// SetRateLimit restarts the test server with the specified rate limits. The ledger is disabled.
+func (ts *TestService) SetRateLimit(limit config.RateLimitConfiguration) {
+
+	conf := config.DefaultConfiguration()
+	conf.WebAPI.RateLimit = limit
+
+	ts.server.Close()
+	ts.server = httptest.NewServer(newRouter(&conf.WebAPI, ts.storage, nil))
+}
+
 func (ts *TestService) Close() {
 	ts.storage.Close()
 	ts.server.Close()
+	ts.orcid.server.Close()
 }
 
 // postTestRequest() sends a JSON-encoded post request to a test server and returns a JSON-decoded response.
 // This function is for test purposes only. If no JSON datastructure should be send or received 'nil' can be used.
 // A user Id and passward can also be used to send HTTP basic authentication.
 func (ts *TestService) PostRequest(urlPostfix string, request interface{}, response interface{}) (statusCode int, err error) {
+	return ts.postRequest("/plocapi/v1"+urlPostfix, request, response)
+}
+
+// PostAdminRequest sends a request to the admin API like PostRequest does to the ploc API.
+func (ts *TestService) PostAdminRequest(urlPostfix string, request interface{}, response interface{}) (statusCode int, err error) {
+	return ts.postRequest("/adminapi/v1"+urlPostfix, request, response)
+}
+
+func (ts *TestService) postRequest(urlPath string, request interface{}, response interface{}) (statusCode int, err error) {
 
 	var jData []byte
 
@@ -80,14 +160,16 @@ func (ts *TestService) PostRequest(urlPostfix string, request interface{}, respo
 
 	// Send post request with JSON payload.
 
-	url := ts.server.URL + "/plocapi/v1" + urlPostfix
+	url := ts.server.URL + urlPath
 
 	req, _ := http.NewRequest("POST", url, bytes.NewBuffer(jData))
 	if request != nil {
 		req.Header.Set("Content-Type", "application/json")
 	}
 
-	if ts.guid != "" {
+	if ts.token != "" {
+		req.Header.Set("Authorization", "Bearer "+ts.token)
+	} else if ts.guid != "" {
 		req.SetBasicAuth(ts.guid, ts.secret)
 	}
 
@@ -125,6 +207,23 @@ func (ts *TestService) PostRequestOK(urlPostfix string, request interface{}, res
 	return
 }
 
+func (ts *TestService) PostAdminRequestOK(urlPostfix string, request interface{}, response interface{}) {
+
+	statusCode, err := ts.PostAdminRequest(urlPostfix, request, response)
+
+	if err != nil {
+		ts.t.Errorf("Unexpected error. %s", err)
+		return
+	}
+
+	if statusCode != http.StatusOK {
+		ts.t.Errorf("Expected HTTP.StatusOK but get: %d", statusCode)
+		return
+	}
+
+	return
+}
+
 func (ts *TestService) CreateCollection(title string) (response ploc.CreateCollectionResponse) {
 	request := ploc.CreateCollectionRequest{Title: title}
 	ts.PostRequestOK("/collection/create", &request, &response)
@@ -137,6 +236,12 @@ func (ts *TestService) CreateExpertBookmark(expertId int64) {
 	return
 }
 
+func (ts *TestService) CreateExpertClaim(expertId int64) (response ploc.CreateExpertClaimResponse) {
+	request := ploc.CreateExpertClaimRequest{ExpertId: expertId}
+	ts.PostRequestOK("/expert-claim/create", &request, &response)
+	return
+}
+
 func (ts *TestService) CreateExpertProfile(orcId string) {
 	request := ploc.CreateExpertProfileRequest{OrcId: orcId}
 	ts.PostRequestOK("/expert-profile/create", &request, nil)
@@ -156,6 +261,11 @@ func (ts *TestService) CreateFeedback(recordId int64, relevance int64, presentat
 	return
 }
 
+func (ts *TestService) CreateFeedToken() (response ploc.CreateFeedTokenResponse) {
+	ts.PostRequestOK("/feed-token/create", nil, &response)
+	return
+}
+
 func (ts *TestService) CreateInterest(subjectId int64) (response ploc.CreateInterestResponse) {
 
 	request := ploc.CreateInterestRequest{SubjectId: subjectId}
@@ -176,6 +286,17 @@ func (ts *TestService) CreateRecordDislike(recordId int64) {
 	return
 }
 
+func (ts *TestService) CreateRecoveryCodes() (response ploc.CreateRecoveryCodesResponse) {
+	ts.PostRequestOK("/recovery-codes/create", nil, &response)
+	return
+}
+
+func (ts *TestService) CreateSession() (response ploc.CreateSessionResponse) {
+	request := ploc.CreateSessionRequest{GUID: ts.guid, Secret: ts.secret}
+	ts.PostRequestOK("/session/create", &request, &response)
+	return
+}
+
 func (ts *TestService) CreateUserProfile() {
 
 	var response ploc.CreateUserProfileResponse
@@ -187,6 +308,7 @@ func (ts *TestService) CreateUserProfile() {
 
 	ts.guid = response.GUID
 	ts.secret = secret
+	ts.codes = response.RecoveryCodes
 
 	return
 }
@@ -249,10 +371,10 @@ func (ts *TestService) CreateUserProfileWithData() (user TestUserProfile) {
 
 	user.ExpertBookmarks = ts.ReadExpertBookmarks().Bookmarks
 
-	// Register dummy ORCiD profile to gain access to feedback feed
+	// Verify dummy ORCiD profile to gain access to feedback feed
 
 	someOrcId := "0000-0001-5393-1421"
-	ts.CreateExpertProfile(someOrcId)
+	ts.VerifyExpertProfile(someOrcId, "Test Expert")
 
 	return
 }
@@ -274,6 +396,10 @@ func (ts *TestService) DeleteExpertProfile() {
 	return
 }
 
+func (ts *TestService) DeleteFeedToken() {
+	ts.PostRequestOK("/feed-token/delete", nil, nil)
+}
+
 func (ts *TestService) DeleteInterest(subjectId int64) (response ploc.DeleteInterestResponse) {
 	request := ploc.DeleteInterestRequest{SubjectId: subjectId}
 	ts.PostRequestOK("/interest/delete", &request, &response)
@@ -286,11 +412,62 @@ func (ts *TestService) DeleteRecordBookmark(recordId int64) {
 	return
 }
 
+func (ts *TestService) DeleteSession(refreshToken string, all bool) {
+	request := ploc.DeleteSessionRequest{RefreshToken: refreshToken, All: all}
+	ts.PostRequestOK("/session/delete", &request, nil)
+	return
+}
+
 func (ts *TestService) DeleteUserProfile() {
 	ts.PostRequestOK("/user-profile/delete", nil, nil)
 	return
 }
 
+func (ts *TestService) ExportRecordBookmarks(collectionId int64, format string, accept string) (statusCode int, contentType string, body string) {
+
+	request := ploc.ExportRecordBookmarksRequest{CollectionId: collectionId, Format: format}
+	jData, _ := json.Marshal(&request)
+
+	req, _ := http.NewRequest("POST", ts.server.URL+"/plocapi/v1/record-bookmarks/export", bytes.NewBuffer(jData))
+	req.Header.Set("Content-Type", "application/json")
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	req.SetBasicAuth(ts.guid, ts.secret)
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		ts.t.Errorf("Unexpected error. %s", err)
+		return
+	}
+	defer resp.Body.Close()
+
+	data, _ := ioutil.ReadAll(resp.Body)
+
+	return resp.StatusCode, resp.Header.Get("Content-Type"), string(data)
+}
+
+func (ts *TestService) GetFeed(feedURL string, header map[string]string) (resp *http.Response, body string) {
+
+	req, _ := http.NewRequest("GET", feedURL, nil)
+	for name, value := range header {
+		req.Header.Set(name, value)
+	}
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		ts.t.Errorf("Unexpected error. %s", err)
+		return
+	}
+	defer resp.Body.Close()
+
+	data, _ := ioutil.ReadAll(resp.Body)
+
+	return resp, string(data)
+}
+
 func (ts *TestService) ReadCollections() (response ploc.ReadCollectionsResponse) {
 	ts.PostRequestOK("/collections/read", nil, &response)
 	return
@@ -301,6 +478,11 @@ func (ts *TestService) ReadExpertBookmarks() (response ploc.ReadExpertBookmarksR
 	return
 }
 
+func (ts *TestService) ReadExpertClaims() (response ploc.ReadExpertClaimsResponse) {
+	ts.PostRequestOK("/expert-claims/read", nil, &response)
+	return
+}
+
 func (ts *TestService) ReadExpertDetails(expertId int64) (response ploc.ReadExpertDetailsResponse) {
 	request := ploc.ReadExpertDetailsRequest{ExpertId: expertId}
 	ts.PostRequestOK("/expert-details/read", &request, &response)
@@ -313,6 +495,18 @@ func (ts *TestService) ReadExpertFeed(offset int64, limit int64) (response ploc.
 	return
 }
 
+func (ts *TestService) ReadExpertNetwork(expertId int64, limit int64) (response ploc.ReadExpertNetworkResponse) {
+	request := ploc.ReadExpertNetworkRequest{ExpertId: expertId, Limit: limit}
+	ts.PostRequestOK("/expert-details/network", &request, &response)
+	return
+}
+
+func (ts *TestService) ReadExpertPath(fromId int64, toId int64) (response ploc.ReadExpertPathResponse) {
+	request := ploc.ReadExpertPathRequest{FromId: fromId, ToId: toId}
+	ts.PostRequestOK("/expert-details/path", &request, &response)
+	return
+}
+
 func (ts *TestService) ReadExpertProfile() (response ploc.ReadExpertProfileResponse) {
 	ts.PostRequestOK("/expert-profile/read", nil, &response)
 	return
@@ -352,22 +546,70 @@ func (ts *TestService) ReadRecordFeed(offset int64, limit int64) (response ploc.
 	return
 }
 
+func (ts *TestService) ReadFilteredRecordFeed(filter ploc.RecordFeedFilter, offset int64, limit int64) (response ploc.ReadRecordFeedResponse) {
+	request := ploc.ReadRecordFeedRequest{Offset: offset, Limit: limit, Filter: filter}
+	ts.PostRequestOK("/record-feed/read", &request, &response)
+	return
+}
+
+func (ts *TestService) ReadRecordRecommendations(offset int64, limit int64) (response ploc.ReadRecordRecommendationsResponse) {
+	request := ploc.ReadRecordRecommendationsRequest{Offset: offset, Limit: limit}
+	ts.PostRequestOK("/record-recommendations/read", &request, &response)
+	return
+}
+
 func (ts *TestService) ReadRecordTypes() (response ploc.ReadRecordTypesResponse) {
 	ts.PostRequestOK("/record-types/read", nil, &response)
 	return
 }
 
+func (ts *TestService) ReadRelatedRecords(recordId int64, limit int64) (response ploc.ReadRelatedRecordsResponse) {
+	request := ploc.ReadRelatedRecordsRequest{RecordId: recordId, Limit: limit}
+	ts.PostRequestOK("/record-details/related", &request, &response)
+	return
+}
+
 func (ts *TestService) ReadSubjects() (response ploc.ReadSubjectsResponse) {
 	ts.PostRequestOK("/subjects/read", nil, &response)
 	return
 }
 
+func (ts *TestService) ReadSuggestions(input string, limit int64) (response ploc.ReadSuggestionsResponse) {
+	request := ploc.ReadSuggestionsRequest{Input: input, Limit: limit}
+	ts.PostRequestOK("/suggestions/read", &request, &response)
+	return
+}
+
+func (ts *TestService) RefreshSession(refreshToken string) (response ploc.RefreshSessionResponse) {
+	request := ploc.RefreshSessionRequest{RefreshToken: refreshToken}
+	ts.PostRequestOK("/session/refresh", &request, &response)
+	return
+}
+
+func (ts *TestService) SearchExpertCatalogue(searchTerm string, offset int64, limit int64) (response ploc.SearchExpertCatalogueResponse) {
+	request := ploc.SearchExpertCatalogueRequest{SearchTerm: searchTerm, Offset: offset, Limit: limit}
+	ts.PostRequestOK("/expert-catalogue/search", &request, &response)
+	return
+}
+
 func (ts *TestService) SearchExpertFeed(searchTerm string, offset int64, limit int64) (response ploc.SearchExpertFeedResponse) {
 	request := ploc.SearchExpertFeedRequest{SearchTerm: searchTerm, Offset: offset, Limit: limit}
 	ts.PostRequestOK("/expert-feed/search", &request, &response)
 	return
 }
 
+func (ts *TestService) SearchRecordCatalogue(searchTerm string, offset int64, limit int64) (response ploc.SearchRecordCatalogueResponse) {
+	request := ploc.SearchRecordCatalogueRequest{SearchTerm: searchTerm, Offset: offset, Limit: limit}
+	ts.PostRequestOK("/record-catalogue/search", &request, &response)
+	return
+}
+
+func (ts *TestService) SearchFilteredRecordFeed(searchTerm string, filter ploc.RecordFeedFilter, offset int64, limit int64) (response ploc.SearchRecordFeedResponse) {
+	request := ploc.SearchRecordFeedRequest{SearchTerm: searchTerm, Offset: offset, Limit: limit, Filter: filter}
+	ts.PostRequestOK("/record-feed/search", &request, &response)
+	return
+}
+
 func (ts *TestService) SearchRecordFeed(searchTerm string, offset int64, limit int64) (response ploc.SearchRecordFeedResponse) {
 	request := ploc.SearchRecordFeedRequest{SearchTerm: searchTerm, Offset: offset, Limit: limit}
 	ts.PostRequestOK("/record-feed/search", &request, &response)
@@ -385,3 +627,45 @@ func (ts *TestService) UpdateRecordBookmarkCollections(recordId int64, collectio
 	ts.PostRequestOK("/record-bookmark/collections/update", &request, nil)
 	return
 }
+
+func (ts *TestService) UpdateExpertDetails(expertId int64, affiliation string) {
+	request := ploc.UpdateExpertDetailsRequest{ExpertId: expertId, Affiliation: affiliation}
+	ts.PostRequestOK("/expert-details/update", &request, nil)
+	return
+}
+
+func (ts *TestService) UpdateUserSecret(secret string) {
+	request := ploc.UpdateUserSecretRequest{CurrentSecret: ts.secret, Secret: secret}
+	ts.PostRequestOK("/user-profile/update-secret", &request, nil)
+	ts.secret = secret
+	return
+}
+
+// VerifyExpertProfile runs the OAuth2 flow for verifying an ORCiD of the named owner against the mock provider and
+// returns the HTTP status of GoZer's callback.
+func (ts *TestService) VerifyExpertProfile(orcId string, name string) (statusCode int) {
+
+	var response ploc.CreateOrcIdAuthorizationResponse
+
+	ts.PostRequestOK("/expert-profile/authorize", nil, &response)
+
+	authURL, err := url.Parse(response.AuthorizationURL)
+	if err != nil {
+		ts.t.Errorf("Unexpected error. %s", err)
+		return
+	}
+
+	params := authURL.Query()
+	code := "code-" + params.Get("state")
+
+	ts.orcid.Grant(code, orcId, name, params.Get("code_challenge"))
+
+	resp, err := http.Get(params.Get("redirect_uri") + "?" + url.Values{"code": {code}, "state": {params.Get("state")}}.Encode())
+	if err != nil {
+		ts.t.Errorf("Unexpected error. %s", err)
+		return
+	}
+	defer resp.Body.Close()
+
+	return resp.StatusCode
+}
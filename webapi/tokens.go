@@ -0,0 +1,79 @@
+package webapi
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+import (
+	"github.com/fzi-forschungszentrum-informatik/dream-gozer/model/ploc"
+)
+
+// sessionTokens returns a fresh access token together with the refresh token of a session.
+func (c *Context) sessionTokens(sessionId int64, refreshToken string) ploc.SessionTokens {
+
+	expiry := time.Duration(c.conf.AccessTokenExpiry) * time.Minute
+
+	return ploc.SessionTokens{
+		AccessToken:  signAccessToken(c.tokenKey, sessionId, time.Now().Add(expiry)),
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(expiry / time.Second),
+		RefreshToken: refreshToken,
+	}
+}
+
+// refreshTokenExpiry returns the time when a refresh token, that is issued now, expires.
+func (c *Context) refreshTokenExpiry() time.Time {
+	return time.Now().Add(time.Duration(c.conf.RefreshTokenExpiry) * time.Hour)
+}
+
+// signAccessToken creates an access token, that authenticates requests of a session until it expires. The token
+// consists of the session ID, the expiry (Unix time) and a HMAC-SHA256 signature of both, separated by dots.
+func signAccessToken(key []byte, sessionId int64, expires time.Time) string {
+	payload := fmt.Sprintf("%d.%d", sessionId, expires.Unix())
+	return payload + "." + accessTokenSignature(key, payload)
+}
+
+// verifyAccessToken checks the signature and the expiry of an access token and returns its session ID.
+// Whether the session was revoked in the meantime, has to be checked separately.
+func verifyAccessToken(key []byte, token string) (sessionId int64, err error) {
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return 0, fmt.Errorf("Access token is malformed.")
+	}
+
+	payload := parts[0] + "." + parts[1]
+
+	if !hmac.Equal([]byte(parts[2]), []byte(accessTokenSignature(key, payload))) {
+		return 0, fmt.Errorf("Signature of access token is invalid.")
+	}
+
+	sessionId, err = strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("Session of access token is malformed. %s", err)
+	}
+
+	expires, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("Expiry of access token is malformed. %s", err)
+	}
+
+	if time.Now().Unix() >= expires {
+		return 0, fmt.Errorf("Access token of session %d has expired.", sessionId)
+	}
+
+	return sessionId, nil
+}
+
+// accessTokenSignature returns the URL-safe, base64-encoded HMAC-SHA256 signature of an access token's payload.
+func accessTokenSignature(key []byte, payload string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}